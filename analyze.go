@@ -0,0 +1,129 @@
+package regula
+
+import (
+	"fmt"
+
+	"github.com/heetch/regula/rule"
+)
+
+// Kinds of findings reported by Analyze.
+const (
+	// FindingUnreachable is reported for a rule evaluated after a rule that always matches.
+	FindingUnreachable = "unreachable"
+	// FindingShadowed is reported for a rule that can only match when a rule evaluated before it matches.
+	FindingShadowed = "shadowed"
+	// FindingDuplicate is reported for a rule with the same condition and result as a rule evaluated before it.
+	FindingDuplicate = "duplicate"
+	// FindingContradiction is reported for a rule which condition can never be true.
+	FindingContradiction = "contradiction"
+	// FindingNoCatchAll is reported for a ruleset with no rule that always matches and no default result,
+	// which evaluation can return rule.ErrNoMatch.
+	FindingNoCatchAll = "no-catch-all"
+)
+
+// A Finding describes a problem detected by Analyze.
+type Finding struct {
+	Kind string `json:"kind"`
+	// Rule is the index of the rule concerned by the finding, -1 if it concerns the whole ruleset.
+	Rule int `json:"rule"`
+	// Cause is the index of the rule causing the finding, like the rule shadowing Rule, -1 if there is none.
+	Cause   int    `json:"cause"`
+	Message string `json:"message"`
+}
+
+func (f Finding) String() string {
+	if f.Rule == -1 {
+		return f.Message
+	}
+
+	return fmt.Sprintf("/rules/%d: %s", f.Rule, f.Message)
+}
+
+// Analyze inspects the rules of the ruleset, without evaluating them, and reports the rules that never match
+// or that are never evaluated, the duplicate rules and the lack of catch-all rule.
+// Rules are compared in order of evaluation. Disabled rules are ignored and rules with a validity window
+// are never considered to prevent the evaluation of the following ones, nor are the rules which result
+// can fail to match because of a missing param, see Ruleset.MissingParams.
+// The analysis only detects the trivial cases, for example a rule is reported as shadowed if its conditions
+// include all the conditions of a preceding rule. The ruleset is expected to be valid.
+func Analyze(rs *Ruleset) []Finding {
+	var findings []Finding
+	var prev []int
+	catchAll := -1
+
+	for _, i := range rs.order() {
+		rl := rs.Rules[i]
+		if rl.Enabled != nil && !*rl.Enabled {
+			continue
+		}
+
+		if f, ok := analyzeRule(rs, prev, catchAll, i); ok {
+			findings = append(findings, f)
+		}
+
+		if rl.ValidFrom != nil || rl.ValidUntil != nil {
+			continue
+		}
+		if catchAll == -1 && rule.Tautology(rl.Expr) && !rs.mayNotMatch(rl.Expr) && !rs.mayNotMatch(rl.Result) {
+			catchAll = i
+		}
+		prev = append(prev, i)
+	}
+
+	if catchAll == -1 && rs.Default == nil {
+		findings = append(findings, Finding{
+			Kind:    FindingNoCatchAll,
+			Rule:    -1,
+			Cause:   -1,
+			Message: "no rule always matches and the ruleset has no default result",
+		})
+	}
+
+	return findings
+}
+
+// analyzeRule compares the rule i with the rules that are always evaluated before it, in order of evaluation,
+// and returns the first problem found.
+func analyzeRule(rs *Ruleset, prev []int, catchAll, i int) (Finding, bool) {
+	rl := rs.Rules[i]
+
+	if msg := rule.Contradiction(rl.Expr); msg != "" {
+		return Finding{Kind: FindingContradiction, Rule: i, Cause: -1, Message: "the rule never matches, " + msg}, true
+	}
+
+	for _, j := range prev {
+		if rule.SameExpr(rl.Expr, rs.Rules[j].Expr) && rule.SameExpr(rl.Result, rs.Rules[j].Result) {
+			return Finding{Kind: FindingDuplicate, Rule: i, Cause: j, Message: fmt.Sprintf("the rule is a duplicate of rule %d", j)}, true
+		}
+	}
+
+	// with strategies combining the results, every rule is evaluated
+	if rs.combines() {
+		return Finding{}, false
+	}
+
+	if catchAll != -1 {
+		return Finding{Kind: FindingUnreachable, Rule: i, Cause: catchAll, Message: fmt.Sprintf("the rule is never evaluated, rule %d always matches", catchAll)}, true
+	}
+
+	for _, j := range prev {
+		if rule.Implies(rl.Expr, rs.Rules[j].Expr) && !rs.mayNotMatch(rs.Rules[j].Result) {
+			return Finding{Kind: FindingShadowed, Rule: i, Cause: j, Message: fmt.Sprintf("the rule only matches when rule %d matches, which is evaluated first", j)}, true
+		}
+	}
+
+	return Finding{}, false
+}
+
+// mayNotMatch reports whether the evaluation of e can fail because of a missing param without stopping
+// the evaluation of the ruleset, in which case the rule doesn't match and the next one is evaluated.
+// It is the case of optional params, and of every param with the no-match missing params policy.
+func (r *Ruleset) mayNotMatch(e rule.Expr) bool {
+	for _, p := range rule.ExprParams(e) {
+		if p.Optional || r.MissingParams == MissingParamsNoMatch {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,104 @@
+package regula
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		expected []Finding
+	}{
+		{"Clean", `
+			city == "paris" and age:int64 > 18 -> 1
+			city == "paris" -> 2
+			true -> 3
+		`, nil},
+		{"Unreachable", `
+			city == "paris" -> 1
+			true -> 2
+			city == "lyon" -> 3
+		`, []Finding{
+			{Kind: FindingUnreachable, Rule: 2, Cause: 1, Message: "the rule is never evaluated, rule 1 always matches"},
+		}},
+		{"Shadowed", `
+			city == "paris" -> 1
+			age:int64 > 18 and city == "paris" -> 2
+			true -> 3
+		`, []Finding{
+			{Kind: FindingShadowed, Rule: 1, Cause: 0, Message: "the rule only matches when rule 0 matches, which is evaluated first"},
+		}},
+		{"Duplicate", `
+			city == "paris" -> 1
+			city == "paris" -> 1
+			true -> 3
+		`, []Finding{
+			{Kind: FindingDuplicate, Rule: 1, Cause: 0, Message: "the rule is a duplicate of rule 0"},
+		}},
+		{"Contradiction", `
+			city == "a" and city == "b" -> 1
+			true -> 3
+		`, []Finding{
+			{Kind: FindingContradiction, Rule: 0, Cause: -1, Message: `the rule never matches, city can't be equal to both "a" and "b"`},
+		}},
+		{"No catch-all", `
+			city == "paris" -> 1
+		`, []Finding{
+			{Kind: FindingNoCatchAll, Rule: -1, Cause: -1, Message: "no rule always matches and the ruleset has no default result"},
+		}},
+		{"Default", `
+			default 0
+			city == "paris" -> 1
+		`, nil},
+		{"Priority", `
+			strategy priority
+			true -> 1
+			city == "paris" -> 2 priority 1
+		`, nil},
+		{"Combining strategy", `
+			strategy sum
+			true -> 1
+			city == "paris" -> 2
+			city == "paris" -> 2
+		`, []Finding{
+			{Kind: FindingDuplicate, Rule: 2, Cause: 1, Message: "the rule is a duplicate of rule 1"},
+		}},
+		{"Disabled rule", `
+			@enabled false
+			true -> 1
+			city == "paris" -> 2
+			true -> 3
+		`, nil},
+		{"Optional result", `
+			true -> x:int64?
+			true -> 0
+		`, nil},
+		{"Missing params no match", `
+			missing-params no-match
+			city == "paris" -> x:int64
+			city == "paris" and age:int64 > 18 -> 1
+			true -> x:int64
+			true -> 0
+		`, nil},
+		{"Validity window", `
+			@valid-until "2030-01-01T00:00:00Z"
+			true -> 1
+			city == "paris" -> 2
+			true -> 3
+		`, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rs, err := ParseRuleset(test.src)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, Analyze(rs))
+		})
+	}
+
+	f := Finding{Kind: FindingDuplicate, Rule: 1, Cause: 0, Message: "the rule is a duplicate of rule 0"}
+	require.Equal(t, "/rules/1: the rule is a duplicate of rule 0", f.String())
+}
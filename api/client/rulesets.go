@@ -65,6 +65,17 @@ func (s *RulesetService) Eval(ctx context.Context, path string, params rule.Para
 // EvalVersion evaluates the given ruleset version with the given params.
 // It implements the regula.Evaluator interface and thus can be passed to the regula.Engine.
 func (s *RulesetService) EvalVersion(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error) {
+	return s.eval(ctx, path, version, params, false)
+}
+
+// EvalTrace evaluates the given ruleset version, or the latest one if version is empty, with the given params
+// and returns the trace of the evaluation, which is also returned if the evaluation fails.
+// It implements the regula.TraceEvaluator interface and thus can be passed to the regula.Engine.
+func (s *RulesetService) EvalTrace(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error) {
+	return s.eval(ctx, path, version, params, true)
+}
+
+func (s *RulesetService) eval(ctx context.Context, path, version string, params rule.Params, trace bool) (*regula.EvalResult, error) {
 	req, err := s.client.newRequest("GET", s.joinPath(path), nil)
 	if err != nil {
 		return nil, err
@@ -83,19 +94,23 @@ func (s *RulesetService) EvalVersion(ctx context.Context, path, version string,
 	if version != "" {
 		q.Add("version", version)
 	}
+	if trace {
+		q.Add("trace", "")
+	}
 	req.URL.RawQuery = q.Encode()
 
 	var resp api.EvalResult
 
 	_, err = s.client.try(ctx, req, &resp)
 	if err != nil {
+		// the error holds the trace of the evaluation which failed, if requested
+		if apiErr, ok := err.(*api.Error); ok && apiErr.Trace != nil {
+			return &regula.EvalResult{Trace: apiErr.Trace}, err
+		}
 		return nil, err
 	}
 
-	return &regula.EvalResult{
-		Value:   resp.Value,
-		Version: resp.Version,
-	}, nil
+	return (*regula.EvalResult)(&resp), nil
 }
 
 // Put creates a ruleset version on the given path.
@@ -111,6 +126,54 @@ func (s *RulesetService) Put(ctx context.Context, path string, rs *regula.Rulese
 	return &resp, err
 }
 
+// Diff compares the behaviour of two versions of the ruleset stored on the given path and returns
+// the params for which their results differ, see regula.Diff. If newVersion is empty, the latest version is used.
+func (s *RulesetService) Diff(ctx context.Context, path, oldVersion, newVersion string) (*api.Diff, error) {
+	req, err := s.client.newRequest("GET", s.joinPath(path), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("diff", "")
+	q.Add("old", oldVersion)
+	if newVersion != "" {
+		q.Add("new", newVersion)
+	}
+	req.URL.RawQuery = q.Encode()
+
+	var resp api.Diff
+
+	_, err = s.client.try(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+// Validate analyzes the given ruleset without storing it and returns the problems found, see regula.Analyze.
+// It returns an error if the ruleset is invalid.
+func (s *RulesetService) Validate(ctx context.Context, rs *regula.Ruleset) (*api.Analysis, error) {
+	req, err := s.client.newRequest("POST", s.joinPath(""), rs)
+	if err != nil {
+		return nil, err
+	}
+
+	q := req.URL.Query()
+	q.Add("validate", "")
+	req.URL.RawQuery = q.Encode()
+
+	var resp api.Analysis
+
+	_, err = s.client.try(ctx, req, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
 // WatchResponse contains a list of events occured on a group of rulesets.
 // If an error occurs during the watching, the Err field will be populated.
 type WatchResponse struct {
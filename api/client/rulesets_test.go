@@ -265,6 +265,61 @@ func TestRulesetService(t *testing.T) {
 		require.Equal(t, &exp, resp)
 	})
 
+	t.Run("EvalRuleset/Trace", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Contains(t, r.URL.Query(), "eval")
+			assert.Contains(t, r.URL.Query(), "trace")
+			assert.NotContains(t, r.URL.Query(), "version")
+			assert.Equal(t, "/rulesets/path/to/ruleset", r.URL.Path)
+			fmt.Fprintf(w, `{"value": {"data": "baz", "type": "string", "kind": "value"}, "version": "1234", "trace": {"rule": 0, "rules": [{"expr": {"kind": "value", "value": {"data": "true", "type": "bool", "kind": "value"}}, "matched": true}]}}`)
+		}))
+		defer ts.Close()
+
+		cli, err := client.New(ts.URL)
+		require.NoError(t, err)
+		cli.Logger = zerolog.New(ioutil.Discard)
+
+		exp := regula.EvalResult{
+			Value:   rule.StringValue("baz"),
+			Version: "1234",
+			Trace: &regula.RulesetTrace{
+				Rules: []*rule.RuleTrace{{
+					Expr:    &rule.Trace{Kind: "value", Value: rule.BoolValue(true)},
+					Matched: true,
+				}},
+			},
+		}
+
+		resp, err := cli.Rulesets.EvalTrace(context.Background(), "path/to/ruleset", "", regula.Params{
+			"foo": "bar",
+		})
+		require.NoError(t, err)
+		require.Equal(t, &exp, resp)
+	})
+
+	t.Run("EvalRuleset/Trace/NoMatch", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"error": "no match", "trace": {"rule": -1, "rules": [{"expr": {"kind": "value", "value": {"data": "false", "type": "bool", "kind": "value"}}, "matched": false}]}}`)
+		}))
+		defer ts.Close()
+
+		cli, err := client.New(ts.URL)
+		require.NoError(t, err)
+		cli.Logger = zerolog.New(ioutil.Discard)
+
+		resp, err := cli.Rulesets.EvalTrace(context.Background(), "path/to/ruleset", "", regula.Params{
+			"foo": "bar",
+		})
+		require.Error(t, err)
+		require.Equal(t, &regula.RulesetTrace{
+			Rule: -1,
+			Rules: []*rule.RuleTrace{{
+				Expr: &rule.Trace{Kind: "value", Value: rule.BoolValue(false)},
+			}},
+		}, resp.Trace)
+	})
+
 	t.Run("PutRuleset", func(t *testing.T) {
 		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			assert.NotEmpty(t, r.Header.Get("User-Agent"))
@@ -288,6 +343,55 @@ func TestRulesetService(t *testing.T) {
 		require.Equal(t, "v", ars.Version)
 	})
 
+	t.Run("DiffRuleset", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/rulesets/a", r.URL.Path)
+			assert.Equal(t, "v1", r.URL.Query().Get("old"))
+			assert.Equal(t, "v2", r.URL.Query().Get("new"))
+			_, ok := r.URL.Query()["diff"]
+			assert.True(t, ok)
+			fmt.Fprintf(w, `{"path": "a", "oldVersion": "v1", "newVersion": "v2", "differences": [{"params": {"city": "lyon"}, "old": {"error": "no match"}, "new": {"value": {"kind": "value", "type": "int64", "data": "1"}}}]}`)
+		}))
+		defer ts.Close()
+
+		cli, err := client.New(ts.URL)
+		require.NoError(t, err)
+		cli.Logger = zerolog.New(ioutil.Discard)
+
+		d, err := cli.Rulesets.Diff(context.Background(), "a", "v1", "v2")
+		require.NoError(t, err)
+		require.Equal(t, &api.Diff{
+			Path:       "a",
+			OldVersion: "v1",
+			NewVersion: "v2",
+			Differences: []regula.Difference{
+				{Params: map[string]string{"city": "lyon"}, Old: regula.Outcome{Error: "no match"}, New: regula.Outcome{Value: rule.Int64Value(1)}},
+			},
+		}, d)
+	})
+
+	t.Run("ValidateRuleset", func(t *testing.T) {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "POST", r.Method)
+			assert.Equal(t, "/rulesets/", r.URL.Path)
+			_, ok := r.URL.Query()["validate"]
+			assert.True(t, ok)
+			fmt.Fprintf(w, `{"findings": [{"kind": "no-catch-all", "rule": -1, "cause": -1, "message": "msg"}]}`)
+		}))
+		defer ts.Close()
+
+		cli, err := client.New(ts.URL)
+		require.NoError(t, err)
+		cli.Logger = zerolog.New(ioutil.Discard)
+
+		rs, err := regula.NewInt64Ruleset(rule.New(rule.BoolParam("a"), rule.Int64Value(1)))
+		require.NoError(t, err)
+
+		a, err := cli.Rulesets.Validate(context.Background(), rs)
+		require.NoError(t, err)
+		require.Equal(t, []regula.Finding{{Kind: regula.FindingNoCatchAll, Rule: -1, Cause: -1, Message: "msg"}}, a.Findings)
+	})
+
 	t.Run("WatchRuleset", func(t *testing.T) {
 		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			assert.NotEmpty(t, r.Header.Get("User-Agent"))
@@ -2,7 +2,6 @@ package server
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -48,11 +47,20 @@ func (s *rulesetService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			s.eval(w, r, path)
 			return
 		}
+		if _, ok := r.URL.Query()["diff"]; ok {
+			s.diff(w, r, path)
+			return
+		}
 	case "PUT":
 		if path != "" {
 			s.put(w, r, path)
 			return
 		}
+	case "POST":
+		if _, ok := r.URL.Query()["validate"]; ok {
+			s.validate(w, r)
+			return
+		}
 	}
 
 	w.WriteHeader(http.StatusNotFound)
@@ -106,12 +114,14 @@ func (s *rulesetService) eval(w http.ResponseWriter, r *http.Request, path strin
 	var err error
 	var res *regula.EvalResult
 
-	params := make(params)
+	params := make(regula.StringParams)
 	for k, v := range r.URL.Query() {
 		params[k] = v[0]
 	}
 
-	if v, ok := r.URL.Query()["version"]; ok {
+	if _, ok := r.URL.Query()["trace"]; ok {
+		res, err = s.rulesets.EvalTrace(r.Context(), path, r.URL.Query().Get("version"), params)
+	} else if v, ok := r.URL.Query()["version"]; ok {
 		res, err = s.rulesets.EvalVersion(r.Context(), path, v[0], params)
 	} else {
 		res, err = s.rulesets.Eval(r.Context(), path, params)
@@ -123,10 +133,21 @@ func (s *rulesetService) eval(w http.ResponseWriter, r *http.Request, path strin
 			return
 		}
 
+		// when requested, the trace explains why the evaluation failed
+		var trace *regula.RulesetTrace
+		if res != nil {
+			trace = res.Trace
+		}
+
 		if err == rule.ErrParamNotFound ||
 			err == rule.ErrParamTypeMismatch ||
 			err == rule.ErrNoMatch {
-			s.writeError(w, r, err, http.StatusBadRequest)
+			s.writeErrorTrace(w, r, err, trace, http.StatusBadRequest)
+			return
+		}
+
+		if _, ok := err.(*regula.ParamError); ok {
+			s.writeErrorTrace(w, r, err, trace, http.StatusBadRequest)
 			return
 		}
 
@@ -137,6 +158,61 @@ func (s *rulesetService) eval(w http.ResponseWriter, r *http.Request, path strin
 	s.encodeJSON(w, r, (*api.EvalResult)(res), http.StatusOK)
 }
 
+// diff compares the behaviour of two versions of a ruleset.
+// The old version is required, the new one defaults to the latest version.
+func (s *rulesetService) diff(w http.ResponseWriter, r *http.Request, path string) {
+	oldVersion := r.URL.Query().Get("old")
+	if oldVersion == "" {
+		s.writeError(w, r, errors.New("missing old version"), http.StatusBadRequest)
+		return
+	}
+
+	old, err := s.rulesets.OneByVersion(r.Context(), path, oldVersion)
+	if err != nil {
+		s.writeEntryError(w, r, path, err)
+		return
+	}
+
+	var cur *store.RulesetEntry
+	if v := r.URL.Query().Get("new"); v != "" {
+		cur, err = s.rulesets.OneByVersion(r.Context(), path, v)
+	} else {
+		cur, err = s.rulesets.Latest(r.Context(), path)
+	}
+	if err != nil {
+		s.writeEntryError(w, r, path, err)
+		return
+	}
+
+	diffs, err := regula.Diff(old.Ruleset, cur.Ruleset)
+	if err != nil {
+		s.writeError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	d := api.Diff{
+		Path:        path,
+		OldVersion:  old.Version,
+		NewVersion:  cur.Version,
+		Differences: diffs,
+	}
+	if d.Differences == nil {
+		d.Differences = []regula.Difference{}
+	}
+
+	s.encodeJSON(w, r, &d, http.StatusOK)
+}
+
+// writeEntryError writes the error returned by the store when fetching a ruleset entry.
+func (s *rulesetService) writeEntryError(w http.ResponseWriter, r *http.Request, path string, err error) {
+	if err == store.ErrNotFound {
+		s.writeError(w, r, fmt.Errorf("the path '%s' doesn't exist", path), http.StatusNotFound)
+		return
+	}
+
+	s.writeError(w, r, err, http.StatusInternalServerError)
+}
+
 // watch watches a prefix for change and returns anything newer.
 func (s *rulesetService) watch(w http.ResponseWriter, r *http.Request, prefix string) {
 	var ae api.Events
@@ -167,17 +243,16 @@ func (s *rulesetService) watch(w http.ResponseWriter, r *http.Request, prefix st
 	s.encodeJSON(w, r, ae, http.StatusOK)
 }
 
-// put creates a new version of a ruleset.
+// put creates a new version of a ruleset, sent in JSON or YAML, see decodeRuleset.
+// The response is encoded in YAML if requested by the Accept header.
 func (s *rulesetService) put(w http.ResponseWriter, r *http.Request, path string) {
-	var rs regula.Ruleset
-
-	err := json.NewDecoder(r.Body).Decode(&rs)
+	rs, err := decodeRuleset(r)
 	if err != nil {
 		s.writeError(w, r, err, http.StatusBadRequest)
 		return
 	}
 
-	entry, err := s.rulesets.Put(r.Context(), path, &rs)
+	entry, err := s.rulesets.Put(r.Context(), path, rs)
 	if err != nil && err != store.ErrNotModified {
 		if store.IsValidationError(err) {
 			s.writeError(w, r, err, http.StatusBadRequest)
@@ -188,5 +263,23 @@ func (s *rulesetService) put(w http.ResponseWriter, r *http.Request, path string
 		return
 	}
 
-	s.encodeJSON(w, r, (*api.Ruleset)(entry), http.StatusOK)
+	s.encode(w, r, (*api.Ruleset)(entry), http.StatusOK)
+}
+
+// validate analyzes the ruleset sent in the body of the request, in JSON or YAML, without storing it.
+func (s *rulesetService) validate(w http.ResponseWriter, r *http.Request) {
+	rs, err := decodeRuleset(r)
+	if err != nil {
+		s.writeError(w, r, err, http.StatusBadRequest)
+		return
+	}
+
+	a := api.Analysis{
+		Findings: regula.Analyze(rs),
+	}
+	if a.Findings == nil {
+		a.Findings = []regula.Finding{}
+	}
+
+	s.encodeJSON(w, r, &a, http.StatusOK)
 }
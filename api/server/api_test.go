@@ -9,6 +9,7 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -130,6 +131,11 @@ func TestAPI(t *testing.T) {
 				return (*regula.EvalResult)(result), nil
 			}
 
+			s.EvalTraceFn = func(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error) {
+				testParamsFn(params)
+				return (*regula.EvalResult)(result), nil
+			}
+
 			w := httptest.NewRecorder()
 			r := httptest.NewRequest("GET", url, nil)
 			h.ServeHTTP(w, r)
@@ -167,6 +173,7 @@ func TestAPI(t *testing.T) {
 			exp := api.EvalResult{
 				Value:   rule.StringValue("success"),
 				Version: "123",
+				RuleID:  "winter-sale",
 			}
 
 			call(t, "/rulesets/path/to/my/ruleset?eval&version=123&str=str&nb=10&boolean=true", http.StatusOK, &exp, func(params rule.Params) {
@@ -177,6 +184,76 @@ func TestAPI(t *testing.T) {
 			require.Equal(t, 1, s.EvalVersionCount)
 		})
 
+		t.Run("OK With default", func(t *testing.T) {
+			exp := api.EvalResult{
+				Value:   rule.StringValue("default"),
+				Version: "123",
+				Default: true,
+			}
+
+			call(t, "/rulesets/path/to/my/ruleset?eval", http.StatusOK, &exp, func(params rule.Params) {})
+			require.Equal(t, 1, s.EvalCount)
+		})
+
+		t.Run("OK With trace", func(t *testing.T) {
+			exp := api.EvalResult{
+				Value:   rule.StringValue("success"),
+				Version: "123",
+				Trace: &regula.RulesetTrace{
+					Rule: 0,
+					Rules: []*rule.RuleTrace{{
+						Expr: &rule.Trace{
+							Kind:  "eq",
+							Value: rule.BoolValue(true),
+							Operands: []*rule.Trace{
+								{Kind: "param", Name: "str", Value: rule.StringValue("str")},
+								{Kind: "value", Value: rule.StringValue("str")},
+							},
+						},
+						Matched: true,
+						Result:  &rule.Trace{Kind: "value", Value: rule.StringValue("success")},
+					}},
+				},
+			}
+
+			call(t, "/rulesets/path/to/my/ruleset?eval&trace&version=123&str=str", http.StatusOK, &exp, func(params rule.Params) {
+				s, err := params.GetString("str")
+				require.NoError(t, err)
+				require.Equal(t, "str", s)
+			})
+			require.Equal(t, 1, s.EvalTraceCount)
+		})
+
+		t.Run("NOK - No match with trace", func(t *testing.T) {
+			trace := regula.RulesetTrace{
+				Rule: -1,
+				Rules: []*rule.RuleTrace{{
+					Expr: &rule.Trace{
+						Kind:  "eq",
+						Value: rule.BoolValue(false),
+						Operands: []*rule.Trace{
+							{Kind: "param", Name: "str", Value: rule.StringValue("foo")},
+							{Kind: "value", Value: rule.StringValue("str")},
+						},
+					},
+				}},
+			}
+
+			s.EvalTraceFn = func(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error) {
+				return &regula.EvalResult{Version: "123", Trace: &trace}, rule.ErrNoMatch
+			}
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/rulesets/path/to/my/ruleset?eval&trace&str=foo", nil)
+			h.ServeHTTP(w, r)
+
+			var resp api.Error
+			err := json.Unmarshal(w.Body.Bytes(), &resp)
+			require.NoError(t, err)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+			require.Equal(t, api.Error{Err: rule.ErrNoMatch.Error(), Trace: &trace}, resp)
+		})
+
 		t.Run("NOK - Ruleset not found", func(t *testing.T) {
 			s.EvalFn = func(ctx context.Context, path string, params rule.Params) (*regula.EvalResult, error) {
 				return nil, regula.ErrRulesetNotFound
@@ -202,6 +279,7 @@ func TestAPI(t *testing.T) {
 				rule.ErrParamNotFound,
 				rule.ErrParamTypeMismatch,
 				rule.ErrNoMatch,
+				&regula.ParamError{Name: "foo", Reason: "must be less than or equal to 5"},
 			}
 
 			for _, e := range errs {
@@ -352,6 +430,146 @@ func TestAPI(t *testing.T) {
 		t.Run("Bad param name", func(t *testing.T) {
 			call(t, "/rulesets/a", http.StatusBadRequest, nil, new(store.ValidationError))
 		})
+
+		t.Run("YAML", func(t *testing.T) {
+			s.PutFn = func(ctx context.Context, path string) (*store.RulesetEntry, error) {
+				return &e1, nil
+			}
+			defer func() { s.PutFn = nil }()
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest("PUT", "/rulesets/a", strings.NewReader("type: bool\nrules:\n- {expr: true, result: true}\n"))
+			r.Header.Set("Content-Type", "application/yaml")
+			r.Header.Set("Accept", "text/yaml, application/json")
+			h.ServeHTTP(w, r)
+
+			require.Equal(t, http.StatusOK, w.Code)
+			require.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+			require.Equal(t, "path: a\nversion: version\nruleset:\n  rules:\n  - expr: true\n    result: true\n  type: bool\n", w.Body.String())
+
+			w = httptest.NewRecorder()
+			r = httptest.NewRequest("PUT", "/rulesets/a", strings.NewReader("type: bool\nrules:\n- {expr: true, result: 1}\n"))
+			r.Header.Set("Content-Type", "application/x-yaml; charset=utf-8")
+			h.ServeHTTP(w, r)
+			require.Equal(t, http.StatusBadRequest, w.Code)
+		})
+	})
+}
+
+func TestValidate(t *testing.T) {
+	s := new(mockRulesetService)
+	log := zerolog.New(ioutil.Discard)
+	h := NewHandler(context.Background(), s, Config{
+		Logger: &log,
+	})
+
+	call := func(t *testing.T, body string, code int) *api.Analysis {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/rulesets/?validate", bytes.NewBufferString(body))
+		h.ServeHTTP(w, r)
+		require.Equal(t, code, w.Code)
+
+		var a api.Analysis
+		if code == http.StatusOK {
+			err := json.NewDecoder(w.Body).Decode(&a)
+			require.NoError(t, err)
+		}
+		return &a
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		rs, err := regula.NewInt64Ruleset(
+			rule.New(rule.True(), rule.Int64Value(1)),
+			rule.New(rule.BoolParam("a"), rule.Int64Value(2)),
+		)
+		require.NoError(t, err)
+		raw, err := json.Marshal(rs)
+		require.NoError(t, err)
+
+		a := call(t, string(raw), http.StatusOK)
+		require.Equal(t, []regula.Finding{
+			{Kind: regula.FindingUnreachable, Rule: 1, Cause: 0, Message: "the rule is never evaluated, rule 0 always matches"},
+		}, a.Findings)
+		require.Zero(t, s.PutCount)
+	})
+
+	t.Run("No findings", func(t *testing.T) {
+		rs, err := regula.NewInt64Ruleset(rule.New(rule.True(), rule.Int64Value(1)))
+		require.NoError(t, err)
+		raw, err := json.Marshal(rs)
+		require.NoError(t, err)
+
+		a := call(t, string(raw), http.StatusOK)
+		require.Empty(t, a.Findings)
+	})
+
+	t.Run("Invalid ruleset", func(t *testing.T) {
+		call(t, `{"type": "int64", "rules": [{"expr": {"kind": "value", "type": "bool", "data": "true"}, "result": {"kind": "value", "type": "string", "data": "a"}}]}`, http.StatusBadRequest)
+	})
+}
+
+func TestDiff(t *testing.T) {
+	s := new(mockRulesetService)
+	log := zerolog.New(ioutil.Discard)
+	h := NewHandler(context.Background(), s, Config{
+		Logger: &log,
+	})
+
+	r1, err := regula.NewStringRuleset(rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.StringValue("a")))
+	require.NoError(t, err)
+	r2, err := regula.NewStringRuleset(rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("lyon")), rule.StringValue("a")))
+	require.NoError(t, err)
+
+	s.OneByVersionFn = func(ctx context.Context, path, version string) (*store.RulesetEntry, error) {
+		if version == "v1" {
+			return &store.RulesetEntry{Path: path, Version: version, Ruleset: r1}, nil
+		}
+		return nil, store.ErrNotFound
+	}
+	s.LatestFn = func(ctx context.Context, path string) (*store.RulesetEntry, error) {
+		return &store.RulesetEntry{Path: path, Version: "v2", Ruleset: r2}, nil
+	}
+
+	call := func(t *testing.T, u string, code int) *api.Diff {
+		t.Helper()
+
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("GET", u, nil)
+		h.ServeHTTP(w, r)
+		require.Equal(t, code, w.Code)
+
+		var d api.Diff
+		if code == http.StatusOK {
+			err := json.NewDecoder(w.Body).Decode(&d)
+			require.NoError(t, err)
+		}
+		return &d
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		d := call(t, "/rulesets/a?diff&old=v1", http.StatusOK)
+		require.Equal(t, "a", d.Path)
+		require.Equal(t, "v1", d.OldVersion)
+		require.Equal(t, "v2", d.NewVersion)
+		require.Equal(t, []regula.Difference{
+			{Params: map[string]string{"city": "paris"}, Old: regula.Outcome{Value: rule.StringValue("a")}, New: regula.Outcome{Error: rule.ErrNoMatch.Error()}},
+			{Params: map[string]string{"city": "lyon"}, Old: regula.Outcome{Error: rule.ErrNoMatch.Error()}, New: regula.Outcome{Value: rule.StringValue("a")}},
+		}, d.Differences[:2])
+	})
+
+	t.Run("Same version", func(t *testing.T) {
+		d := call(t, "/rulesets/a?diff&old=v1&new=v1", http.StatusOK)
+		require.Empty(t, d.Differences)
+	})
+
+	t.Run("Missing old version", func(t *testing.T) {
+		call(t, "/rulesets/a?diff", http.StatusBadRequest)
+	})
+
+	t.Run("Unknown version", func(t *testing.T) {
+		call(t, "/rulesets/a?diff&old=v3", http.StatusNotFound)
 	})
 }
 
@@ -363,6 +581,7 @@ func resetStore(s *mockRulesetService) {
 	s.PutCount = 0
 	s.EvalCount = 0
 	s.EvalVersionCount = 0
+	s.EvalTraceCount = 0
 	s.ListFn = nil
 	s.LatestFn = nil
 	s.OneByVersionFn = nil
@@ -370,4 +589,5 @@ func resetStore(s *mockRulesetService) {
 	s.PutFn = nil
 	s.EvalFn = nil
 	s.EvalVersionFn = nil
+	s.EvalTraceFn = nil
 }
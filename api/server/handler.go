@@ -3,15 +3,20 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"io/ioutil"
+	"mime"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"github.com/heetch/regula"
 	"github.com/heetch/regula/api"
 	"github.com/heetch/regula/store"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/hlog"
+	"gopkg.in/yaml.v2"
 )
 
 // HTTP errors
@@ -104,6 +109,79 @@ func (s *service) encodeJSON(w http.ResponseWriter, r *http.Request, v interface
 	}
 }
 
+// encode encodes v to w in YAML format if requested by the Accept header of the request, in JSON format otherwise.
+func (s *service) encode(w http.ResponseWriter, r *http.Request, v interface{}, status int) {
+	if !acceptsYAML(r) {
+		s.encodeJSON(w, r, v, status)
+		return
+	}
+
+	raw, err := yaml.Marshal(v)
+	if err != nil {
+		s.writeError(w, r, err, http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(status)
+	if _, err := w.Write(raw); err != nil {
+		loggerFromRequest(r).Error().Err(err).Msg("failed to write yaml to http response")
+	}
+}
+
+// isYAML reports whether the media type designates YAML. There is no registered media type for YAML,
+// the ones in use are all accepted.
+func isYAML(mediaType string) bool {
+	switch mediaType {
+	case "application/yaml", "application/x-yaml", "text/yaml", "text/x-yaml":
+		return true
+	}
+
+	return false
+}
+
+// acceptsYAML reports whether the Accept header of the request lists a YAML media type before JSON.
+// Quality values are ignored.
+func acceptsYAML(r *http.Request) bool {
+	for _, accept := range r.Header["Accept"] {
+		for _, part := range strings.Split(accept, ",") {
+			mediaType, _, err := mime.ParseMediaType(part)
+			if err != nil {
+				continue
+			}
+
+			if isYAML(mediaType) {
+				return true
+			}
+			if mediaType == "application/json" {
+				return false
+			}
+		}
+	}
+
+	return false
+}
+
+// decodeRuleset decodes the ruleset sent in the body of the request, in YAML format if the Content-Type header
+// designates YAML, in JSON format otherwise.
+func decodeRuleset(r *http.Request) (*regula.Ruleset, error) {
+	var rs regula.Ruleset
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if !isYAML(mediaType) {
+		err := json.NewDecoder(r.Body).Decode(&rs)
+		return &rs, err
+	}
+
+	raw, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = yaml.Unmarshal(raw, &rs)
+	return &rs, err
+}
+
 func loggerFromRequest(r *http.Request) *zerolog.Logger {
 	logger := hlog.FromRequest(r).With().
 		Str("method", r.Method).
@@ -114,6 +192,12 @@ func loggerFromRequest(r *http.Request) *zerolog.Logger {
 
 // writeError writes an error to the http response in JSON format.
 func (s *service) writeError(w http.ResponseWriter, r *http.Request, err error, code int) {
+	s.writeErrorTrace(w, r, err, nil, code)
+}
+
+// writeErrorTrace is like writeError and adds to the response the trace of the evaluation which failed.
+// The trace is omitted for internal errors.
+func (s *service) writeErrorTrace(w http.ResponseWriter, r *http.Request, err error, trace *regula.RulesetTrace, code int) {
 	// Prepare log.
 	logger := loggerFromRequest(r).With().
 		Err(err).
@@ -124,9 +208,10 @@ func (s *service) writeError(w http.ResponseWriter, r *http.Request, err error,
 	if code == http.StatusInternalServerError {
 		logger.Error().Msg("unexpected http error")
 		err = errInternal
+		trace = nil
 	} else {
 		logger.Debug().Msg("http error")
 	}
 
-	s.encodeJSON(w, r, &api.Error{Err: err.Error()}, code)
+	s.encodeJSON(w, r, &api.Error{Err: err.Error(), Trace: trace}, code)
 }
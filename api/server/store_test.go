@@ -26,6 +26,8 @@ type mockRulesetService struct {
 	EvalFn            func(ctx context.Context, path string, params rule.Params) (*regula.EvalResult, error)
 	EvalVersionCount  int
 	EvalVersionFn     func(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error)
+	EvalTraceCount    int
+	EvalTraceFn       func(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error)
 }
 
 func (s *mockRulesetService) List(ctx context.Context, prefix string, limit int, token string) (*store.RulesetEntries, error) {
@@ -92,3 +94,12 @@ func (s *mockRulesetService) EvalVersion(ctx context.Context, path, version stri
 	}
 	return nil, nil
 }
+
+func (s *mockRulesetService) EvalTrace(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error) {
+	s.EvalTraceCount++
+
+	if s.EvalTraceFn != nil {
+		return s.EvalTraceFn(ctx, path, version, params)
+	}
+	return nil, nil
+}
@@ -10,14 +10,19 @@ import (
 
 // EvalResult is the response sent to the client after an eval.
 type EvalResult struct {
-	Value   *rule.Value `json:"value"`
-	Version string      `json:"version"`
+	Value   *rule.Value          `json:"value"`
+	Version string               `json:"version"`
+	Trace   *regula.RulesetTrace `json:"trace,omitempty"`
+	Default bool                 `json:"default,omitempty"`
+	RuleID  string               `json:"ruleID,omitempty"`
 }
 
 // Error is a generic error response.
 type Error struct {
-	Err      string         `json:"error"`
-	Response *http.Response `json:"-"` // Used by clients to return the original server response
+	Err string `json:"error"`
+	// Trace of the evaluation which failed, if requested
+	Trace    *regula.RulesetTrace `json:"trace,omitempty"`
+	Response *http.Response       `json:"-"` // Used by clients to return the original server response
 }
 
 func (e Error) Error() string {
@@ -33,9 +38,24 @@ func (e Error) Error() string {
 
 // Ruleset holds a ruleset and its metadata.
 type Ruleset struct {
-	Path    string          `json:"path"`
-	Version string          `json:"version"`
-	Ruleset *regula.Ruleset `json:"ruleset"`
+	Path    string          `json:"path" yaml:"path"`
+	Version string          `json:"version" yaml:"version"`
+	Ruleset *regula.Ruleset `json:"ruleset" yaml:"ruleset"`
+}
+
+// Analysis is the response sent to the client after the validation of a ruleset.
+// It holds the findings reported by regula.Analyze.
+type Analysis struct {
+	Findings []regula.Finding `json:"findings"`
+}
+
+// Diff is the response sent to the client after the comparison of two versions of a ruleset.
+// It holds the differences reported by regula.Diff.
+type Diff struct {
+	Path        string              `json:"path"`
+	OldVersion  string              `json:"oldVersion"`
+	NewVersion  string              `json:"newVersion"`
+	Differences []regula.Difference `json:"differences"`
 }
 
 // Rulesets holds a list of rulesets.
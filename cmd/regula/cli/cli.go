@@ -30,7 +30,8 @@ type Config struct {
 		Timeout      time.Duration `config:"server-timeout"`
 		WatchTimeout time.Duration `config:"server-watch-timeout"`
 	}
-	LogLevel string `config:"log-level"`
+	RejectFindings []string `config:"reject-findings"`
+	LogLevel       string   `config:"log-level"`
 }
 
 // LoadConfig loads the configuration from the environment or command line flags.
@@ -43,6 +44,7 @@ func LoadConfig(args []string) (*Config, error) {
 	flag.StringVar(&cfg.LogLevel, "log-level", zerolog.DebugLevel.String(), "debug level")
 	cfg.Etcd.Endpoints = []string{"127.0.0.1:2379"}
 	flag.Var(commaSeparatedFlag{&cfg.Etcd.Endpoints}, "etcd-endpoints", "comma separated etcd endpoints")
+	flag.Var(commaSeparatedFlag{&cfg.RejectFindings}, "reject-findings", "comma separated kinds of analysis findings rejected when a ruleset is created (e.g. unreachable,shadowed)")
 	flag.StringVar(&cfg.Server.Address, "addr", "0.0.0.0:5331", "server address to listen on")
 	flag.DurationVar(&cfg.Server.Timeout, "server-timeout", 5*time.Second, "server timeout (TODO)")
 	flag.DurationVar(&cfg.Server.WatchTimeout, "server-watch-timeout", 30*time.Second, "server watch timeout (TODO)")
@@ -60,6 +62,41 @@ func LoadConfig(args []string) (*Config, error) {
 	return &cfg, nil
 }
 
+// GenConfig holds the configuration of the gen command, which generates Go code from ruleset signatures.
+type GenConfig struct {
+	Server  string
+	Dir     string
+	Prefix  string
+	Package string
+	Output  string
+	Check   bool
+}
+
+// LoadGenConfig loads the configuration of the gen command from the command line flags.
+// The args hold the command line arguments following the program name, the first one being gen.
+// It returns flag.ErrHelp if the -help flag is specified on the command line.
+func LoadGenConfig(args []string) (*GenConfig, error) {
+	var cfg GenConfig
+	flag := stdflag.NewFlagSet("gen", stdflag.ContinueOnError)
+	flag.StringVar(&cfg.Server, "server", "", "address of the server to fetch the rulesets from")
+	flag.StringVar(&cfg.Dir, "dir", "", "directory to read the rulesets from, in .rule, .json or .yaml files")
+	flag.StringVar(&cfg.Prefix, "prefix", "", "prefix of the paths of the rulesets fetched from the server")
+	flag.StringVar(&cfg.Package, "package", "rules", "package of the generated code")
+	flag.StringVar(&cfg.Output, "o", "", "file to write the generated code to, instead of the standard output")
+	flag.BoolVar(&cfg.Check, "check", false, "fail if the file given by -o is not up to date instead of writing it")
+
+	if err := flag.Parse(args[1:]); err != nil {
+		return nil, err
+	}
+	if (cfg.Server == "") == (cfg.Dir == "") {
+		return nil, fmt.Errorf("either -server or -dir is required")
+	}
+	if cfg.Check && cfg.Output == "" {
+		return nil, fmt.Errorf("-check requires -o")
+	}
+	return &cfg, nil
+}
+
 type commaSeparatedFlag struct {
 	parts *[]string
 }
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/heetch/regula/api/client"
+	"github.com/heetch/regula/cmd/regula/cli"
+	"github.com/heetch/regula/cmd/regula/gen"
+)
+
+// runGen runs the gen command and returns the exit code of the program.
+func runGen(args []string) int {
+	cfg, err := cli.LoadGenConfig(args)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "regula: %v\n", err)
+		return 2
+	}
+
+	code, err := generate(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "regula: %v\n", err)
+		return 1
+	}
+
+	switch {
+	case cfg.Check:
+		err = gen.Check(cfg.Output, code)
+		if err == gen.ErrOutOfDate {
+			err = fmt.Errorf("%s is out of date with the ruleset signatures, run regula gen to update it", cfg.Output)
+		}
+	case cfg.Output != "":
+		err = ioutil.WriteFile(cfg.Output, code, 0644)
+	default:
+		_, err = os.Stdout.Write(code)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "regula: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
+
+func generate(cfg *cli.GenConfig) ([]byte, error) {
+	var sigs []*gen.Signature
+	var err error
+
+	if cfg.Dir != "" {
+		sigs, err = gen.FromDir(cfg.Dir)
+	} else {
+		var c *client.Client
+		c, err = client.New(cfg.Server)
+		if err != nil {
+			return nil, err
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		sigs, err = gen.FromServer(ctx, c, cfg.Prefix)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return gen.Generate(cfg.Package, sigs)
+}
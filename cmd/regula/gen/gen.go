@@ -0,0 +1,370 @@
+// Package gen generates Go functions evaluating rulesets with typed params and results,
+// from the signatures of the rulesets.
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/heetch/regula"
+)
+
+// ErrOutOfDate is returned by Check when the generated code differs from the existing one.
+var ErrOutOfDate = errors.New("generated code is out of date")
+
+// A Signature describes the params and the result of a ruleset.
+type Signature struct {
+	Path string
+	// Params is sorted by name.
+	Params     []Param
+	ResultType string
+	// Schema holds the fields of the result of object rulesets.
+	Schema map[string]string
+}
+
+// A Param is a param of a ruleset.
+type Param struct {
+	Name     string
+	Type     string
+	Optional bool
+}
+
+// NewSignature returns the signature of the ruleset stored on the given path: the params it declares or uses,
+// which are optional if declared or used as such, and its result type.
+func NewSignature(path string, rs *regula.Ruleset) *Signature {
+	byName := make(map[string]*Param)
+	add := func(name, typ string, optional bool) {
+		p, ok := byName[name]
+		if !ok {
+			p = &Param{Name: name, Type: typ}
+			byName[name] = p
+		}
+		p.Optional = p.Optional || optional
+	}
+
+	for _, spec := range rs.ParamSpecs {
+		add(spec.Name, spec.Type, spec.Optional)
+	}
+	for _, p := range rs.Params() {
+		add(p.Name, p.Type, p.Optional)
+	}
+
+	sig := Signature{
+		Path:       path,
+		ResultType: rs.ResultType(),
+		Schema:     rs.Schema,
+	}
+	for _, p := range byName {
+		sig.Params = append(sig.Params, *p)
+	}
+	sort.Slice(sig.Params, func(i, j int) bool {
+		return sig.Params[i].Name < sig.Params[j].Name
+	})
+
+	return &sig
+}
+
+// goTypes associates the types of params and results with Go types.
+var goTypes = map[string]string{
+	"string":       "string",
+	"bool":         "bool",
+	"int64":        "int64",
+	"float64":      "float64",
+	"time":         "time.Time",
+	"duration":     "time.Duration",
+	"version":      "string",
+	"point":        "rule.Point",
+	"string-list":  "[]string",
+	"int64-list":   "[]int64",
+	"float64-list": "[]float64",
+}
+
+// getters associates the result types with the Engine methods returning them.
+var getters = map[string]string{
+	"string":       "GetString",
+	"bool":         "GetBool",
+	"int64":        "GetInt64",
+	"float64":      "GetFloat64",
+	"string-list":  "GetStringList",
+	"int64-list":   "GetInt64List",
+	"float64-list": "GetFloat64List",
+}
+
+// initialisms are written in upper case in identifiers, following the Go conventions.
+var initialisms = map[string]bool{
+	"api": true, "http": true, "id": true, "ip": true, "json": true, "url": true, "uuid": true,
+}
+
+// identifier converts a name, like driver-id or marketplace/radius, into an exported Go identifier, like DriverID.
+func identifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var b strings.Builder
+	for _, w := range words {
+		if initialisms[strings.ToLower(w)] {
+			b.WriteString(strings.ToUpper(w))
+			continue
+		}
+
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		b.WriteString(string(r))
+	}
+
+	id := b.String()
+	if id != "" && unicode.IsDigit([]rune(id)[0]) {
+		id = "X" + id
+	}
+
+	return id
+}
+
+type field struct {
+	Name   string
+	Field  string
+	GoType string
+	// Optional fields are pointers, except lists which are omitted when nil.
+	Optional bool
+	Pointer  bool
+}
+
+type function struct {
+	Name   string
+	Path   string
+	Params []field
+	// Schema holds the fields of the result struct of object rulesets.
+	Schema []field
+	Result string
+	Getter string
+}
+
+type file struct {
+	Package string
+	Funcs   []*function
+	// Imports holds the standard library imports first, then the other ones.
+	Imports [][]string
+}
+
+// Generate returns the source of a Go file of the given package which, for each ruleset, declares a function
+// evaluating it with an Engine. Functions are named after the last segment of the path of the rulesets,
+// or their whole path if it's shared by several rulesets, e.g.
+//
+//	func Radius(ctx context.Context, eng *regula.Engine, p RadiusParams, opts ...regula.Option) (float64, error)
+//
+// RadiusParams is a struct with one field per param. Optional params are pointers, or slices omitted when nil.
+// Object rulesets return a struct with one field per field of their schema.
+func Generate(pkg string, sigs []*Signature) ([]byte, error) {
+	sigs = append([]*Signature(nil), sigs...)
+	sort.Slice(sigs, func(i, j int) bool {
+		return sigs[i].Path < sigs[j].Path
+	})
+
+	bases := make(map[string]int)
+	for _, sig := range sigs {
+		bases[identifier(lastSegment(sig.Path))]++
+	}
+
+	f := file{Package: pkg}
+	imports := map[string]bool{"context": true, "github.com/heetch/regula": true}
+	// owners associates the generated identifiers with the path of the ruleset they belong to
+	owners := make(map[string]string)
+	declare := func(id, path string) error {
+		if prev, ok := owners[id]; ok {
+			return fmt.Errorf("rulesets %s and %s both generate the identifier %s", prev, path, id)
+		}
+		owners[id] = path
+		return nil
+	}
+
+	for _, sig := range sigs {
+		fn := function{
+			Name: identifier(lastSegment(sig.Path)),
+			Path: sig.Path,
+		}
+		if bases[fn.Name] > 1 {
+			fn.Name = identifier(sig.Path)
+		}
+		if fn.Name == "" {
+			return nil, fmt.Errorf("cannot generate an identifier for ruleset %s", sig.Path)
+		}
+
+		ids := []string{fn.Name, fn.Name + "Params"}
+		if sig.ResultType == "object" {
+			ids = append(ids, fn.Name+"Result")
+		}
+		for _, id := range ids {
+			if err := declare(id, sig.Path); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		fn.Params, err = fields(sig.Path, sig.Params, imports)
+		if err != nil {
+			return nil, err
+		}
+
+		if sig.ResultType == "object" {
+			var schema []Param
+			for name, typ := range sig.Schema {
+				schema = append(schema, Param{Name: name, Type: typ})
+			}
+			sort.Slice(schema, func(i, j int) bool {
+				return schema[i].Name < schema[j].Name
+			})
+
+			fn.Schema, err = fields(sig.Path, schema, imports)
+			if err != nil {
+				return nil, err
+			}
+			fn.Result = fn.Name + "Result"
+		} else {
+			fn.Getter = getters[sig.ResultType]
+			fn.Result = goTypes[sig.ResultType]
+			if fn.Getter == "" {
+				return nil, fmt.Errorf("unsupported result type %s for ruleset %s", sig.ResultType, sig.Path)
+			}
+		}
+
+		f.Funcs = append(f.Funcs, &fn)
+	}
+
+	var std, other []string
+	for imp := range imports {
+		if strings.Contains(imp, ".") {
+			other = append(other, imp)
+		} else {
+			std = append(std, imp)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	f.Imports = [][]string{std, other}
+
+	var buf bytes.Buffer
+	if err := fileTemplate.Execute(&buf, &f); err != nil {
+		return nil, err
+	}
+
+	return format.Source(buf.Bytes())
+}
+
+func lastSegment(path string) string {
+	return path[strings.LastIndexByte(path, '/')+1:]
+}
+
+// fields returns the struct fields holding the given params and adds the packages of their types to imports.
+func fields(path string, params []Param, imports map[string]bool) ([]field, error) {
+	fields := make([]field, 0, len(params))
+	seen := make(map[string]string)
+
+	for _, p := range params {
+		f := field{
+			Name:     p.Name,
+			Field:    identifier(p.Name),
+			GoType:   goTypes[p.Type],
+			Optional: p.Optional,
+		}
+
+		switch {
+		case f.GoType == "":
+			return nil, fmt.Errorf("unsupported type %s for %s in ruleset %s", p.Type, p.Name, path)
+		case f.Field == "":
+			return nil, fmt.Errorf("cannot generate an identifier for %s in ruleset %s", p.Name, path)
+		case seen[f.Field] != "":
+			return nil, fmt.Errorf("%s and %s both generate the field %s in ruleset %s", seen[f.Field], p.Name, f.Field, path)
+		}
+		seen[f.Field] = p.Name
+
+		if f.Optional && !strings.HasPrefix(f.GoType, "[]") {
+			f.Pointer = true
+			f.GoType = "*" + f.GoType
+		}
+
+		switch {
+		case strings.Contains(f.GoType, "time."):
+			imports["time"] = true
+		case strings.Contains(f.GoType, "rule."):
+			imports["github.com/heetch/regula/rule"] = true
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+var fileTemplate = template.Must(template.New("file").Parse(`// Code generated by regula gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range $i, $group := .Imports}}{{if $i}}
+{{end}}{{range $group}}
+	"{{.}}"
+{{- end}}{{end}}
+)
+{{range .Funcs}}
+// {{.Name}}Params holds the params of the {{.Path}} ruleset.
+type {{.Name}}Params struct {
+{{- range .Params}}
+	{{.Field}} {{.GoType}}{{if .Optional}} // optional{{end}}
+{{- end}}
+}
+{{if .Schema}}
+// {{.Name}}Result holds the result of the {{.Path}} ruleset.
+type {{.Name}}Result struct {
+{{- range .Schema}}
+	{{.Field}} {{.GoType}} ` + "`" + `json:"{{.Name}}"` + "`" + `
+{{- end}}
+}
+{{end}}
+// {{.Name}} evaluates the {{.Path}} ruleset.
+func {{.Name}}(ctx context.Context, eng *regula.Engine, p {{.Name}}Params, opts ...regula.Option) ({{.Result}}, error) {
+	params := regula.Params{
+{{- range .Params}}{{if not .Optional}}
+		{{printf "%q" .Name}}: p.{{.Field}},
+{{- end}}{{end}}
+	}
+{{- range .Params}}{{if .Optional}}
+	if p.{{.Field}} != nil {
+		params[{{printf "%q" .Name}}] = {{if .Pointer}}*{{end}}p.{{.Field}}
+	}
+{{- end}}{{end}}
+{{if .Schema}}
+	var res {{.Result}}
+	_, err := eng.Unmarshal(ctx, {{printf "%q" .Path}}, params, &res, opts...)
+	return res, err
+{{- else}}
+	res, _, err := eng.{{.Getter}}(ctx, {{printf "%q" .Path}}, params, opts...)
+	return res, err
+{{- end}}
+}
+{{end}}`))
+
+// Check compares the generated code with the content of the given file and returns ErrOutOfDate if they differ.
+func Check(filename string, code []byte) error {
+	existing, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return ErrOutOfDate
+	}
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(existing, code) {
+		return ErrOutOfDate
+	}
+
+	return nil
+}
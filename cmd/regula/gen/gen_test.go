@@ -0,0 +1,251 @@
+package gen
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/heetch/regula"
+	"github.com/heetch/regula/api"
+	"github.com/heetch/regula/api/client"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdentifier(t *testing.T) {
+	tests := map[string]string{
+		"radius":             "Radius",
+		"driver-id":          "DriverID",
+		"marketplace/radius": "MarketplaceRadius",
+		"max_API_calls":      "MaxAPICalls",
+		"2fa":                "X2fa",
+		"--":                 "",
+	}
+
+	for name, expected := range tests {
+		require.Equal(t, expected, identifier(name), name)
+	}
+}
+
+func TestNewSignature(t *testing.T) {
+	rs, err := regula.ParseRuleset(`
+		type string
+		param age:int64
+		param city:string
+		param promo:string?
+		param zone:string
+
+		city == "paris" and age:int64 > 18 -> "a"
+		has(promo?) -> "b"
+	`)
+	require.NoError(t, err)
+
+	require.Equal(t, &Signature{
+		Path: "a/b",
+		Params: []Param{
+			{Name: "age", Type: "int64"},
+			{Name: "city", Type: "string"},
+			{Name: "promo", Type: "string", Optional: true},
+			{Name: "zone", Type: "string"},
+		},
+		ResultType: "string",
+	}, NewSignature("a/b", rs))
+}
+
+func TestGenerate(t *testing.T) {
+	sigs := []*Signature{
+		{
+			Path: "pricing/surge",
+			Params: []Param{
+				{Name: "zones", Type: "string-list", Optional: true},
+			},
+			ResultType: "object",
+			Schema:     map[string]string{"multiplier": "float64", "reason": "string"},
+		},
+		{
+			Path: "marketplace/radius",
+			Params: []Param{
+				{Name: "city", Type: "string"},
+				{Name: "driver-id", Type: "string", Optional: true},
+				{Name: "since", Type: "time"},
+			},
+			ResultType: "float64",
+		},
+	}
+
+	code, err := Generate("rules", sigs)
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by regula gen. DO NOT EDIT.
+
+package rules
+
+import (
+	"context"
+	"time"
+
+	"github.com/heetch/regula"
+)
+
+// RadiusParams holds the params of the marketplace/radius ruleset.
+type RadiusParams struct {
+	City     string
+	DriverID *string // optional
+	Since    time.Time
+}
+
+// Radius evaluates the marketplace/radius ruleset.
+func Radius(ctx context.Context, eng *regula.Engine, p RadiusParams, opts ...regula.Option) (float64, error) {
+	params := regula.Params{
+		"city":  p.City,
+		"since": p.Since,
+	}
+	if p.DriverID != nil {
+		params["driver-id"] = *p.DriverID
+	}
+
+	res, _, err := eng.GetFloat64(ctx, "marketplace/radius", params, opts...)
+	return res, err
+}
+
+// SurgeParams holds the params of the pricing/surge ruleset.
+type SurgeParams struct {
+	Zones []string // optional
+}
+
+// SurgeResult holds the result of the pricing/surge ruleset.
+type SurgeResult struct {
+	Multiplier float64 `+"`"+`json:"multiplier"`+"`"+`
+	Reason     string  `+"`"+`json:"reason"`+"`"+`
+}
+
+// Surge evaluates the pricing/surge ruleset.
+func Surge(ctx context.Context, eng *regula.Engine, p SurgeParams, opts ...regula.Option) (SurgeResult, error) {
+	params := regula.Params{}
+	if p.Zones != nil {
+		params["zones"] = p.Zones
+	}
+
+	var res SurgeResult
+	_, err := eng.Unmarshal(ctx, "pricing/surge", params, &res, opts...)
+	return res, err
+}
+`, string(code))
+
+	t.Run("Shared names", func(t *testing.T) {
+		code, err := Generate("rules", []*Signature{
+			{Path: "a/radius", ResultType: "bool"},
+			{Path: "b/radius", ResultType: "bool"},
+		})
+		require.NoError(t, err)
+		require.Contains(t, string(code), "func ARadius(")
+		require.Contains(t, string(code), "func BRadius(")
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			sigs []*Signature
+			err  string
+		}{
+			{"identifier", []*Signature{{Path: "radius", ResultType: "bool"}, {Path: "radius-params", ResultType: "bool"}},
+				"rulesets radius and radius-params both generate the identifier RadiusParams"},
+			{"field", []*Signature{{Path: "a", ResultType: "bool", Params: []Param{{Name: "a-b", Type: "string"}, {Name: "a_b", Type: "string"}}}},
+				"a-b and a_b both generate the field AB in ruleset a"},
+			{"result type", []*Signature{{Path: "a", ResultType: "object-list"}},
+				"unsupported result type object-list for ruleset a"},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := Generate("rules", test.sigs)
+				require.EqualError(t, err, test.err)
+			})
+		}
+	})
+}
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "regula-gen")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "rules.go")
+	require.Equal(t, ErrOutOfDate, Check(name, []byte("code")))
+
+	err = ioutil.WriteFile(name, []byte("code"), 0644)
+	require.NoError(t, err)
+	require.NoError(t, Check(name, []byte("code")))
+	require.Equal(t, ErrOutOfDate, Check(name, []byte("new code")))
+}
+
+func TestFromDir(t *testing.T) {
+	dir, err := ioutil.TempDir("", "regula-gen")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"marketplace/radius.rule": "city == \"paris\" -> 3.0\n",
+		"pricing/enabled.yaml":    "type: bool\nrules:\n- {expr: {param: surge, type: bool}, result: true}\n",
+		"README.md":               "ignored",
+	}
+	for name, content := range files {
+		name = filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(name), 0755))
+		require.NoError(t, ioutil.WriteFile(name, []byte(content), 0644))
+	}
+
+	sigs, err := FromDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, []*Signature{
+		{Path: "marketplace/radius", Params: []Param{{Name: "city", Type: "string"}}, ResultType: "float64"},
+		{Path: "pricing/enabled", Params: []Param{{Name: "surge", Type: "bool"}}, ResultType: "bool"},
+	}, sigs)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "invalid.rule"), []byte("city =="), 0644)
+	require.NoError(t, err)
+	_, err = FromDir(dir)
+	require.Error(t, err)
+}
+
+func TestFromServer(t *testing.T) {
+	rs, err := regula.ParseRuleset(`city == "paris" -> 3.0`)
+	require.NoError(t, err)
+	rs2, err := regula.ParseRuleset(`city == "paris" and age:int64 > 18 -> 3.0`)
+	require.NoError(t, err)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/rulesets/marketplace", r.URL.Path)
+
+		var rl api.Rulesets
+		switch r.URL.Query().Get("continue") {
+		case "":
+			rl.Rulesets = []api.Ruleset{{Path: "marketplace/radius", Version: "1", Ruleset: rs}}
+			rl.Continue = "next"
+		case "next":
+			// every version of each ruleset is listed
+			rl.Rulesets = []api.Ruleset{
+				{Path: "marketplace/radius", Version: "2", Ruleset: rs2},
+				{Path: "marketplace/surge", Version: "1", Ruleset: rs},
+			}
+		}
+		json.NewEncoder(w).Encode(&rl)
+	}))
+	defer ts.Close()
+
+	c, err := client.New(ts.URL)
+	require.NoError(t, err)
+	c.Logger = zerolog.Nop()
+
+	sigs, err := FromServer(context.Background(), c, "marketplace")
+	require.NoError(t, err)
+	require.Len(t, sigs, 2)
+	require.Equal(t, "marketplace/radius", sigs[0].Path)
+	require.Equal(t, []Param{{Name: "age", Type: "int64"}, {Name: "city", Type: "string"}}, sigs[0].Params)
+	require.Equal(t, "marketplace/surge", sigs[1].Path)
+	require.Equal(t, []Param{{Name: "city", Type: "string"}}, sigs[1].Params)
+}
@@ -0,0 +1,109 @@
+package gen
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/heetch/regula"
+	"github.com/heetch/regula/api/client"
+	"gopkg.in/yaml.v2"
+)
+
+// FromServer returns the signatures of the latest version of the rulesets stored on the server
+// which path starts with the given prefix.
+func FromServer(ctx context.Context, c *client.Client, prefix string) ([]*Signature, error) {
+	var sigs []*Signature
+	// the list holds every version of each ruleset, versions being ordered by creation time
+	latest := make(map[string]int)
+	versions := make(map[string]string)
+
+	var opt client.ListOptions
+	for {
+		rl, err := c.Rulesets.List(ctx, prefix, &opt)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, rs := range rl.Rulesets {
+			i, ok := latest[rs.Path]
+			switch {
+			case !ok:
+				latest[rs.Path] = len(sigs)
+				sigs = append(sigs, NewSignature(rs.Path, rs.Ruleset))
+			case rs.Version > versions[rs.Path]:
+				sigs[i] = NewSignature(rs.Path, rs.Ruleset)
+			default:
+				continue
+			}
+			versions[rs.Path] = rs.Version
+		}
+
+		if rl.Continue == "" {
+			return sigs, nil
+		}
+		opt.Continue = rl.Continue
+	}
+}
+
+// FromDir returns the signatures of the rulesets stored in the files of the given directory and its subdirectories.
+// Rulesets can be written in the rule syntax, see regula.ParseRuleset, in files with the .rule extension,
+// or in JSON or YAML, in files with the .json, .yaml or .yml extensions. Other files are ignored.
+// The path of each ruleset is the path of its file relative to the directory, without extension,
+// e.g. marketplace/radius for the dir/marketplace/radius.rule file.
+func FromDir(dir string) ([]*Signature, error) {
+	var sigs []*Signature
+
+	err := filepath.Walk(dir, func(name string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		ext := filepath.Ext(name)
+		switch ext {
+		case ".rule", ".json", ".yaml", ".yml":
+		default:
+			return nil
+		}
+
+		rs, err := readRuleset(name, ext)
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+
+		rel, err := filepath.Rel(dir, name)
+		if err != nil {
+			return err
+		}
+		path := filepath.ToSlash(strings.TrimSuffix(rel, ext))
+
+		sigs = append(sigs, NewSignature(path, rs))
+		return nil
+	})
+
+	return sigs, err
+}
+
+func readRuleset(name, ext string) (*regula.Ruleset, error) {
+	raw, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	switch ext {
+	case ".rule":
+		return regula.ParseRuleset(string(raw))
+	case ".json":
+		var rs regula.Ruleset
+		err = json.Unmarshal(raw, &rs)
+		return &rs, err
+	}
+
+	var rs regula.Ruleset
+	err = yaml.Unmarshal(raw, &rs)
+	return &rs, err
+}
@@ -13,6 +13,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "gen" {
+		os.Exit(runGen(os.Args[1:]))
+	}
+
 	cfg, err := cli.LoadConfig(os.Args)
 	if err != nil {
 		if err == flag.ErrHelp {
@@ -34,9 +38,10 @@ func main() {
 	defer etcdCli.Close()
 
 	service := etcd.RulesetService{
-		Client:    etcdCli,
-		Namespace: cfg.Etcd.Namespace,
-		Logger:    logger.With().Str("service", "etcd").Logger(),
+		Client:         etcdCli,
+		Namespace:      cfg.Etcd.Namespace,
+		Logger:         logger.With().Str("service", "etcd").Logger(),
+		RejectFindings: cfg.RejectFindings,
 	}
 
 	srv := server.New(&service, server.Config{
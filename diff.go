@@ -0,0 +1,451 @@
+package regula
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/heetch/regula/rule"
+)
+
+// An Outcome is the result of the evaluation of a ruleset: a value or an error, like rule.ErrNoMatch.
+type Outcome struct {
+	Value *rule.Value `json:"value,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+func newOutcome(v *rule.Value, err error) Outcome {
+	if err != nil {
+		return Outcome{Error: err.Error()}
+	}
+
+	return Outcome{Value: v}
+}
+
+func (o Outcome) equal(other Outcome) bool {
+	if o.Value == nil || other.Value == nil {
+		return o.Value == other.Value && o.Error == other.Error
+	}
+
+	return o.Value.Type == other.Value.Type && o.Value.Equal(other.Value)
+}
+
+// A Difference holds params for which two rulesets return different outcomes.
+type Difference struct {
+	// Params holds the params encoded as strings, see StringParams. Optional params may be omitted.
+	Params map[string]string `json:"params"`
+	Old    Outcome           `json:"old"`
+	New    Outcome           `json:"new"`
+}
+
+type diffConfig struct {
+	samples int
+	seed    int64
+	limit   int
+}
+
+// DiffOption is used to customize the comparison of rulesets done by Diff.
+type DiffOption func(cfg *diffConfig)
+
+// DiffSamples sets the number of random inputs evaluated in addition to the boundary inputs.
+// It defaults to 100.
+func DiffSamples(n int) DiffOption {
+	return func(cfg *diffConfig) {
+		cfg.samples = n
+	}
+}
+
+// DiffSeed sets the seed used to generate the random inputs. It defaults to 1, making comparisons reproducible.
+func DiffSeed(seed int64) DiffOption {
+	return func(cfg *diffConfig) {
+		cfg.seed = seed
+	}
+}
+
+// DiffLimit sets the maximum number of differences returned. It defaults to 100.
+func DiffLimit(n int) DiffOption {
+	return func(cfg *diffConfig) {
+		cfg.limit = n
+	}
+}
+
+// maxBoundaryInputs is the maximum number of combinations of boundary values evaluated by Diff.
+// Above it, combinations are picked randomly.
+const maxBoundaryInputs = 10000
+
+// Diff compares the behaviour of two versions of a ruleset and returns params for which their outcomes differ.
+// The params are generated from the constants used by the rules of both rulesets, like 10 in age > 10,
+// and the values surrounding them, like 9 and 11, completed by random samples.
+// An empty result doesn't prove the rulesets are equivalent, but that no difference was found.
+func Diff(old, new *Ruleset, opts ...DiffOption) ([]Difference, error) {
+	cfg := diffConfig{
+		samples: 100,
+		seed:    1,
+		limit:   100,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	p1, err := old.Compile()
+	if err != nil {
+		return nil, err
+	}
+	p2, err := new.Compile()
+	if err != nil {
+		return nil, err
+	}
+
+	g := inputGenerator{
+		params: diffParams(old, new),
+		rnd:    rand.New(rand.NewSource(cfg.seed)),
+		seen:   make(map[string]bool),
+	}
+
+	var diffs []Difference
+	g.generate(cfg.samples, func(params StringParams) bool {
+		o1 := newOutcome(p1.Eval(params))
+		o2 := newOutcome(p2.Eval(params))
+		if !o1.equal(o2) {
+			diffs = append(diffs, Difference{Params: params, Old: o1, New: o2})
+		}
+
+		return len(diffs) < cfg.limit
+	})
+
+	return diffs, nil
+}
+
+// diffParam describes a param used by the compared rulesets along with its boundary values, encoded as strings.
+type diffParam struct {
+	name     string
+	typ      string
+	optional bool
+	consts   []*rule.Value
+	values   []string
+}
+
+// diffParams returns the params used or declared by the rulesets, sorted by name.
+func diffParams(rulesets ...*Ruleset) []*diffParam {
+	byName := make(map[string]*diffParam)
+	get := func(name, typ string, optional bool) *diffParam {
+		p, ok := byName[name]
+		if !ok {
+			p = &diffParam{name: name, typ: typ}
+			byName[name] = p
+		}
+		p.optional = p.optional || optional
+		return p
+	}
+
+	for _, rs := range rulesets {
+		for _, spec := range rs.ParamSpecs {
+			p := get(spec.Name, spec.Type, spec.Optional)
+			for _, e := range spec.Enum {
+				p.consts = append(p.consts, &rule.Value{Kind: "value", Type: spec.Type, Data: e})
+			}
+			for _, b := range []*float64{spec.Min, spec.Max} {
+				if b != nil {
+					p.consts = append(p.consts, boundValue(spec.Type, *b))
+				}
+			}
+		}
+
+		for _, rl := range rs.Rules {
+			for _, prm := range rl.Params() {
+				get(prm.Name, prm.Type, prm.Optional)
+			}
+
+			for _, e := range []rule.Expr{rl.Expr, rl.Result} {
+				for name, values := range rule.ParamConstants(e) {
+					if p, ok := byName[name]; ok {
+						p.consts = append(p.consts, values...)
+					}
+				}
+			}
+		}
+	}
+
+	params := make([]*diffParam, 0, len(byName))
+	for _, p := range byName {
+		p.values = boundaries(p.typ, p.consts)
+		params = append(params, p)
+	}
+	sort.Slice(params, func(i, j int) bool {
+		return params[i].name < params[j].name
+	})
+
+	return params
+}
+
+func boundValue(typ string, b float64) *rule.Value {
+	if typ == "int64" {
+		return rule.Int64Value(int64(b))
+	}
+
+	return rule.Float64Value(b)
+}
+
+// boundaries returns the values of a param of the given type worth evaluating, given the constants it is compared to.
+func boundaries(typ string, consts []*rule.Value) []string {
+	var values []string
+	seen := make(map[string]bool)
+	add := func(v string) {
+		if !seen[v] {
+			seen[v] = true
+			values = append(values, v)
+		}
+	}
+
+	elemType := strings.TrimSuffix(typ, "-list")
+	for _, c := range consts {
+		if c.Type == typ && typ != elemType {
+			add(c.Data)
+		}
+		if c.Type != elemType {
+			continue
+		}
+
+		for _, v := range around(elemType, c.Data) {
+			if typ != elemType {
+				v = encodeListElem(elemType, v)
+			}
+			add(v)
+		}
+	}
+
+	switch typ {
+	case "string":
+		add("")
+	case "bool":
+		add("true")
+		add("false")
+	case "int64":
+		add("0")
+	case "float64":
+		add(rule.Float64Value(0).Data)
+	case "duration":
+		add("0s")
+	case "time":
+		add(rule.NowFunc().UTC().Format(time.RFC3339Nano))
+	case "point":
+		add("[0,0]")
+	case "string-list", "int64-list", "float64-list":
+		add("[]")
+	}
+
+	return values
+}
+
+// around returns the given value along with the closest values on each side, for the types that have some.
+func around(typ, data string) []string {
+	switch typ {
+	case "int64":
+		n, err := strconv.ParseInt(data, 10, 64)
+		if err != nil {
+			return nil
+		}
+		values := []string{data}
+		if n > math.MinInt64 {
+			values = append(values, strconv.FormatInt(n-1, 10))
+		}
+		if n < math.MaxInt64 {
+			values = append(values, strconv.FormatInt(n+1, 10))
+		}
+		return values
+	case "float64":
+		f, err := strconv.ParseFloat(data, 64)
+		if err != nil {
+			return nil
+		}
+		// float64 values are formatted with 6 decimals, closer values would be rounded to the constant
+		return []string{
+			rule.Float64Value(f).Data,
+			rule.Float64Value(f - 1e-6).Data,
+			rule.Float64Value(f + 1e-6).Data,
+		}
+	case "duration":
+		d, err := time.ParseDuration(data)
+		if err != nil {
+			return nil
+		}
+		return []string{d.String(), (d - 1).String(), (d + 1).String()}
+	case "time":
+		t, err := time.Parse(time.RFC3339Nano, data)
+		if err != nil {
+			return nil
+		}
+		return []string{
+			t.Format(time.RFC3339Nano),
+			t.Add(-time.Nanosecond).Format(time.RFC3339Nano),
+			t.Add(time.Nanosecond).Format(time.RFC3339Nano),
+		}
+	}
+
+	return []string{data}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// encodeListElem returns a list holding the given element, encoded as a JSON array.
+func encodeListElem(typ, v string) string {
+	if typ == "string" {
+		raw, _ := json.Marshal([]string{v})
+		return string(raw)
+	}
+
+	return "[" + v + "]"
+}
+
+// inputGenerator generates the params evaluated by Diff.
+type inputGenerator struct {
+	params []*diffParam
+	rnd    *rand.Rand
+	// seen holds the inputs already generated, to evaluate them only once.
+	seen map[string]bool
+}
+
+// generate calls fn with the combinations of the boundary values of the params, or random combinations of them
+// if there are too many, then with the given number of random samples, until fn returns false.
+func (g *inputGenerator) generate(samples int, fn func(StringParams) bool) {
+	combinations := 1
+	for _, p := range g.params {
+		combinations *= g.choices(p)
+		if combinations > maxBoundaryInputs {
+			break
+		}
+	}
+
+	if combinations <= maxBoundaryInputs {
+		idx := make([]int, len(g.params))
+		for {
+			if !g.emit(idx, fn) {
+				return
+			}
+
+			// increment the indexes like an odometer
+			i := len(idx) - 1
+			for ; i >= 0; i-- {
+				idx[i]++
+				if idx[i] < g.choices(g.params[i]) {
+					break
+				}
+				idx[i] = 0
+			}
+			if i < 0 {
+				break
+			}
+		}
+	} else {
+		idx := make([]int, len(g.params))
+		for n := 0; n < maxBoundaryInputs; n++ {
+			for i, p := range g.params {
+				idx[i] = g.rnd.Intn(g.choices(p))
+			}
+			if !g.emit(idx, fn) {
+				return
+			}
+		}
+	}
+
+	for n := 0; n < samples; n++ {
+		params := make(StringParams)
+		for _, p := range g.params {
+			if p.optional && g.rnd.Intn(4) == 0 {
+				continue
+			}
+			if len(p.values) > 0 && g.rnd.Intn(2) == 0 {
+				params[p.name] = p.values[g.rnd.Intn(len(p.values))]
+			} else {
+				params[p.name] = g.random(p.typ)
+			}
+		}
+
+		if !g.call(params, fn) {
+			return
+		}
+	}
+}
+
+// choices returns the number of values of the param, including its absence if it is optional
+// or if no value can be generated for its type.
+func (g *inputGenerator) choices(p *diffParam) int {
+	if p.optional || len(p.values) == 0 {
+		return len(p.values) + 1
+	}
+
+	return len(p.values)
+}
+
+// emit calls fn with the params built from the values at the given indexes,
+// an index out of the values of an optional param meaning that it is omitted.
+func (g *inputGenerator) emit(idx []int, fn func(StringParams) bool) bool {
+	params := make(StringParams)
+	for i, p := range g.params {
+		if idx[i] < len(p.values) {
+			params[p.name] = p.values[idx[i]]
+		}
+	}
+
+	return g.call(params, fn)
+}
+
+func (g *inputGenerator) call(params StringParams, fn func(StringParams) bool) bool {
+	keys := make([]string, 0, len(params))
+	for k, v := range params {
+		keys = append(keys, strconv.Quote(k)+"="+strconv.Quote(v))
+	}
+	sort.Strings(keys)
+
+	key := strings.Join(keys, "&")
+	if g.seen[key] {
+		return true
+	}
+	g.seen[key] = true
+
+	return fn(params)
+}
+
+// random returns a random value of the given type.
+func (g *inputGenerator) random(typ string) string {
+	switch typ {
+	case "string":
+		b := make([]byte, g.rnd.Intn(8))
+		for i := range b {
+			b[i] = byte('a' + g.rnd.Intn(26))
+		}
+		return string(b)
+	case "bool":
+		return strconv.FormatBool(g.rnd.Intn(2) == 0)
+	case "int64":
+		return strconv.FormatInt(g.rnd.Int63n(2001)-1000, 10)
+	case "float64":
+		return formatFloat(g.rnd.Float64()*2000 - 1000)
+	case "duration":
+		return (time.Duration(g.rnd.Int63n(48)) * time.Hour).String()
+	case "time":
+		t := rule.NowFunc().Add(time.Duration(g.rnd.Int63n(2*365*24)-365*24) * time.Hour)
+		return t.UTC().Format(time.RFC3339Nano)
+	case "version":
+		return strconv.Itoa(g.rnd.Intn(10)) + "." + strconv.Itoa(g.rnd.Intn(20)) + "." + strconv.Itoa(g.rnd.Intn(20))
+	case "point":
+		return "[" + formatFloat(g.rnd.Float64()*180-90) + "," + formatFloat(g.rnd.Float64()*360-180) + "]"
+	case "string-list", "int64-list", "float64-list":
+		elems := make([]string, g.rnd.Intn(4))
+		for i := range elems {
+			elems[i] = g.random(strings.TrimSuffix(typ, "-list"))
+			if typ == "string-list" {
+				elems[i] = strconv.Quote(elems[i])
+			}
+		}
+		return "[" + strings.Join(elems, ",") + "]"
+	}
+
+	return ""
+}
@@ -0,0 +1,173 @@
+package regula
+
+import (
+	"testing"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiff(t *testing.T) {
+	parse := func(t *testing.T, src string) *Ruleset {
+		t.Helper()
+
+		rs, err := ParseRuleset(src)
+		require.NoError(t, err)
+		return rs
+	}
+
+	t.Run("Equivalent", func(t *testing.T) {
+		old := parse(t, `
+			city == "paris" and age:int64 > 18 -> 3.0
+			true -> 1.0
+		`)
+		new := parse(t, `
+			age:int64 >= 19 and city in ("paris") -> 3.0
+			true -> 1.0
+		`)
+
+		diffs, err := Diff(old, new)
+		require.NoError(t, err)
+		require.Empty(t, diffs)
+	})
+
+	t.Run("Computed result", func(t *testing.T) {
+		// as decoded from JSON, the literal isn't formatted like computed values
+		old := &Ruleset{Type: "float64", Rules: []*rule.Rule{
+			rule.New(rule.True(), &rule.Value{Kind: "value", Type: "float64", Data: "3.5"}),
+		}}
+		new := parse(t, `true -> 7.0 / 2.0`)
+
+		diffs, err := Diff(old, new, DiffSamples(0))
+		require.NoError(t, err)
+		require.Empty(t, diffs)
+	})
+
+	t.Run("Boundary", func(t *testing.T) {
+		old := parse(t, `
+			age:int64 > 18 -> 3.0
+			true -> 1.0
+		`)
+		new := parse(t, `
+			age:int64 >= 18 -> 3.0
+			true -> 1.0
+		`)
+
+		diffs, err := Diff(old, new)
+		require.NoError(t, err)
+		require.Equal(t, []Difference{{
+			Params: map[string]string{"age": "18"},
+			Old:    Outcome{Value: rule.Float64Value(1)},
+			New:    Outcome{Value: rule.Float64Value(3)},
+		}}, diffs)
+	})
+
+	t.Run("Float boundary", func(t *testing.T) {
+		values := boundaries("float64", []*rule.Value{rule.Float64Value(1.5)})
+		require.Equal(t, []string{"1.500000", "1.499999", "1.500001", "0.000000"}, values)
+
+		old := parse(t, `
+			x:float64 > 1.5 -> 3.0
+			true -> 1.0
+		`)
+		new := parse(t, `
+			x:float64 > 1.4 -> 3.0
+			true -> 1.0
+		`)
+
+		diffs, err := Diff(old, new, DiffSamples(0))
+		require.NoError(t, err)
+		require.Contains(t, diffs, Difference{
+			Params: map[string]string{"x": "1.400001"},
+			Old:    Outcome{Value: rule.Float64Value(1)},
+			New:    Outcome{Value: rule.Float64Value(3)},
+		})
+	})
+
+	t.Run("New rule", func(t *testing.T) {
+		old := parse(t, `
+			city == "paris" -> 3.0
+		`)
+		new := parse(t, `
+			city == "paris" -> 3.0
+			city == "lyon" -> 2.0
+		`)
+
+		diffs, err := Diff(old, new, DiffSamples(0))
+		require.NoError(t, err)
+		require.Equal(t, []Difference{{
+			Params: map[string]string{"city": "lyon"},
+			Old:    Outcome{Error: rule.ErrNoMatch.Error()},
+			New:    Outcome{Value: rule.Float64Value(2)},
+		}}, diffs)
+	})
+
+	t.Run("Optional param", func(t *testing.T) {
+		old := parse(t, `
+			promo:string? == "summer" -> 2.0
+			true -> 1.0
+		`)
+		new := parse(t, `
+			has(promo?) -> 2.0
+			true -> 1.0
+		`)
+
+		diffs, err := Diff(old, new, DiffSamples(0))
+		require.NoError(t, err)
+		require.Equal(t, []Difference{{
+			Params: map[string]string{"promo": ""},
+			Old:    Outcome{Value: rule.Float64Value(1)},
+			New:    Outcome{Value: rule.Float64Value(2)},
+		}}, diffs)
+	})
+
+	t.Run("Random samples", func(t *testing.T) {
+		old := parse(t, `
+			city == "paris" -> 3.0
+			true -> 1.0
+		`)
+		new := parse(t, `
+			city == "paris" -> 3.0
+			city != "" -> 2.0
+			true -> 1.0
+		`)
+
+		diffs, err := Diff(old, new, DiffSamples(0))
+		require.NoError(t, err)
+		require.Empty(t, diffs)
+
+		diffs, err = Diff(old, new, DiffSamples(50), DiffSeed(42), DiffLimit(3))
+		require.NoError(t, err)
+		require.Len(t, diffs, 3)
+		for _, d := range diffs {
+			require.NotEqual(t, "paris", d.Params["city"])
+			require.Equal(t, Outcome{Value: rule.Float64Value(2)}, d.New)
+		}
+	})
+
+	t.Run("Version samples", func(t *testing.T) {
+		// without constants, the versions to compare can only come from the random samples
+		old := parse(t, `
+			app:version > min:version -> 2.0
+			true -> 1.0
+		`)
+		new := parse(t, `
+			app:version > min:version -> 2.0
+			app:version < min:version -> 3.0
+			true -> 1.0
+		`)
+
+		diffs, err := Diff(old, new, DiffSamples(50), DiffLimit(1))
+		require.NoError(t, err)
+		require.Len(t, diffs, 1)
+		require.Equal(t, Outcome{Value: rule.Float64Value(3)}, diffs[0].New)
+	})
+
+	t.Run("Invalid ruleset", func(t *testing.T) {
+		old := parse(t, `true -> 1.0`)
+		new := &Ruleset{Type: "string", Rules: old.Rules}
+
+		_, err := Diff(old, new)
+		require.Equal(t, ErrRulesetIncoherentType, err)
+	})
+}
@@ -38,6 +38,17 @@ All the rules of a ruleset always return the same type.
 		"score": int64(20),
 	})
 
+Rulesets can also be written using a human readable syntax, one rule per line, and parsed using ParseRuleset.
+FormatRuleset does the opposite.
+
+	rs, err := regula.ParseRuleset(`
+		type string
+
+		group == "admin" -> "first rule matched"
+		score:int64 in (10, 20, 30) -> "second rule matched"
+		true -> "default rule matched"
+	`)
+
 To query and evaluate rulesets with a set of parameters, the engine must be used.
 An engine takes an evaluator which is responsible of evaluating rulesets on demand and return a value, the engine then parses the value into a type safe result
 and return it to the caller.
@@ -2,6 +2,7 @@ package regula
 
 import (
 	"context"
+	"encoding/json"
 	"strconv"
 	"sync"
 
@@ -38,16 +39,23 @@ func (e *Engine) get(ctx context.Context, typ, path string, params rule.Params,
 		err    error
 	)
 
-	if cfg.Version != "" {
+	if cfg.Trace {
+		te, ok := e.evaluator.(TraceEvaluator)
+		if !ok {
+			return nil, ErrTraceNotSupported
+		}
+		result, err = te.EvalTrace(ctx, path, cfg.Version, params)
+	} else if cfg.Version != "" {
 		result, err = e.evaluator.EvalVersion(ctx, path, cfg.Version, params)
 	} else {
 		result, err = e.evaluator.Eval(ctx, path, params)
 	}
 	if err != nil {
+		// the result holds the trace of the evaluation, if requested
 		if err == ErrRulesetNotFound || err == rule.ErrNoMatch {
-			return nil, err
+			return result, err
 		}
-		return nil, errors.Wrap(err, "failed to evaluate ruleset")
+		return result, errors.Wrap(err, "failed to evaluate ruleset")
 	}
 
 	if result.Value.Type != typ {
@@ -61,7 +69,7 @@ func (e *Engine) get(ctx context.Context, typ, path string, params rule.Params,
 func (e *Engine) GetString(ctx context.Context, path string, params rule.Params, opts ...Option) (string, *EvalResult, error) {
 	res, err := e.get(ctx, "string", path, params, opts...)
 	if err != nil {
-		return "", nil, err
+		return "", res, err
 	}
 
 	return res.Value.Data, res, nil
@@ -71,7 +79,7 @@ func (e *Engine) GetString(ctx context.Context, path string, params rule.Params,
 func (e *Engine) GetBool(ctx context.Context, path string, params rule.Params, opts ...Option) (bool, *EvalResult, error) {
 	res, err := e.get(ctx, "bool", path, params, opts...)
 	if err != nil {
-		return false, nil, err
+		return false, res, err
 	}
 
 	b, err := strconv.ParseBool(res.Value.Data)
@@ -82,7 +90,7 @@ func (e *Engine) GetBool(ctx context.Context, path string, params rule.Params, o
 func (e *Engine) GetInt64(ctx context.Context, path string, params rule.Params, opts ...Option) (int64, *EvalResult, error) {
 	res, err := e.get(ctx, "int64", path, params, opts...)
 	if err != nil {
-		return 0, nil, err
+		return 0, res, err
 	}
 
 	i, err := strconv.ParseInt(res.Value.Data, 10, 64)
@@ -93,13 +101,72 @@ func (e *Engine) GetInt64(ctx context.Context, path string, params rule.Params,
 func (e *Engine) GetFloat64(ctx context.Context, path string, params rule.Params, opts ...Option) (float64, *EvalResult, error) {
 	res, err := e.get(ctx, "float64", path, params, opts...)
 	if err != nil {
-		return 0, nil, err
+		return 0, res, err
 	}
 
 	f, err := strconv.ParseFloat(res.Value.Data, 64)
 	return f, res, err
 }
 
+// GetObject evaluates an object ruleset and returns the fields of the result.
+// Numbers are returned as json.Number values, see rule.DecodeObject.
+func (e *Engine) GetObject(ctx context.Context, path string, params rule.Params, opts ...Option) (map[string]interface{}, *EvalResult, error) {
+	res, err := e.get(ctx, "object", path, params, opts...)
+	if err != nil {
+		return nil, res, err
+	}
+
+	fields, err := rule.DecodeObject(res.Value)
+	return fields, res, err
+}
+
+// Unmarshal evaluates an object ruleset and decodes the result into the value pointed to by v,
+// using the same rules as json.Unmarshal.
+func (e *Engine) Unmarshal(ctx context.Context, path string, params rule.Params, v interface{}, opts ...Option) (*EvalResult, error) {
+	res, err := e.get(ctx, "object", path, params, opts...)
+	if err != nil {
+		return res, err
+	}
+
+	return res, json.Unmarshal([]byte(res.Value.Data), v)
+}
+
+// GetStringList evaluates a ruleset using the all-matches strategy and returns the result as a list of strings.
+func (e *Engine) GetStringList(ctx context.Context, path string, params rule.Params, opts ...Option) ([]string, *EvalResult, error) {
+	res, err := e.get(ctx, "string-list", path, params, opts...)
+	if err != nil {
+		return nil, res, err
+	}
+
+	var l []string
+	err = json.Unmarshal([]byte(res.Value.Data), &l)
+	return l, res, err
+}
+
+// GetInt64List evaluates a ruleset using the all-matches strategy and returns the result as a list of int64.
+func (e *Engine) GetInt64List(ctx context.Context, path string, params rule.Params, opts ...Option) ([]int64, *EvalResult, error) {
+	res, err := e.get(ctx, "int64-list", path, params, opts...)
+	if err != nil {
+		return nil, res, err
+	}
+
+	var l []int64
+	err = json.Unmarshal([]byte(res.Value.Data), &l)
+	return l, res, err
+}
+
+// GetFloat64List evaluates a ruleset using the all-matches strategy and returns the result as a list of float64.
+func (e *Engine) GetFloat64List(ctx context.Context, path string, params rule.Params, opts ...Option) ([]float64, *EvalResult, error) {
+	res, err := e.get(ctx, "float64-list", path, params, opts...)
+	if err != nil {
+		return nil, res, err
+	}
+
+	var l []float64
+	err = json.Unmarshal([]byte(res.Value.Data), &l)
+	return l, res, err
+}
+
 // LoadStruct takes a pointer to struct and params and loads rulesets into fields
 // tagged with the "ruleset" struct tag.
 func (e *Engine) LoadStruct(ctx context.Context, to interface{}, params rule.Params) error {
@@ -124,6 +191,7 @@ func (e *Engine) LoadStruct(ctx context.Context, to interface{}, params rule.Par
 
 type engineConfig struct {
 	Version string
+	Trace   bool
 }
 
 // Option is used to customize the engine behaviour.
@@ -136,6 +204,15 @@ func Version(version string) Option {
 	}
 }
 
+// Trace is an option used to request the trace of the evaluation, returned in the Trace field of the EvalResult.
+// The evaluator must implement the TraceEvaluator interface, otherwise ErrTraceNotSupported is returned.
+// If the evaluation fails, for instance if no rule matched, the result holding the trace is returned along with the error.
+func Trace() Option {
+	return func(cfg *engineConfig) {
+		cfg.Trace = true
+	}
+}
+
 // An Evaluator provides methods to evaluate rulesets from any location.
 // Long running implementations must listen to the given context for timeout and cancelation.
 type Evaluator interface {
@@ -147,12 +224,28 @@ type Evaluator interface {
 	EvalVersion(ctx context.Context, path string, version string, params rule.Params) (*EvalResult, error)
 }
 
+// A TraceEvaluator is an Evaluator able to explain its evaluations.
+type TraceEvaluator interface {
+	Evaluator
+	// EvalTrace evaluates the given version of a ruleset, or the latest one if version is empty,
+	// and returns the trace of the evaluation in the Trace field of the result.
+	// If the evaluation fails, the result holding the trace is returned along with the error.
+	// If no ruleset is found for a given path, the implementation must return ErrRulesetNotFound.
+	EvalTrace(ctx context.Context, path string, version string, params rule.Params) (*EvalResult, error)
+}
+
 // EvalResult is the product of an evaluation. It contains the value generated as long as some metadata.
 type EvalResult struct {
 	// Result of the evaluation
 	Value *rule.Value
 	// Version of the ruleset that generated this value
 	Version string
+	// Trace of the evaluation, if requested
+	Trace *RulesetTrace
+	// Default reports whether no rule matched and the default result of the ruleset was returned
+	Default bool
+	// RuleID is the ID of the rule that matched, if it has one
+	RuleID string
 }
 
 // RulesetBuffer can hold a group of rulesets in memory and can be used as an evaluator.
@@ -172,13 +265,49 @@ func NewRulesetBuffer() *RulesetBuffer {
 type rulesetInfo struct {
 	path, version string
 	r             *Ruleset
+	// p is the compiled ruleset, nil if it couldn't be compiled.
+	p *Program
+}
+
+// eval evaluates the compiled ruleset if available, the ruleset otherwise, and returns the result.
+func (ri *rulesetInfo) eval(params rule.Params) (*EvalResult, error) {
+	var v *rule.Value
+	var rl *rule.Rule
+	var err error
+
+	if ri.p != nil {
+		v, rl, err = ri.p.EvalRule(params)
+	} else {
+		v, rl, err = ri.r.EvalRule(params)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	res := EvalResult{
+		Value:   v,
+		Version: ri.version,
+		Default: rl == nil,
+	}
+	if rl != nil {
+		res.RuleID = rl.ID
+	}
+
+	return &res, nil
 }
 
 // Add adds the given ruleset version to a list for a specific path.
 // The last added ruleset is treated as the latest version.
+// The ruleset is compiled once, to speed up its evaluation.
 func (b *RulesetBuffer) Add(path, version string, r *Ruleset) {
+	// rulesets that don't compile are evaluated as is, and return the same errors
+	var p *Program
+	if r != nil {
+		p, _ = r.Compile()
+	}
+
 	b.rw.Lock()
-	b.rulesets[path] = append(b.rulesets[path], &rulesetInfo{path, version, r})
+	b.rulesets[path] = append(b.rulesets[path], &rulesetInfo{path, version, r, p})
 	b.rw.Unlock()
 }
 
@@ -219,15 +348,7 @@ func (b *RulesetBuffer) Eval(ctx context.Context, path string, params rule.Param
 	}
 
 	ri := l[len(l)-1]
-	v, err := ri.r.Eval(params)
-	if err != nil {
-		return nil, err
-	}
-
-	return &EvalResult{
-		Value:   v,
-		Version: ri.version,
-	}, nil
+	return ri.eval(params)
 }
 
 func (b *RulesetBuffer) getVersion(path, version string) (*rulesetInfo, error) {
@@ -255,13 +376,45 @@ func (b *RulesetBuffer) EvalVersion(ctx context.Context, path, version string, p
 		return nil, err
 	}
 
-	v, err := ri.r.Eval(params)
-	if err != nil {
-		return nil, err
+	return ri.eval(params)
+}
+
+// EvalTrace evaluates the selected ruleset version, or the latest one if version is empty,
+// and returns the trace of the evaluation, even if the evaluation fails. It returns ErrRulesetNotFound if not found.
+func (b *RulesetBuffer) EvalTrace(ctx context.Context, path, version string, params rule.Params) (*EvalResult, error) {
+	b.rw.RLock()
+	defer b.rw.RUnlock()
+
+	var ri *rulesetInfo
+	if version != "" {
+		var err error
+		ri, err = b.getVersion(path, version)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		l, ok := b.rulesets[path]
+		if !ok || len(l) == 0 {
+			return nil, ErrRulesetNotFound
+		}
+		ri = l[len(l)-1]
 	}
 
-	return &EvalResult{
-		Value:   v,
+	v, trace, err := ri.r.EvalTrace(params)
+	res := EvalResult{
 		Version: ri.version,
-	}, nil
+		Trace:   trace,
+	}
+	if err != nil {
+		// the trace explains why the evaluation failed
+		return &res, err
+	}
+
+	res.Value = v
+	res.Default = trace.Default
+	if trace.Rule != -1 {
+		res.RuleID = ri.r.Rules[trace.Rule].ID
+	}
+
+	return &res, nil
 }
@@ -2,6 +2,7 @@ package regula_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -45,6 +46,36 @@ func TestEngine(t *testing.T) {
 			rule.New(rule.Eq(rule.StringValue("foo"), rule.StringValue("bar")), rule.StringValue("matched d")),
 		},
 	})
+	buf.Add("default", "1", &regula.Ruleset{
+		Type: "string",
+		Rules: []*rule.Rule{
+			rule.New(rule.Eq(rule.StringValue("foo"), rule.StringValue("bar")), rule.StringValue("matched e")),
+		},
+		Default: rule.StringValue("default e"),
+	})
+	buf.Add("all-matches", "1", &regula.Ruleset{
+		Type:     "int64",
+		Strategy: regula.StrategyAllMatches,
+		Rules: []*rule.Rule{
+			rule.New(rule.True(), rule.Int64Value(1)),
+			rule.New(rule.Not(rule.True()), rule.Int64Value(2)),
+			rule.New(rule.True(), rule.Int64Value(3)),
+		},
+	})
+	buf.Add("rule-id", "1", &regula.Ruleset{
+		Type: "string",
+		Rules: []*rule.Rule{
+			{Expr: rule.Eq(rule.StringParam("foo"), rule.StringValue("bar")), Result: rule.StringValue("bar"), ID: "is-bar"},
+			{Expr: rule.True(), Result: rule.StringValue("other"), ID: "fallback"},
+		},
+	})
+	buf.Add("object", "1", &regula.Ruleset{
+		Type:   "object",
+		Schema: map[string]string{"radius": "float64", "drivers": "int64"},
+		Rules: []*rule.Rule{
+			rule.New(rule.True(), rule.ObjectValue(map[string]interface{}{"radius": 3.5, "drivers": 10})),
+		},
+	})
 	buf.Add("match-bool", "1", &regula.Ruleset{
 		Type: "bool",
 		Rules: []*rule.Rule{
@@ -112,10 +143,78 @@ func TestEngine(t *testing.T) {
 		_, _, err = e.GetString(ctx, "no-match", nil)
 		require.Equal(t, rule.ErrNoMatch, err)
 
+		str, res, err = e.GetString(ctx, "default", nil)
+		require.NoError(t, err)
+		require.Equal(t, "default e", str)
+		require.True(t, res.Default)
+
+		l, _, err := e.GetInt64List(ctx, "all-matches", nil)
+		require.NoError(t, err)
+		require.Equal(t, []int64{1, 3}, l)
+
+		_, _, err = e.GetInt64(ctx, "all-matches", nil)
+		require.Equal(t, regula.ErrTypeMismatch, err)
+
+		str, res, err = e.GetString(ctx, "rule-id", regula.Params{"foo": "baz"})
+		require.NoError(t, err)
+		require.Equal(t, "other", str)
+		require.Equal(t, "fallback", res.RuleID)
+
+		str, res, err = e.GetString(ctx, "rule-id", regula.Params{"foo": "bar"}, regula.Trace())
+		require.NoError(t, err)
+		require.Equal(t, "bar", str)
+		require.Equal(t, "is-bar", res.RuleID)
+
+		obj, _, err := e.GetObject(ctx, "object", nil)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{"radius": json.Number("3.5"), "drivers": json.Number("10")}, obj)
+
+		var dispatch struct {
+			Radius  float64 `json:"radius"`
+			Drivers int64   `json:"drivers"`
+		}
+		res, err = e.Unmarshal(ctx, "object", nil, &dispatch)
+		require.NoError(t, err)
+		require.Equal(t, "1", res.Version)
+		require.Equal(t, 3.5, dispatch.Radius)
+		require.Equal(t, int64(10), dispatch.Drivers)
+
+		_, err = e.Unmarshal(ctx, "match-string-b", nil, &dispatch)
+		require.Equal(t, regula.ErrTypeMismatch, err)
+
 		_, _, err = e.GetString(ctx, "not-found", nil)
 		require.Equal(t, regula.ErrRulesetNotFound, err)
 	})
 
+	t.Run("Trace", func(t *testing.T) {
+		str, res, err := e.GetString(ctx, "match-string-a", regula.Params{
+			"foo": "bar",
+		}, regula.Version("1"), regula.Trace())
+		require.NoError(t, err)
+		require.Equal(t, "matched a v1", str)
+		require.Equal(t, "1", res.Version)
+		require.Equal(t, 0, res.Trace.Rule)
+		require.Len(t, res.Trace.Rules, 1)
+		require.Equal(t, rule.StringValue("bar"), res.Trace.Rules[0].Expr.Operands[0].Value)
+
+		// the trace explains why no rule matched
+		_, res, err = e.GetString(ctx, "no-match", nil, regula.Trace())
+		require.Equal(t, rule.ErrNoMatch, err)
+		require.Equal(t, -1, res.Trace.Rule)
+		require.NotEmpty(t, res.Trace.Rules)
+		require.False(t, res.Trace.Rules[0].Matched)
+
+		str, res, err = e.GetString(ctx, "default", nil, regula.Trace())
+		require.NoError(t, err)
+		require.Equal(t, "default e", str)
+		require.True(t, res.Default)
+		require.Equal(t, -1, res.Trace.Rule)
+		require.True(t, res.Trace.Default)
+
+		_, _, err = regula.NewEngine(struct{ regula.Evaluator }{buf}).GetString(ctx, "match-string-a", nil, regula.Trace())
+		require.Equal(t, regula.ErrTraceNotSupported, err)
+	})
+
 	t.Run("StructLoading", func(t *testing.T) {
 		to := struct {
 			StringA  string        `ruleset:"match-string-a"`
@@ -11,4 +11,7 @@ var (
 
 	// ErrRulesetIncoherentType is returned when a ruleset contains rules of different types.
 	ErrRulesetIncoherentType = errors.New("types in ruleset are incoherent")
+
+	// ErrTraceNotSupported is returned when a trace is requested from an evaluator that doesn't implement TraceEvaluator.
+	ErrTraceNotSupported = errors.New("evaluator doesn't support traces")
 )
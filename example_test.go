@@ -232,3 +232,29 @@ func ExampleEngine_LoadStruct() {
 	// 10
 	// 3s
 }
+
+func ExampleStructParams() {
+	rs, err := regula.ParseRuleset(`
+		type string
+
+		city == "paris" and distance:int64 > 10 -> "far"
+		true -> "near"
+	`)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	type trip struct {
+		City     string `param:"city"`
+		Distance int    `param:"distance"`
+	}
+
+	v, err := rs.Eval(regula.StructParams(trip{City: "paris", Distance: 12}))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println(v.Data)
+	// Output:
+	// far
+}
@@ -0,0 +1,165 @@
+package regula
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/heetch/regula/rule"
+)
+
+// NewObjectRuleset creates a ruleset which rules all return an object matching the given schema,
+// otherwise an error is returned. The schema associates the name of each field with its type,
+// which must be string, bool, int64 or float64.
+func NewObjectRuleset(schema map[string]string, rules ...*rule.Rule) (*Ruleset, error) {
+	rs := Ruleset{
+		Rules:  rules,
+		Type:   "object",
+		Schema: schema,
+	}
+
+	err := rs.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+// validateSchema ensures the schema is only set on object rulesets and that its fields are of supported types.
+func (r *Ruleset) validateSchema() error {
+	if r.Type != "object" {
+		if len(r.Schema) > 0 {
+			return errors.New("a schema can only be declared by object rulesets")
+		}
+		return nil
+	}
+
+	if len(r.Schema) == 0 {
+		return errors.New("object rulesets must declare a schema")
+	}
+
+	for name, typ := range r.Schema {
+		if name == "" {
+			return errors.New("schema field names must not be empty")
+		}
+
+		switch typ {
+		case "string", "bool", "int64", "float64":
+		default:
+			return fmt.Errorf("unsupported type %s for schema field %s", typ, name)
+		}
+	}
+
+	return nil
+}
+
+// checkObject ensures e is an object value matching the schema of the ruleset.
+func (r *Ruleset) checkObject(e rule.Expr, path string) error {
+	v, ok := e.(*rule.Value)
+	if !ok {
+		return &rule.TypeError{Path: path, Msg: "the result of an object ruleset must be an object value"}
+	}
+
+	fields, err := rule.DecodeObject(v)
+	if err != nil {
+		return &rule.TypeError{Path: path, Msg: err.Error()}
+	}
+
+	// fields are checked in order to report errors consistently
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		if _, ok := r.Schema[name]; !ok {
+			return &rule.TypeError{Path: path, Msg: fmt.Sprintf("unknown field %s", name)}
+		}
+	}
+
+	names = names[:0]
+	for name := range r.Schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		typ := r.Schema[name]
+		f, ok := fields[name]
+		if !ok {
+			return &rule.TypeError{Path: path, Msg: fmt.Sprintf("missing field %s", name)}
+		}
+
+		if !isSchemaType(typ, f) {
+			return &rule.TypeError{Path: path, Msg: fmt.Sprintf("field %s must be of type %s", name, typ)}
+		}
+	}
+
+	return nil
+}
+
+func isSchemaType(typ string, f interface{}) bool {
+	switch typ {
+	case "string":
+		_, ok := f.(string)
+		return ok
+	case "bool":
+		_, ok := f.(bool)
+		return ok
+	}
+
+	n, ok := f.(json.Number)
+	if !ok {
+		return false
+	}
+
+	var err error
+	if typ == "int64" {
+		_, err = n.Int64()
+	} else {
+		_, err = n.Float64()
+	}
+
+	return err == nil
+}
+
+// formatSchema returns the schema in the format of the schema header, the fields being sorted by name.
+func formatSchema(schema map[string]string) string {
+	fields := make([]string, 0, len(schema))
+	for name, typ := range schema {
+		fields = append(fields, name+":"+typ)
+	}
+	sort.Strings(fields)
+
+	return strings.Join(fields, " ")
+}
+
+// parseSchema parses the fields of the schema header, of the form name:type.
+// It returns ok false if one of the fields is not of that form, in which case the line is not a header.
+func parseSchema(fields []string) (schema map[string]string, ok bool, err error) {
+	schema = make(map[string]string)
+
+	for _, f := range fields {
+		idx := strings.LastIndexByte(f, ':')
+		if idx <= 0 {
+			return nil, false, nil
+		}
+		schema[f[:idx]] = f[idx+1:]
+	}
+
+	// the types are only checked once the line is known to be a header
+	for _, f := range fields {
+		idx := strings.LastIndexByte(f, ':')
+		switch f[idx+1:] {
+		case "string", "bool", "int64", "float64":
+		default:
+			return nil, true, fmt.Errorf("unsupported type %s for schema field %s", f[idx+1:], f[:idx])
+		}
+	}
+
+	return schema, true, nil
+}
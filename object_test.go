@@ -0,0 +1,126 @@
+package regula
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+var dispatchSchema = map[string]string{
+	"radius":  "float64",
+	"drivers": "int64",
+	"zone":    "string",
+	"surge":   "bool",
+}
+
+func dispatchValue(radius float64, drivers int64) *rule.Value {
+	return rule.ObjectValue(map[string]interface{}{
+		"radius":  radius,
+		"drivers": drivers,
+		"zone":    "center",
+		"surge":   false,
+	})
+}
+
+func TestObjectRuleset(t *testing.T) {
+	t.Run("Eval", func(t *testing.T) {
+		rs, err := NewObjectRuleset(dispatchSchema,
+			rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), dispatchValue(3.5, 10)),
+			rule.New(rule.True(), dispatchValue(5, 3)),
+		)
+		require.NoError(t, err)
+
+		v, err := rs.Eval(Params{"city": "paris"})
+		require.NoError(t, err)
+		require.Equal(t, dispatchValue(3.5, 10), v)
+
+		p, err := rs.Compile()
+		require.NoError(t, err)
+
+		v, err = p.Eval(Params{"city": "lyon"})
+		require.NoError(t, err)
+		require.Equal(t, dispatchValue(5, 3), v)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			schema map[string]string
+			result rule.Expr
+			err    string
+		}{
+			{"Missing schema", nil, dispatchValue(1, 1), "object rulesets must declare a schema"},
+			{"Unsupported field type", map[string]string{"at": "time"}, dispatchValue(1, 1), "unsupported type time for schema field at"},
+			{"Computed result", dispatchSchema, rule.Coalesce(dispatchValue(1, 1), dispatchValue(1, 2)), "/rules/0/result: the result of an object ruleset must be an object value"},
+			{"Missing field", map[string]string{"radius": "float64", "other": "string"}, rule.ObjectValue(map[string]interface{}{"radius": 1}), "/rules/0/result: missing field other"},
+			{"Unknown field", map[string]string{"radius": "float64"}, dispatchValue(1, 1), "/rules/0/result: unknown field drivers"},
+			{"Wrong field type", map[string]string{"radius": "int64"}, rule.ObjectValue(map[string]interface{}{"radius": 1.5}), "/rules/0/result: field radius must be of type int64"},
+			{"Not an object", dispatchSchema, rule.StringValue("a"), ErrRulesetIncoherentType.Error()},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := NewObjectRuleset(test.schema, rule.New(rule.True(), test.result))
+				require.EqualError(t, err, test.err)
+			})
+		}
+
+		_, err := NewInt64Ruleset(rule.New(rule.True(), rule.Int64Value(1)))
+		require.NoError(t, err)
+
+		rs := Ruleset{Type: "int64", Schema: dispatchSchema}
+		require.EqualError(t, rs.validate(), "a schema can only be declared by object rulesets")
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		rs := Ruleset{Type: "object", Schema: dispatchSchema, Default: rule.ObjectValue(nil)}
+		require.EqualError(t, rs.validate(), "/default: missing field drivers")
+
+		rs.Default = dispatchValue(1, 1)
+		require.NoError(t, rs.validate())
+	})
+
+	t.Run("EncDec", func(t *testing.T) {
+		rs1, err := NewObjectRuleset(dispatchSchema, rule.New(rule.True(), dispatchValue(3.5, 10)))
+		require.NoError(t, err)
+
+		raw, err := json.Marshal(rs1)
+		require.NoError(t, err)
+
+		var rs2 Ruleset
+		err = json.Unmarshal(raw, &rs2)
+		require.NoError(t, err)
+		require.Equal(t, rs1, &rs2)
+	})
+
+	t.Run("Parse and format", func(t *testing.T) {
+		rs, err := ParseRuleset(`
+			type object
+			schema radius:float64 drivers:int64
+
+			city == "paris" -> object("{\"radius\": 3.5, \"drivers\": 10}")
+		`)
+		require.NoError(t, err)
+		require.Equal(t, map[string]string{"radius": "float64", "drivers": "int64"}, rs.Schema)
+
+		s, err := FormatRuleset(rs)
+		require.NoError(t, err)
+		require.Equal(t, `type object
+schema drivers:int64 radius:float64
+
+city == "paris" -> object("{\"radius\": 3.5, \"drivers\": 10}")
+`, s)
+
+		_, err = ParseRuleset("type object\nschema radius:time\n")
+		require.Equal(t, &rule.ParseError{Line: 2, Column: 8, Msg: "invalid schema: unsupported type time for schema field radius"}, err)
+
+		// a rule using a param named schema isn't a header
+		rs, err = ParseRuleset(`schema == "a" -> "x"`)
+		require.NoError(t, err)
+		require.Equal(t, "string", rs.Type)
+		require.Nil(t, rs.Schema)
+		require.Len(t, rs.Rules, 1)
+	})
+}
@@ -1,7 +1,9 @@
 package regula
 
 import (
+	"encoding/json"
 	"strconv"
+	"time"
 
 	"github.com/heetch/regula/rule"
 	"github.com/pkg/errors"
@@ -70,6 +72,96 @@ func (p Params) GetFloat64(key string) (float64, error) {
 	return f, nil
 }
 
+// GetTime extracts a time parameter corresponding to the given key.
+func (p Params) GetTime(key string) (time.Time, error) {
+	v, ok := p[key]
+	if !ok {
+		return time.Time{}, rule.ErrParamNotFound
+	}
+
+	t, ok := v.(time.Time)
+	if !ok {
+		return time.Time{}, rule.ErrParamTypeMismatch
+	}
+
+	return t, nil
+}
+
+// GetDuration extracts a duration parameter corresponding to the given key.
+func (p Params) GetDuration(key string) (time.Duration, error) {
+	v, ok := p[key]
+	if !ok {
+		return 0, rule.ErrParamNotFound
+	}
+
+	d, ok := v.(time.Duration)
+	if !ok {
+		return 0, rule.ErrParamTypeMismatch
+	}
+
+	return d, nil
+}
+
+// GetStringList extracts a list of strings parameter corresponding to the given key.
+func (p Params) GetStringList(key string) ([]string, error) {
+	v, ok := p[key]
+	if !ok {
+		return nil, rule.ErrParamNotFound
+	}
+
+	l, ok := v.([]string)
+	if !ok {
+		return nil, rule.ErrParamTypeMismatch
+	}
+
+	return l, nil
+}
+
+// GetInt64List extracts a list of int64 parameter corresponding to the given key.
+func (p Params) GetInt64List(key string) ([]int64, error) {
+	v, ok := p[key]
+	if !ok {
+		return nil, rule.ErrParamNotFound
+	}
+
+	l, ok := v.([]int64)
+	if !ok {
+		return nil, rule.ErrParamTypeMismatch
+	}
+
+	return l, nil
+}
+
+// GetFloat64List extracts a list of float64 parameter corresponding to the given key.
+func (p Params) GetFloat64List(key string) ([]float64, error) {
+	v, ok := p[key]
+	if !ok {
+		return nil, rule.ErrParamNotFound
+	}
+
+	l, ok := v.([]float64)
+	if !ok {
+		return nil, rule.ErrParamTypeMismatch
+	}
+
+	return l, nil
+}
+
+// GetPoint extracts a point parameter corresponding to the given key.
+func (p Params) GetPoint(key string) (rule.Point, error) {
+	v, ok := p[key]
+	if !ok {
+		return rule.Point{}, rule.ErrParamNotFound
+	}
+
+	pt, ok := v.(rule.Point)
+	if !ok {
+		return rule.Point{}, rule.ErrParamTypeMismatch
+	}
+
+	return pt, nil
+}
+
 // Keys returns the list of all the keys.
 func (p Params) Keys() []string {
 	keys := make([]string, 0, len(p))
@@ -81,12 +173,18 @@ func (p Params) Keys() []string {
 }
 
 // EncodeValue returns the string representation of the selected value.
+// Lists are encoded as JSON arrays, and points as JSON arrays holding their latitude and longitude.
 func (p Params) EncodeValue(key string) (string, error) {
 	v, ok := p[key]
 	if !ok {
 		return "", rule.ErrParamNotFound
 	}
 
+	return encodeValue(v)
+}
+
+// encodeValue returns the string representation of a param value, see Params.EncodeValue.
+func encodeValue(v interface{}) (string, error) {
 	switch t := v.(type) {
 	case string:
 		return t, nil
@@ -96,6 +194,22 @@ func (p Params) EncodeValue(key string) (string, error) {
 		return strconv.FormatFloat(t, 'f', 6, 64), nil
 	case bool:
 		return strconv.FormatBool(t), nil
+	case time.Time:
+		return t.Format(time.RFC3339Nano), nil
+	case time.Duration:
+		return t.String(), nil
+	case []string, []int64, []float64:
+		b, err := json.Marshal(t)
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	case rule.Point:
+		b, err := json.Marshal([]float64{t.Lat, t.Lng})
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
 	default:
 		return "", errors.Errorf("type %t is not supported", t)
 	}
@@ -2,6 +2,7 @@ package regula
 
 import (
 	"testing"
+	"time"
 
 	"github.com/heetch/regula/rule"
 	"github.com/stretchr/testify/require"
@@ -106,3 +107,184 @@ func TestGetFloat64(t *testing.T) {
 		require.Equal(t, err, rule.ErrParamTypeMismatch)
 	})
 }
+
+func TestGetTime(t *testing.T) {
+	p := Params{
+		"time":   time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC),
+		"string": "string",
+	}
+
+	t.Run("GetTime - OK", func(t *testing.T) {
+		v, err := p.GetTime("time")
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC), v)
+	})
+
+	t.Run("GetTime - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetTime("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetTime - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetTime("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestGetDuration(t *testing.T) {
+	p := Params{
+		"duration": 90 * time.Minute,
+		"string":   "string",
+	}
+
+	t.Run("GetDuration - OK", func(t *testing.T) {
+		v, err := p.GetDuration("duration")
+		require.NoError(t, err)
+		require.Equal(t, 90*time.Minute, v)
+	})
+
+	t.Run("GetDuration - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetDuration("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetDuration - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetDuration("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestEncodeValue(t *testing.T) {
+	p := Params{
+		"string":   "foo",
+		"time":     time.Date(2026, 10, 17, 12, 30, 0, 500, time.UTC),
+		"duration": 90 * time.Minute,
+		"strings":  []string{"a", "b"},
+		"int64s":   []int64{1, 2},
+		"float64s": []float64{1.5},
+		"point":    rule.Point{Lat: 48.8566, Lng: 2.3522},
+	}
+
+	tests := map[string]string{
+		"string":   "foo",
+		"time":     "2026-10-17T12:30:00.0000005Z",
+		"duration": "1h30m0s",
+		"strings":  `["a","b"]`,
+		"int64s":   `[1,2]`,
+		"float64s": `[1.5]`,
+		"point":    `[48.8566,2.3522]`,
+	}
+
+	for key, expected := range tests {
+		v, err := p.EncodeValue(key)
+		require.NoError(t, err)
+		require.Equal(t, expected, v)
+	}
+
+	_, err := p.EncodeValue("badkey")
+	require.Equal(t, rule.ErrParamNotFound, err)
+}
+
+func TestGetStringList(t *testing.T) {
+	p := Params{
+		"list":   []string{"a", "b"},
+		"string": "string",
+	}
+
+	t.Run("GetStringList - OK", func(t *testing.T) {
+		v, err := p.GetStringList("list")
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, v)
+	})
+
+	t.Run("GetStringList - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetStringList("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetStringList - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetStringList("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestGetInt64List(t *testing.T) {
+	p := Params{
+		"list":   []int64{1, 2},
+		"string": "string",
+	}
+
+	t.Run("GetInt64List - OK", func(t *testing.T) {
+		v, err := p.GetInt64List("list")
+		require.NoError(t, err)
+		require.Equal(t, []int64{1, 2}, v)
+	})
+
+	t.Run("GetInt64List - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetInt64List("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetInt64List - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetInt64List("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestGetFloat64List(t *testing.T) {
+	p := Params{
+		"list":   []float64{1.5},
+		"string": "string",
+	}
+
+	t.Run("GetFloat64List - OK", func(t *testing.T) {
+		v, err := p.GetFloat64List("list")
+		require.NoError(t, err)
+		require.Equal(t, []float64{1.5}, v)
+	})
+
+	t.Run("GetFloat64List - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetFloat64List("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetFloat64List - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetFloat64List("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestGetPoint(t *testing.T) {
+	p := Params{
+		"point":  rule.Point{Lat: 48.8566, Lng: 2.3522},
+		"string": "string",
+	}
+
+	t.Run("GetPoint - OK", func(t *testing.T) {
+		v, err := p.GetPoint("point")
+		require.NoError(t, err)
+		require.Equal(t, rule.Point{Lat: 48.8566, Lng: 2.3522}, v)
+	})
+
+	t.Run("GetPoint - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetPoint("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetPoint - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetPoint("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
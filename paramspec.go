@@ -0,0 +1,348 @@
+package regula
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/heetch/regula/rule"
+)
+
+// A ParamSpec declares a param expected by a ruleset, along with the constraints its value must satisfy.
+// When a ruleset declares its params, its rules can only use those params.
+type ParamSpec struct {
+	Name        string `json:"name" yaml:"name"`
+	Type        string `json:"type" yaml:"type"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Optional reports whether the param can be omitted during evaluation.
+	// Rules must use the param with the same optionality.
+	Optional bool `json:"optional,omitempty" yaml:"optional,omitempty"`
+
+	// Enum lists the allowed values of string, int64 and float64 params, in the format of the data of rule values.
+	Enum []string `json:"enum,omitempty" yaml:"enum,omitempty"`
+	// Min and Max are the inclusive bounds of int64 and float64 params.
+	Min *float64 `json:"min,omitempty" yaml:"min,omitempty"`
+	Max *float64 `json:"max,omitempty" yaml:"max,omitempty"`
+	// Pattern is a regular expression string params must match.
+	Pattern string `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+}
+
+// A ParamError is returned by the evaluation of a ruleset when a param doesn't satisfy the constraints
+// declared by the ruleset.
+type ParamError struct {
+	Name   string
+	Reason string
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("invalid param %s: %s", e.Name, e.Reason)
+}
+
+// paramCheck holds a param spec ready to be checked against the params passed during evaluation.
+type paramCheck struct {
+	spec    ParamSpec
+	param   rule.Param
+	enum    []*rule.Value
+	pattern *regexp.Regexp
+}
+
+// paramChecks validates the param specs of the ruleset and prepares them for evaluation.
+func (r *Ruleset) paramChecks() ([]paramCheck, error) {
+	checks := make([]paramCheck, len(r.ParamSpecs))
+	names := make(map[string]bool)
+
+	for i := range r.ParamSpecs {
+		spec := &r.ParamSpecs[i]
+		c := paramCheck{
+			spec:  *spec,
+			param: rule.Param{Kind: "param", Type: spec.Type, Name: spec.Name, Optional: spec.Optional},
+		}
+
+		if spec.Name == "" {
+			return nil, fmt.Errorf("/params/%d: missing name", i)
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf("/params/%d: duplicate param %s", i, spec.Name)
+		}
+		names[spec.Name] = true
+
+		if _, err := rule.TypeCheck(&c.param); err != nil {
+			return nil, fmt.Errorf("/params/%d: unsupported type %s", i, spec.Type)
+		}
+
+		numeric := spec.Type == "int64" || spec.Type == "float64"
+
+		if len(spec.Enum) > 0 {
+			if spec.Type != "string" && !numeric {
+				return nil, fmt.Errorf("/params/%d: enum is only supported by string, int64 and float64 params", i)
+			}
+
+			for _, e := range spec.Enum {
+				v, err := enumValue(spec.Type, e)
+				if err != nil {
+					return nil, fmt.Errorf("/params/%d: invalid enum value %q", i, e)
+				}
+				c.enum = append(c.enum, v)
+			}
+		}
+
+		if spec.Min != nil || spec.Max != nil {
+			if !numeric {
+				return nil, fmt.Errorf("/params/%d: min and max are only supported by int64 and float64 params", i)
+			}
+			if spec.Min != nil && spec.Max != nil && *spec.Min > *spec.Max {
+				return nil, fmt.Errorf("/params/%d: min is greater than max", i)
+			}
+		}
+
+		if spec.Pattern != "" {
+			if spec.Type != "string" {
+				return nil, fmt.Errorf("/params/%d: pattern is only supported by string params", i)
+			}
+
+			var err error
+			c.pattern, err = regexp.Compile(spec.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("/params/%d: invalid pattern: %v", i, err)
+			}
+		}
+
+		checks[i] = c
+	}
+
+	return checks, nil
+}
+
+// enumValue parses an enum entry, formatting numbers the way param values are formatted
+// so that "2" matches a float64 param equal to 2.
+func enumValue(typ, data string) (*rule.Value, error) {
+	switch typ {
+	case "int64":
+		i, err := strconv.ParseInt(data, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return rule.Int64Value(i), nil
+	case "float64":
+		f, err := strconv.ParseFloat(data, 64)
+		if err != nil {
+			return nil, err
+		}
+		return rule.Float64Value(f), nil
+	}
+
+	return rule.StringValue(data), nil
+}
+
+// CheckDeclaredParams ensures the params used by the given rule are declared by the ruleset
+// with the same type and optionality. Rulesets which don't declare their params accept any param.
+func (r *Ruleset) CheckDeclaredParams(rl *rule.Rule) error {
+	if len(r.ParamSpecs) == 0 {
+		return nil
+	}
+
+	for _, p := range rl.Params() {
+		spec := r.paramSpec(p.Name)
+		switch {
+		case spec == nil:
+			return fmt.Errorf("undeclared param %s", p.Name)
+		case spec.Type != p.Type:
+			return fmt.Errorf("param %s must be of type %s", p.Name, spec.Type)
+		case spec.Optional != p.Optional && spec.Optional:
+			return fmt.Errorf("param %s must be optional", p.Name)
+		case spec.Optional != p.Optional:
+			return fmt.Errorf("param %s must not be optional", p.Name)
+		}
+	}
+
+	return nil
+}
+
+// paramSpec returns the spec of the param with the given name, or nil if it isn't declared.
+func (r *Ruleset) paramSpec(name string) *ParamSpec {
+	for i := range r.ParamSpecs {
+		if r.ParamSpecs[i].Name == name {
+			return &r.ParamSpecs[i]
+		}
+	}
+
+	return nil
+}
+
+// checkParams ensures the given params satisfy the constraints of the param specs.
+// Missing params are ignored, it is up to the rules using them to report the error.
+func checkParams(checks []paramCheck, params rule.Params) error {
+	if params == nil {
+		return nil
+	}
+
+	for i := range checks {
+		c := &checks[i]
+
+		v, err := c.param.Eval(params)
+		if err == rule.ErrParamNotFound || err == rule.ErrOptionalParamNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := c.check(v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *paramCheck) check(v *rule.Value) error {
+	if len(c.enum) > 0 {
+		var ok bool
+		for _, e := range c.enum {
+			if e.Equal(v) {
+				ok = true
+				break
+			}
+		}
+
+		if !ok {
+			return &ParamError{Name: c.spec.Name, Reason: "must be one of " + strings.Join(c.spec.Enum, ", ")}
+		}
+	}
+
+	if c.spec.Min != nil || c.spec.Max != nil {
+		f, err := strconv.ParseFloat(v.Data, 64)
+		if err != nil {
+			return err
+		}
+
+		if c.spec.Min != nil && f < *c.spec.Min {
+			return &ParamError{Name: c.spec.Name, Reason: "must be greater than or equal to " + formatBound(*c.spec.Min)}
+		}
+		if c.spec.Max != nil && f > *c.spec.Max {
+			return &ParamError{Name: c.spec.Name, Reason: "must be less than or equal to " + formatBound(*c.spec.Max)}
+		}
+	}
+
+	if c.pattern != nil && !c.pattern.MatchString(v.Data) {
+		return &ParamError{Name: c.spec.Name, Reason: "must match " + c.spec.Pattern}
+	}
+
+	return nil
+}
+
+func formatBound(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// formatParamSpec returns the spec in the format of the param header, e.g.
+//
+//	param age:int64 min 18 max 99 description "Age of the rider"
+func formatParamSpec(spec *ParamSpec) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "param %s:%s", spec.Name, spec.Type)
+	if spec.Optional {
+		b.WriteByte('?')
+	}
+
+	if len(spec.Enum) > 0 {
+		b.WriteString(" enum")
+		for _, e := range spec.Enum {
+			if spec.Type == "string" {
+				e = strconv.Quote(e)
+			}
+			b.WriteString(" " + e)
+		}
+	}
+	if spec.Min != nil {
+		b.WriteString(" min " + formatBound(*spec.Min))
+	}
+	if spec.Max != nil {
+		b.WriteString(" max " + formatBound(*spec.Max))
+	}
+	if spec.Pattern != "" {
+		b.WriteString(" pattern " + strconv.Quote(spec.Pattern))
+	}
+	if spec.Description != "" {
+		b.WriteString(" description " + strconv.Quote(spec.Description))
+	}
+
+	return b.String()
+}
+
+// parseParamSpec parses the fields of the param header, following the param keyword.
+// It returns ok false if the first field is not of the form name:type, in which case the line is not a header.
+func parseParamSpec(line string) (spec *ParamSpec, ok bool, err error) {
+	tokens, err := splitHeader(line)
+	if err != nil || len(tokens) == 0 || tokens[0].quoted {
+		return nil, false, nil
+	}
+
+	decl := tokens[0].text
+	idx := strings.IndexByte(decl, ':')
+	if idx <= 0 {
+		return nil, false, nil
+	}
+
+	spec = &ParamSpec{Name: decl[:idx], Type: decl[idx+1:]}
+	if strings.HasSuffix(spec.Type, "?") {
+		spec.Type = strings.TrimSuffix(spec.Type, "?")
+		spec.Optional = true
+	}
+
+	keyword := func(t headerToken) bool {
+		switch t.text {
+		case "enum", "min", "max", "pattern", "description":
+			return !t.quoted
+		}
+		return false
+	}
+
+	tokens = tokens[1:]
+	for len(tokens) > 0 {
+		kw := tokens[0]
+		if !keyword(kw) {
+			return nil, true, fmt.Errorf("unexpected %s in param declaration", kw.text)
+		}
+		tokens = tokens[1:]
+
+		if kw.text == "enum" {
+			for len(tokens) > 0 && !keyword(tokens[0]) {
+				spec.Enum = append(spec.Enum, tokens[0].text)
+				tokens = tokens[1:]
+			}
+			if len(spec.Enum) == 0 {
+				return nil, true, errors.New("missing enum values")
+			}
+			continue
+		}
+
+		if len(tokens) == 0 || keyword(tokens[0]) {
+			return nil, true, fmt.Errorf("missing %s value", kw.text)
+		}
+		arg := tokens[0].text
+		tokens = tokens[1:]
+
+		switch kw.text {
+		case "min", "max":
+			f, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, true, fmt.Errorf("invalid %s value %s", kw.text, arg)
+			}
+			if kw.text == "min" {
+				spec.Min = &f
+			} else {
+				spec.Max = &f
+			}
+		case "pattern":
+			spec.Pattern = arg
+		case "description":
+			spec.Description = arg
+		}
+	}
+
+	return spec, true, nil
+}
@@ -0,0 +1,170 @@
+package regula
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
+
+func TestParamSpecs(t *testing.T) {
+	specs := []ParamSpec{
+		{Name: "city", Type: "string", Enum: []string{"paris", "lyon"}},
+		{Name: "age", Type: "int64", Min: float64Ptr(18), Max: float64Ptr(99)},
+		{Name: "email", Type: "string", Optional: true, Pattern: `^[a-z]+@[a-z]+\.com$`},
+		{Name: "unused", Type: "float64", Description: "expected but not used yet"},
+	}
+
+	rs := Ruleset{
+		Type:       "bool",
+		ParamSpecs: specs,
+		Rules: []*rule.Rule{
+			rule.New(rule.And(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.GTE(rule.Int64Param("age"), rule.Int64Value(21))), rule.BoolValue(true)),
+			rule.New(rule.Has(rule.Optional(rule.StringParam("email"))), rule.BoolValue(true)),
+			rule.New(rule.True(), rule.BoolValue(false)),
+		},
+	}
+	require.NoError(t, rs.validate())
+	// the specs are prepared once, not on each evaluation
+	require.Len(t, rs.checks, len(specs))
+	require.NotNil(t, rs.checks[2].pattern)
+
+	t.Run("Eval", func(t *testing.T) {
+		p, err := rs.Compile()
+		require.NoError(t, err)
+
+		tests := []struct {
+			name     string
+			params   Params
+			expected *rule.Value
+			err      error
+		}{
+			{"Valid", Params{"city": "paris", "age": int64(30)}, rule.BoolValue(true), nil},
+			{"Valid optional", Params{"city": "lyon", "age": int64(18), "email": "bob@example.com"}, rule.BoolValue(true), nil},
+			{"Enum", Params{"city": "nice", "age": int64(30)}, nil, &ParamError{Name: "city", Reason: "must be one of paris, lyon"}},
+			{"Min", Params{"city": "paris", "age": int64(17)}, nil, &ParamError{Name: "age", Reason: "must be greater than or equal to 18"}},
+			{"Max", Params{"city": "paris", "age": int64(100)}, nil, &ParamError{Name: "age", Reason: "must be less than or equal to 99"}},
+			{"Pattern", Params{"city": "paris", "age": int64(30), "email": "bob"}, nil, &ParamError{Name: "email", Reason: `must match ^[a-z]+@[a-z]+\.com$`}},
+			{"Type mismatch", Params{"city": "paris", "age": 30}, nil, rule.ErrParamTypeMismatch},
+			{"Missing", Params{"age": int64(30)}, nil, rule.ErrParamNotFound},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				v, err := rs.Eval(test.params)
+				require.Equal(t, test.err, err)
+				require.Equal(t, test.expected, v)
+
+				v, _, err = rs.EvalTrace(test.params)
+				require.Equal(t, test.err, err)
+				require.Equal(t, test.expected, v)
+
+				v, err = p.Eval(test.params)
+				require.Equal(t, test.err, err)
+				require.Equal(t, test.expected, v)
+			})
+		}
+	})
+
+	t.Run("Float enum", func(t *testing.T) {
+		rs, err := ParseRuleset(`
+			type bool
+			param x:float64 enum 1.5 2
+
+			x:float64 > 1.0 -> true
+			true -> false
+		`)
+		require.NoError(t, err)
+
+		for _, params := range []rule.Params{Params{"x": 2.0}, StringParams{"x": "2"}, StringParams{"x": "1.50"}} {
+			v, err := rs.Eval(params)
+			require.NoError(t, err)
+			require.Equal(t, rule.BoolValue(true), v)
+		}
+
+		_, err = rs.Eval(Params{"x": 2.5})
+		require.Equal(t, &ParamError{Name: "x", Reason: "must be one of 1.5, 2"}, err)
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			specs []ParamSpec
+			expr  rule.Expr
+			err   string
+		}{
+			{"Missing name", []ParamSpec{{Type: "bool"}}, rule.True(), "/params/0: missing name"},
+			{"Duplicate", []ParamSpec{{Name: "a", Type: "bool"}, {Name: "a", Type: "bool"}}, rule.True(), "/params/1: duplicate param a"},
+			{"Unsupported type", []ParamSpec{{Name: "a", Type: "date"}}, rule.True(), "/params/0: unsupported type date"},
+			{"Bool enum", []ParamSpec{{Name: "a", Type: "bool", Enum: []string{"true"}}}, rule.True(), "/params/0: enum is only supported by string, int64 and float64 params"},
+			{"Invalid enum value", []ParamSpec{{Name: "a", Type: "int64", Enum: []string{"1", "one"}}}, rule.True(), `/params/0: invalid enum value "one"`},
+			{"String range", []ParamSpec{{Name: "a", Type: "string", Min: float64Ptr(1)}}, rule.True(), "/params/0: min and max are only supported by int64 and float64 params"},
+			{"Empty range", []ParamSpec{{Name: "a", Type: "float64", Min: float64Ptr(2), Max: float64Ptr(1)}}, rule.True(), "/params/0: min is greater than max"},
+			{"Int64 pattern", []ParamSpec{{Name: "a", Type: "int64", Pattern: "1+"}}, rule.True(), "/params/0: pattern is only supported by string params"},
+			{"Invalid pattern", []ParamSpec{{Name: "a", Type: "string", Pattern: "("}}, rule.True(), "/params/0: invalid pattern: error parsing regexp: missing closing ): `(`"},
+			{"Undeclared param", []ParamSpec{{Name: "a", Type: "bool"}}, rule.BoolParam("b"), "/rules/0: undeclared param b"},
+			{"Wrong type", []ParamSpec{{Name: "a", Type: "string"}}, rule.BoolParam("a"), "/rules/0: param a must be of type string"},
+			{"Must be optional", []ParamSpec{{Name: "a", Type: "bool", Optional: true}}, rule.BoolParam("a"), "/rules/0: param a must be optional"},
+			{"Must not be optional", []ParamSpec{{Name: "a", Type: "bool"}}, rule.Optional(rule.BoolParam("a")), "/rules/0: param a must not be optional"},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				rs := Ruleset{
+					Type:       "bool",
+					ParamSpecs: test.specs,
+					Rules:      []*rule.Rule{rule.New(test.expr, rule.BoolValue(true))},
+				}
+				require.EqualError(t, rs.validate(), test.err)
+			})
+		}
+	})
+
+	t.Run("EncDec", func(t *testing.T) {
+		raw, err := json.Marshal(&rs)
+		require.NoError(t, err)
+
+		var rs2 Ruleset
+		err = json.Unmarshal(raw, &rs2)
+		require.NoError(t, err)
+		require.Equal(t, rs.ParamSpecs, rs2.ParamSpecs)
+	})
+
+	t.Run("Parse and format", func(t *testing.T) {
+		src := `type bool
+param city:string enum "paris" "lyon"
+param age:int64 min 18 max 99
+param email:string? pattern "^[a-z]+@[a-z]+\\.com$"
+param unused:float64 description "expected but not used yet"
+
+city == "paris" and age:int64 >= 21 -> true
+has(email?) -> true
+true -> false
+`
+		parsed, err := ParseRuleset(src)
+		require.NoError(t, err)
+		require.Equal(t, specs, parsed.ParamSpecs)
+
+		s, err := FormatRuleset(parsed)
+		require.NoError(t, err)
+		require.Equal(t, src, s)
+
+		parsed, err = ParseRuleset(`
+			param radius:int64 enum 1 2 # radiuses in km
+			param param:string
+			param == "x" -> true
+		`)
+		require.NoError(t, err)
+		require.Len(t, parsed.ParamSpecs, 2)
+		require.Equal(t, []string{"1", "2"}, parsed.ParamSpecs[0].Enum)
+		require.Len(t, parsed.Rules, 1)
+
+		_, err = ParseRuleset("param age:int64 min young\n")
+		require.Equal(t, &rule.ParseError{Line: 1, Column: 7, Msg: "invalid param declaration: invalid min value young"}, err)
+	})
+}
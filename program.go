@@ -0,0 +1,100 @@
+package regula
+
+import (
+	"github.com/heetch/regula/rule"
+)
+
+// A Program is the compiled form of a ruleset, created by Ruleset.Compile.
+// It returns the same results as the ruleset but evaluates faster and allocates less.
+// A Program is safe for concurrent use.
+type Program struct {
+	rules []compiledRule
+
+	// rs holds the settings of the ruleset, like its strategy or its default result.
+	rs Ruleset
+	// order holds the indexes of the rules, in the order they must be evaluated.
+	order []int
+	// checks holds the param specs of the ruleset, ready to be checked.
+	checks []paramCheck
+}
+
+type compiledRule struct {
+	expr, result *rule.Program
+}
+
+// Compile validates the ruleset and compiles each of its rules.
+func (r *Ruleset) Compile() (*Program, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	checks, err := r.paramChecks()
+	if err != nil {
+		return nil, err
+	}
+
+	p := Program{
+		rules:  make([]compiledRule, len(r.Rules)),
+		rs:     *r,
+		order:  r.order(),
+		checks: checks,
+	}
+
+	for i, rl := range r.Rules {
+		expr, err := rule.Compile(rl.Expr)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := rule.Compile(rl.Result)
+		if err != nil {
+			return nil, err
+		}
+
+		p.rules[i] = compiledRule{expr: expr, result: result}
+	}
+
+	return &p, nil
+}
+
+// Eval evaluates the rules of the program according to the strategy of the ruleset, by default until one matches.
+// If no rule matches the given context, it returns the default result of the ruleset or rule.ErrNoMatch if there is none.
+func (p *Program) Eval(params rule.Params) (*rule.Value, error) {
+	res, _, err := p.EvalRule(params)
+	return res, err
+}
+
+// EvalRule evaluates the program like Eval and also returns the rule that matched, see Ruleset.EvalRule.
+func (p *Program) EvalRule(params rule.Params) (*rule.Value, *rule.Rule, error) {
+	if err := checkParams(p.checks, params); err != nil {
+		return nil, nil, err
+	}
+
+	res, i, err := p.rs.evalRules(p.order, func(i int) (*rule.Value, error) {
+		return p.rules[i].eval(params)
+	})
+	if i == -1 {
+		return res, nil, err
+	}
+
+	return res, p.rs.Rules[i], err
+}
+
+// eval evaluates the rule like rule.Rule.Eval.
+func (rl *compiledRule) eval(params rule.Params) (*rule.Value, error) {
+	ok, err := rl.expr.EvalBool(params)
+	if err == nil && !ok {
+		err = rule.ErrNoMatch
+	}
+
+	var res *rule.Value
+	if err == nil {
+		res, err = rl.result.Eval(params)
+	}
+
+	if err == rule.ErrOptionalParamNotFound {
+		return nil, rule.ErrNoMatch
+	}
+
+	return res, err
+}
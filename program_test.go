@@ -0,0 +1,137 @@
+package regula
+
+import (
+	"testing"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgramEval(t *testing.T) {
+	rs, err := NewFloat64Ruleset(
+		rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.Float64Value(3)),
+		rule.New(rule.BoolParam("surge"), rule.Mul(rule.Float64Param("base"), rule.Float64Value(1.5))),
+		rule.New(rule.GT(rule.Int64Param("score"), rule.Int64Value(10)), rule.Float64Param("base")),
+	)
+	require.NoError(t, err)
+
+	p, err := rs.Compile()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		params Params
+	}{
+		{"First rule", Params{"city": "paris"}},
+		{"Computed result", Params{"city": "lyon", "surge": true, "base": 2.0}},
+		{"No match", Params{"city": "lyon", "surge": false, "score": int64(10)}},
+		{"Missing param", Params{"city": "lyon", "surge": false}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exp, expErr := rs.Eval(test.params)
+			v, err := p.Eval(test.params)
+			require.Equal(t, expErr, err)
+			require.Equal(t, exp, v)
+		})
+	}
+
+	t.Run("Default result", func(t *testing.T) {
+		rs := *rs
+		rs.Default = rule.Float64Value(1)
+
+		p, err := rs.Compile()
+		require.NoError(t, err)
+
+		v, rl, err := p.EvalRule(Params{"city": "lyon", "surge": false, "score": int64(10)})
+		require.NoError(t, err)
+		require.Equal(t, rule.Float64Value(1), v)
+		// no rule matched
+		require.Nil(t, rl)
+	})
+
+	t.Run("Missing params no match", func(t *testing.T) {
+		rs, err := NewFloat64Ruleset(
+			rule.New(rule.Optional(rule.BoolParam("surge")), rule.Float64Value(2)),
+			rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.Float64Value(3)),
+			rule.New(rule.True(), rule.Float64Value(1)),
+		)
+		require.NoError(t, err)
+		rs.MissingParams = MissingParamsNoMatch
+
+		p, err := rs.Compile()
+		require.NoError(t, err)
+
+		for _, params := range []Params{{}, {"city": "paris"}, {"surge": true}} {
+			exp, expErr := rs.Eval(params)
+			v, err := p.Eval(params)
+			require.Equal(t, expErr, err)
+			require.Equal(t, exp, v)
+		}
+	})
+}
+
+func TestRulesetCompile(t *testing.T) {
+	rs := Ruleset{
+		Type: "string",
+		Rules: []*rule.Rule{
+			rule.New(rule.True(), rule.Int64Value(1)),
+		},
+	}
+
+	_, err := rs.Compile()
+	require.Equal(t, ErrRulesetIncoherentType, err)
+}
+
+func benchmarkRuleset(b *testing.B) (*Ruleset, Params) {
+	rs, err := NewFloat64Ruleset(
+		rule.New(rule.And(
+			rule.Eq(rule.StringParam("city"), rule.StringValue("lyon")),
+			rule.In(rule.StringParam("status"), rule.StringValue("gold"), rule.StringValue("silver")),
+		), rule.Float64Value(2)),
+		rule.New(rule.And(
+			rule.Eq(rule.StringParam("city"), rule.StringValue("paris")),
+			rule.GT(rule.Int64Param("score"), rule.Int64Value(10)),
+			rule.Percentile(rule.StringParam("id"), rule.Int64Value(100)),
+		), rule.Mul(rule.Float64Param("base"), rule.Float64Value(1.5))),
+		rule.New(rule.True(), rule.Float64Value(1)),
+	)
+	require.NoError(b, err)
+
+	return rs, Params{
+		"city":   "paris",
+		"status": "bronze",
+		"score":  int64(42),
+		"id":     "123",
+		"base":   2.0,
+	}
+}
+
+func BenchmarkRulesetEval(b *testing.B) {
+	rs, params := benchmarkRuleset(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := rs.Eval(params)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkProgramEval(b *testing.B) {
+	rs, params := benchmarkRuleset(b)
+	p, err := rs.Compile()
+	require.NoError(b, err)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := p.Eval(params)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
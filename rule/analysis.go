@@ -0,0 +1,256 @@
+package rule
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// SameExpr reports whether a and b are the same expression: the same operators applied to the same operands,
+// the same params and equal values.
+func SameExpr(a, b Expr) bool {
+	switch x := a.(type) {
+	case *Value:
+		y, ok := b.(*Value)
+		return ok && x.Type == y.Type && x.Equal(y)
+	case *Param:
+		y, ok := b.(*Param)
+		return ok && *x == *y
+	}
+
+	x, ok1 := a.(operatorExpr)
+	y, ok2 := b.(operatorExpr)
+	if !ok1 || !ok2 {
+		return false
+	}
+
+	n1, n2 := x.node(), y.node()
+	if n1.kind != n2.kind || len(n1.operands) != len(n2.operands) {
+		return false
+	}
+
+	for i := range n1.operands {
+		if !SameExpr(n1.operands[i], n2.operands[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Tautology reports whether e always evaluates to true, whatever the params.
+// It only detects the trivial cases, like the true value or an or expression with a true operand.
+func Tautology(e Expr) bool {
+	if v, ok := e.(*Value); ok {
+		return v.Type == "bool" && v.Data == "true"
+	}
+
+	switch kind(e) {
+	case "or":
+		for _, op := range operandsOf(e) {
+			if Tautology(op) {
+				return true
+			}
+		}
+	case "and":
+		for _, op := range operandsOf(e) {
+			if !Tautology(op) {
+				return false
+			}
+		}
+		return true
+	case "not":
+		ops := operandsOf(e)
+		if len(ops) != 1 {
+			return false
+		}
+		v, ok := ops[0].(*Value)
+		return ok && v.Type == "bool" && v.Data == "false"
+	}
+
+	return false
+}
+
+// Implies reports whether b is true every time a is true. It detects the cases where
+// the conditions of b are a subset of those of a, for example a and b implies a.
+// A false result doesn't mean a doesn't imply b.
+func Implies(a, b Expr) bool {
+	if Tautology(b) || SameExpr(a, b) {
+		return true
+	}
+
+	switch kind(b) {
+	case "and":
+		for _, op := range operandsOf(b) {
+			if !Implies(a, op) {
+				return false
+			}
+		}
+		return true
+	case "or":
+		for _, op := range operandsOf(b) {
+			if Implies(a, op) {
+				return true
+			}
+		}
+	}
+
+	switch kind(a) {
+	case "and":
+		for _, op := range operandsOf(a) {
+			if Implies(op, b) {
+				return true
+			}
+		}
+	case "or":
+		for _, op := range operandsOf(a) {
+			if !Implies(op, b) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return false
+}
+
+// Contradiction explains why e can never evaluate to true, or returns an empty string if it can
+// or if the contradiction can't be detected. It detects the false value, a param compared to
+// different values and expressions required to be both true and false, in the conditions of an and expression.
+func Contradiction(e Expr) string {
+	conds := conjuncts(e)
+	eqs := make(map[string]*Value)
+
+	for _, c := range conds {
+		if v, ok := c.(*Value); ok && v.Type == "bool" && v.Data == "false" {
+			return "the condition is always false"
+		}
+
+		if kind(c) == "not" && len(operandsOf(c)) == 1 {
+			op := operandsOf(c)[0]
+			for _, other := range conds {
+				if SameExpr(op, other) {
+					return fmt.Sprintf("%s must be both true and false", format(op))
+				}
+			}
+		}
+
+		if kind(c) != "eq" || len(operandsOf(c)) != 2 {
+			continue
+		}
+
+		p, v := paramValue(operandsOf(c))
+		if p == nil {
+			continue
+		}
+
+		if prev, ok := eqs[p.Name]; ok && !prev.Equal(v) {
+			return fmt.Sprintf("%s can't be equal to both %s and %s", p.Name, format(prev), format(v))
+		}
+		eqs[p.Name] = v
+	}
+
+	return ""
+}
+
+// conjuncts returns the conditions of e if it is an and expression, flattening the nested ones, or e itself otherwise.
+func conjuncts(e Expr) []Expr {
+	if kind(e) != "and" {
+		return []Expr{e}
+	}
+
+	var conds []Expr
+	for _, op := range operandsOf(e) {
+		conds = append(conds, conjuncts(op)...)
+	}
+
+	return conds
+}
+
+// paramValue returns the param and the value of a comparison between them, in any order.
+func paramValue(ops []Expr) (*Param, *Value) {
+	for i := range ops {
+		p, ok1 := ops[i].(*Param)
+		v, ok2 := ops[1-i].(*Value)
+		if ok1 && ok2 {
+			return p, v
+		}
+	}
+
+	return nil, nil
+}
+
+// Operator returns the kind of the operator e, like "eq" or "and", and its operands.
+// It returns an empty kind if e is not an operator, like a value or a param.
+func Operator(e Expr) (string, []Expr) {
+	o, ok := e.(operatorExpr)
+	if !ok {
+		return "", nil
+	}
+
+	n := o.node()
+	return n.kind, n.operands
+}
+
+func kind(e Expr) string {
+	if o, ok := e.(operatorExpr); ok {
+		return o.node().kind
+	}
+
+	return ""
+}
+
+func operandsOf(e Expr) []Expr {
+	return e.(operatorExpr).node().operands
+}
+
+// format returns the representation of e in the rule syntax, or its JSON representation
+// if it can't be represented.
+func format(e Expr) string {
+	s, err := Format(e)
+	if err != nil {
+		raw, _ := json.Marshal(e)
+		return string(raw)
+	}
+
+	return s
+}
+
+// ParamConstants returns, for each param of e, the values it is directly compared to or combined with,
+// like 10 in age > 10 or "a" and "b" in city in ("a", "b"). Duplicate values are returned once.
+func ParamConstants(e Expr) map[string][]*Value {
+	consts := make(map[string][]*Value)
+
+	_ = walk(e, func(e Expr) error {
+		o, ok := e.(operatorExpr)
+		if !ok {
+			return nil
+		}
+
+		ops := o.node().operands
+		for _, op := range ops {
+			p, ok := op.(*Param)
+			if !ok {
+				continue
+			}
+
+		values:
+			for _, op := range ops {
+				v, ok := op.(*Value)
+				if !ok {
+					continue
+				}
+
+				for _, prev := range consts[p.Name] {
+					if prev.Type == v.Type && prev.Equal(v) {
+						continue values
+					}
+				}
+				consts[p.Name] = append(consts[p.Name], v)
+			}
+		}
+
+		return nil
+	})
+
+	return consts
+}
@@ -0,0 +1,112 @@
+package rule_test
+
+import (
+	"testing"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParse(t *testing.T, src string) rule.Expr {
+	t.Helper()
+
+	e, err := rule.Parse(src)
+	require.NoError(t, err)
+	return e
+}
+
+func TestSameExpr(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{`city == "paris"`, `city == "paris"`, true},
+		{`age:int64 > 10 and city == "paris"`, `age:int64 > 10 and city == "paris"`, true},
+		{`rate:float64 > 1.0`, `rate:float64 > 1.00`, true},
+		{`city == "paris"`, `city == "lyon"`, false},
+		{`city == "paris"`, `zone == "paris"`, false},
+		{`age:int64 > 10`, `age:int64 >= 10`, false},
+		{`city == "paris" and true`, `city == "paris"`, false},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, rule.SameExpr(mustParse(t, test.a), mustParse(t, test.b)), "%s / %s", test.a, test.b)
+	}
+}
+
+func TestTautology(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected bool
+	}{
+		{`true`, true},
+		{`false`, false},
+		{`city == "paris" or true`, true},
+		{`true and true`, true},
+		{`true and city == "paris"`, false},
+		{`not false`, true},
+		{`city == "paris"`, false},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, rule.Tautology(mustParse(t, test.src)), test.src)
+	}
+}
+
+func TestImplies(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{`city == "paris"`, `city == "paris"`, true},
+		{`city == "paris" and age:int64 > 10`, `city == "paris"`, true},
+		{`city == "paris" and age:int64 > 10 and surge:bool`, `surge:bool and city == "paris"`, true},
+		{`city == "paris"`, `city == "paris" or city == "lyon"`, true},
+		{`city == "paris" or city == "lyon"`, `city == "paris"`, false},
+		{`city == "paris"`, `true`, true},
+		{`city == "paris"`, `city == "paris" and age:int64 > 10`, false},
+		{`(city == "paris" and surge:bool) or (city == "paris" and age:int64 > 10)`, `city == "paris"`, true},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, rule.Implies(mustParse(t, test.a), mustParse(t, test.b)), "%s => %s", test.a, test.b)
+	}
+}
+
+func TestContradiction(t *testing.T) {
+	tests := []struct {
+		src      string
+		expected string
+	}{
+		{`city == "a" and city == "b"`, `city can't be equal to both "a" and "b"`},
+		{`"a" == city and (age:int64 > 1 and city == "b")`, `city can't be equal to both "a" and "b"`},
+		{`surge:bool and not surge:bool`, `surge:bool must be both true and false`},
+		{`city == "a" and false`, `the condition is always false`},
+		{`city == "a" and city == "a"`, ``},
+		{`city == "a" or city == "b"`, ``},
+		{`city == "a" and zone == "b"`, ``},
+	}
+
+	for _, test := range tests {
+		require.Equal(t, test.expected, rule.Contradiction(mustParse(t, test.src)), test.src)
+	}
+}
+
+func TestParamConstants(t *testing.T) {
+	e := mustParse(t, `city in ("paris", "lyon") and age:int64 > 18 and age:int64 < 65 and city != "paris" and surge:bool`)
+
+	require.Equal(t, map[string][]*rule.Value{
+		"city": {rule.StringValue("paris"), rule.StringValue("lyon")},
+		"age":  {rule.Int64Value(18), rule.Int64Value(65)},
+	}, rule.ParamConstants(e))
+}
+
+func TestOperator(t *testing.T) {
+	kind, ops := rule.Operator(mustParse(t, `city == "paris"`))
+	require.Equal(t, "eq", kind)
+	require.Equal(t, []rule.Expr{rule.StringParam("city"), rule.StringValue("paris")}, ops)
+
+	kind, ops = rule.Operator(rule.StringValue("paris"))
+	require.Empty(t, kind)
+	require.Nil(t, ops)
+}
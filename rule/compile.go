@@ -0,0 +1,1208 @@
+package rule
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// A Program is the compiled form of an expression.
+// It returns the same results as the expression it was compiled from but it evaluates
+// a tree of closures operating on native Go values: numbers are not parsed and formatted
+// by each operator, and the evaluation of the common expressions doesn't allocate.
+// Like Float64Value, float64 values are rounded to 6 decimal places.
+// A Program is safe for concurrent use.
+type Program struct {
+	typ string
+	fn  evalFn
+
+	// value is set if the expression always returns the same value.
+	value *Value
+}
+
+// Compile type checks the given expression and compiles it into a program.
+func Compile(e Expr) (*Program, error) {
+	if _, err := TypeCheck(e); err != nil {
+		return nil, err
+	}
+
+	n, err := compile(e)
+	if err != nil {
+		return nil, err
+	}
+
+	p := Program{
+		typ: n.typ,
+		fn:  n.fn,
+	}
+
+	if n.isConst {
+		v, err := n.fn(nil)
+		if err == nil {
+			p.value = fromNative(n.typ, v)
+		}
+	}
+
+	if v, ok := e.(*Value); ok {
+		// values evaluate to themselves
+		p.value = v
+	}
+
+	return &p, nil
+}
+
+// Type returns the type of the value returned by the program.
+func (p *Program) Type() string {
+	return p.typ
+}
+
+// Eval evaluates the program against the given params.
+func (p *Program) Eval(params Params) (*Value, error) {
+	if p.value != nil {
+		return p.value, nil
+	}
+
+	v, err := p.fn(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return fromNative(p.typ, v), nil
+}
+
+// EvalBool evaluates a program returning a boolean, without allocating a Value.
+func (p *Program) EvalBool(params Params) (bool, error) {
+	if p.typ != "bool" {
+		return false, fmt.Errorf("invalid program returning %s value", p.typ)
+	}
+
+	v, err := p.fn(params)
+	return v.b, err
+}
+
+// native holds the value of an expression of a type known at compile time.
+type native struct {
+	b  bool
+	i  int64 // int64 values and durations, in nanoseconds
+	f  float64
+	s  string
+	t  time.Time
+	ss []string
+	is []int64
+	fs []float64
+	v  Version
+	p  Point
+	ps []Point // polygons
+}
+
+type evalFn func(params Params) (native, error)
+
+// node is a compiled expression.
+type node struct {
+	typ string
+	fn  evalFn
+
+	// isConst reports whether the node doesn't depend on the params nor on the current time.
+	isConst bool
+}
+
+func compile(e Expr) (node, error) {
+	switch t := e.(type) {
+	case *Value:
+		v, err := toNative(t)
+		if err != nil {
+			return node{}, err
+		}
+		return node{typ: t.Type, isConst: true, fn: func(Params) (native, error) {
+			return v, nil
+		}}, nil
+	case *Param:
+		return compileParam(t)
+	case operatorExpr:
+		o := t.node()
+
+		ops := make([]node, len(o.operands))
+		isConst := o.kind != "now" && o.kind != "any" && o.kind != "all"
+		for i, op := range o.operands {
+			n, err := compile(op)
+			if err != nil {
+				return node{}, err
+			}
+			ops[i] = n
+			isConst = isConst && n.isConst
+		}
+
+		n, err := compileOperator(t, o.kind, ops)
+		if err != nil {
+			return node{}, err
+		}
+
+		if isConst {
+			return fold(n), nil
+		}
+		return n, nil
+	}
+
+	return node{}, fmt.Errorf("cannot compile expression of type %T", e)
+}
+
+// fold evaluates a node whose operands are all constant once, at compile time.
+// Errors are left for the evaluation to return.
+func fold(n node) node {
+	v, err := n.fn(nil)
+	if err != nil {
+		return n
+	}
+
+	n.isConst = true
+	n.fn = func(Params) (native, error) {
+		return v, nil
+	}
+	return n
+}
+
+func compileParam(p *Param) (node, error) {
+	name := p.Name
+
+	var fn evalFn
+	switch p.Type {
+	case "string":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.s, err = params.GetString(name)
+			return
+		}
+	case "bool":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.b, err = params.GetBool(name)
+			return
+		}
+	case "int64":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.i, err = params.GetInt64(name)
+			return
+		}
+	case "float64":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.f, err = params.GetFloat64(name)
+			v.f = roundFloat(v.f)
+			return
+		}
+	case "time":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.t, err = params.GetTime(name)
+			return
+		}
+	case "duration":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			d, err := params.GetDuration(name)
+			v.i = int64(d)
+			return
+		}
+	case "string-list":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.ss, err = params.GetStringList(name)
+			return
+		}
+	case "int64-list":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.is, err = params.GetInt64List(name)
+			return
+		}
+	case "float64-list":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.fs, err = params.GetFloat64List(name)
+			return
+		}
+	case "version":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			s, err := params.GetString(name)
+			if err != nil {
+				return
+			}
+			v.v, err = ParseVersion(s)
+			if err != nil {
+				err = ErrParamTypeMismatch
+			}
+			return
+		}
+	case "point":
+		fn = func(params Params) (v native, err error) {
+			if params == nil {
+				return v, errNilParams
+			}
+			v.p, err = params.GetPoint(name)
+			if err != nil {
+				return
+			}
+			v.p = Point{Lat: roundFloat(v.p.Lat), Lng: roundFloat(v.p.Lng)}
+			err = v.p.validate()
+			return
+		}
+	default:
+		return node{}, errors.New("unsupported param type")
+	}
+
+	if p.Optional {
+		get := fn
+		fn = func(params Params) (native, error) {
+			v, err := get(params)
+			if err == ErrParamNotFound {
+				err = ErrOptionalParamNotFound
+			}
+			return v, err
+		}
+	}
+
+	return node{typ: p.Type, fn: fn}, nil
+}
+
+func compileOperator(e operatorExpr, kind string, ops []node) (node, error) {
+	switch kind {
+	case "not":
+		return compileNot(ops[0]), nil
+	case "and":
+		return compileLogical(ops, false), nil
+	case "or":
+		return compileLogical(ops, true), nil
+	case "eq":
+		return compileEq(ops), nil
+	case "in":
+		return compileIn(ops), nil
+	case "gt", "gte", "lt", "lte":
+		return compileComparison(kind, ops), nil
+	case "fnv":
+		return compileFNV(e.node().operands[0], ops[0]), nil
+	case "percentile":
+		return compilePercentile(ops), nil
+	case "variant":
+		return compileVariant(e.(*exprVariant), ops[1])
+	case "add", "sub", "mul", "div", "mod", "min", "max":
+		return compileArithmetic(kind, ops), nil
+	case "abs":
+		return compileAbs(ops[0]), nil
+	case "contains":
+		return compileContains(ops), nil
+	case "prefix":
+		return compileStringPredicate(ops, strings.HasPrefix), nil
+	case "suffix":
+		return compileStringPredicate(ops, strings.HasSuffix), nil
+	case "matches":
+		return compileMatches(e.(*exprMatches), ops[0])
+	case "lower":
+		return compileStringFunc(ops[0], strings.ToLower), nil
+	case "upper":
+		return compileStringFunc(ops[0], strings.ToUpper), nil
+	case "length":
+		return compileLength(ops[0]), nil
+	case "concat":
+		return compileConcat(ops), nil
+	case "now":
+		return node{typ: "time", fn: func(Params) (native, error) {
+			return native{t: NowFunc()}, nil
+		}}, nil
+	case "before", "after":
+		return compileTimeComparison(kind, ops), nil
+	case "hour-of-day", "day-of-week":
+		return compileTimeIn(kind, ops)
+	case "intersects":
+		return compileIntersects(ops), nil
+	case "size":
+		return compileSize(ops[0]), nil
+	case "any", "all":
+		return compileQuantifier(kind, e.node().operands, ops)
+	case "distance", "within-radius", "in-polygon":
+		return compileGeo(kind, ops), nil
+	case "has":
+		return compileHas(ops[0]), nil
+	case "coalesce":
+		return compileCoalesce(ops), nil
+	}
+
+	return node{}, fmt.Errorf("cannot compile operator %q", kind)
+}
+
+func compileNot(op node) node {
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		v, err := op.fn(params)
+		v.b = !v.b
+		return v, err
+	}}
+}
+
+// compileLogical compiles the and and or operators, which stop evaluating
+// their operands as soon as one of them returns stopOn.
+func compileLogical(ops []node, stopOn bool) node {
+	fns := nodeFuncs(ops)
+
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		for _, fn := range fns {
+			v, err := fn(params)
+			if err != nil || v.b == stopOn {
+				return v, err
+			}
+		}
+
+		return native{b: !stopOn}, nil
+	}}
+}
+
+func compileEq(ops []node) node {
+	fns := nodeFuncs(ops)
+	eq := equalFunc(ops[0].typ)
+
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		a, err := fns[0](params)
+		if err != nil {
+			return native{}, err
+		}
+
+		for _, fn := range fns[1:] {
+			b, err := fn(params)
+			if err != nil {
+				return native{}, err
+			}
+
+			if !eq(a, b) {
+				return native{}, nil
+			}
+		}
+
+		return native{b: true}, nil
+	}}
+}
+
+func compileIn(ops []node) node {
+	fns := nodeFuncs(ops)
+	eq := equalFunc(ops[0].typ)
+
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		a, err := fns[0](params)
+		if err != nil {
+			return native{}, err
+		}
+
+		for _, fn := range fns[1:] {
+			b, err := fn(params)
+			if err != nil {
+				return native{}, err
+			}
+
+			if eq(a, b) {
+				return native{b: true}, nil
+			}
+		}
+
+		return native{}, nil
+	}}
+}
+
+// compileComparison compiles the gt, gte, lt and lte operators, which compare their first operand to each of the others.
+func compileComparison(kind string, ops []node) node {
+	fns := nodeFuncs(ops)
+	cmp := compareFunc(ops[0].typ)
+
+	var ok func(c int) bool
+	switch kind {
+	case "gt":
+		ok = func(c int) bool { return c > 0 }
+	case "gte":
+		ok = func(c int) bool { return c >= 0 }
+	case "lt":
+		ok = func(c int) bool { return c < 0 }
+	default:
+		ok = func(c int) bool { return c <= 0 }
+	}
+
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		a, err := fns[0](params)
+		if err != nil {
+			return native{}, err
+		}
+
+		for _, fn := range fns[1:] {
+			b, err := fn(params)
+			if err != nil {
+				return native{}, err
+			}
+
+			if !ok(cmp(a, b)) {
+				return native{}, nil
+			}
+		}
+
+		return native{b: true}, nil
+	}}
+}
+
+func compileFNV(e Expr, op node) node {
+	if v, ok := e.(*Value); ok {
+		// hash the data as is, it may not be formatted like the values of the program
+		h := fnv32(v.Data)
+		return node{typ: "int64", isConst: true, fn: func(Params) (native, error) {
+			return native{i: h}, nil
+		}}
+	}
+
+	return node{typ: "int64", fn: func(params Params) (native, error) {
+		v, err := op.fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		return native{i: hashNative(op.typ, v)}, nil
+	}}
+}
+
+func compilePercentile(ops []node) node {
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		v, err := ops[0].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		p, err := ops[1].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		return native{b: hashNative(ops[0].typ, v)%100 <= p.i}, nil
+	}}
+}
+
+func compileVariant(n *exprVariant, key node) (node, error) {
+	if n.err != nil {
+		return node{}, n.err
+	}
+
+	salt, variants, weights := n.salt, n.variants, n.weights
+
+	if v, ok := n.operands[1].(*Value); ok {
+		// hash the data as is, like compileFNV
+		name := variants[pickVariant(weights, salt, v.Data)]
+		return node{typ: "string", isConst: true, fn: func(Params) (native, error) {
+			return native{s: name}, nil
+		}}, nil
+	}
+
+	return node{typ: "string", fn: func(params Params) (native, error) {
+		v, err := key.fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		data := v.s
+		if key.typ != "string" {
+			data = fromNative(key.typ, v).Data
+		}
+
+		return native{s: variants[pickVariant(weights, salt, data)]}, nil
+	}}, nil
+}
+
+func compileArithmetic(kind string, ops []node) node {
+	var intFn func(a, b int64) (int64, error)
+	var floatFn func(a, b float64) (float64, error)
+
+	switch kind {
+	case "add":
+		intFn = addInt64
+		floatFn = func(a, b float64) (float64, error) { return a + b, nil }
+	case "sub":
+		intFn = subInt64
+		floatFn = func(a, b float64) (float64, error) { return a - b, nil }
+	case "mul":
+		intFn = mulInt64
+		floatFn = func(a, b float64) (float64, error) { return a * b, nil }
+	case "div":
+		intFn = divInt64
+		floatFn = func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, ErrDivisionByZero
+			}
+			return a / b, nil
+		}
+	case "mod":
+		intFn = func(a, b int64) (int64, error) {
+			if b == 0 {
+				return 0, ErrDivisionByZero
+			}
+			return a % b, nil
+		}
+		floatFn = func(a, b float64) (float64, error) {
+			if b == 0 {
+				return 0, ErrDivisionByZero
+			}
+			return math.Mod(a, b), nil
+		}
+	case "min":
+		intFn = func(a, b int64) (int64, error) {
+			if b < a {
+				return b, nil
+			}
+			return a, nil
+		}
+		floatFn = func(a, b float64) (float64, error) { return math.Min(a, b), nil }
+	case "max":
+		intFn = func(a, b int64) (int64, error) {
+			if b > a {
+				return b, nil
+			}
+			return a, nil
+		}
+		floatFn = func(a, b float64) (float64, error) { return math.Max(a, b), nil }
+	}
+
+	fns := nodeFuncs(ops)
+
+	if ops[0].typ == "int64" {
+		return node{typ: "int64", fn: func(params Params) (native, error) {
+			a, err := fns[0](params)
+			if err != nil {
+				return native{}, err
+			}
+
+			for _, fn := range fns[1:] {
+				b, err := fn(params)
+				if err != nil {
+					return native{}, err
+				}
+
+				a.i, err = intFn(a.i, b.i)
+				if err != nil {
+					return native{}, err
+				}
+			}
+
+			return a, nil
+		}}
+	}
+
+	return node{typ: "float64", fn: func(params Params) (native, error) {
+		a, err := fns[0](params)
+		if err != nil {
+			return native{}, err
+		}
+
+		for _, fn := range fns[1:] {
+			b, err := fn(params)
+			if err != nil {
+				return native{}, err
+			}
+
+			a.f, err = floatFn(a.f, b.f)
+			if err != nil {
+				return native{}, err
+			}
+			a.f = roundFloat(a.f)
+		}
+
+		return a, nil
+	}}
+}
+
+func compileAbs(op node) node {
+	if op.typ == "int64" {
+		return node{typ: "int64", fn: func(params Params) (native, error) {
+			v, err := op.fn(params)
+			if err != nil {
+				return native{}, err
+			}
+
+			if v.i == math.MinInt64 {
+				return native{}, ErrIntegerOverflow
+			}
+			if v.i < 0 {
+				v.i = -v.i
+			}
+			return v, nil
+		}}
+	}
+
+	return node{typ: "float64", fn: func(params Params) (native, error) {
+		v, err := op.fn(params)
+		v.f = roundFloat(math.Abs(v.f))
+		return v, err
+	}}
+}
+
+func compileContains(ops []node) node {
+	if ops[0].typ == "string" {
+		return compileStringPredicate(ops, strings.Contains)
+	}
+
+	eq := equalFunc(ops[1].typ)
+	typ := ops[0].typ
+
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		l, err := ops[0].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		x, err := ops[1].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		for i, n := 0, listLen(typ, l); i < n; i++ {
+			if eq(listItem(typ, l, i), x) {
+				return native{b: true}, nil
+			}
+		}
+
+		return native{}, nil
+	}}
+}
+
+func compileStringPredicate(ops []node, fn func(s, x string) bool) node {
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		s, err := ops[0].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		x, err := ops[1].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		return native{b: fn(s.s, x.s)}, nil
+	}}
+}
+
+func compileMatches(m *exprMatches, op node) (node, error) {
+	if m.err != nil {
+		return node{}, m.err
+	}
+
+	re := m.re
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		v, err := op.fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		return native{b: re.MatchString(v.s)}, nil
+	}}, nil
+}
+
+func compileStringFunc(op node, fn func(string) string) node {
+	return node{typ: "string", fn: func(params Params) (native, error) {
+		v, err := op.fn(params)
+		v.s = fn(v.s)
+		return v, err
+	}}
+}
+
+func compileLength(op node) node {
+	return node{typ: "int64", fn: func(params Params) (native, error) {
+		v, err := op.fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		return native{i: int64(utf8.RuneCountInString(v.s))}, nil
+	}}
+}
+
+func compileConcat(ops []node) node {
+	fns := nodeFuncs(ops)
+
+	return node{typ: "string", fn: func(params Params) (native, error) {
+		var b strings.Builder
+		for _, fn := range fns {
+			v, err := fn(params)
+			if err != nil {
+				return native{}, err
+			}
+			b.WriteString(v.s)
+		}
+
+		return native{s: b.String()}, nil
+	}}
+}
+
+func compileTimeComparison(kind string, ops []node) node {
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		a, err := ops[0].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		b, err := ops[1].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		if kind == "before" {
+			return native{b: a.t.Before(b.t)}, nil
+		}
+		return native{b: a.t.After(b.t)}, nil
+	}}
+}
+
+func compileTimeIn(kind string, ops []node) (node, error) {
+	location := func(params Params) (*time.Location, error) {
+		tz, err := ops[1].fn(params)
+		if err != nil {
+			return nil, err
+		}
+		return loadLocation(tz.s)
+	}
+
+	if ops[1].isConst {
+		// the time zone is loaded once
+		loc, err := location(nil)
+		if err != nil {
+			return node{}, err
+		}
+		location = func(Params) (*time.Location, error) {
+			return loc, nil
+		}
+	}
+
+	return node{typ: "int64", fn: func(params Params) (native, error) {
+		v, err := ops[0].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		loc, err := location(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		t := v.t.In(loc)
+		if kind == "hour-of-day" {
+			return native{i: int64(t.Hour())}, nil
+		}
+		return native{i: int64(t.Weekday())}, nil
+	}}, nil
+}
+
+func compileIntersects(ops []node) node {
+	typ := ops[0].typ
+	eq := equalFunc(listElemType(typ))
+
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		a, err := ops[0].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		b, err := ops[1].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		na, nb := listLen(typ, a), listLen(typ, b)
+		for i := 0; i < na; i++ {
+			x := listItem(typ, a, i)
+			for j := 0; j < nb; j++ {
+				if eq(x, listItem(typ, b, j)) {
+					return native{b: true}, nil
+				}
+			}
+		}
+
+		return native{}, nil
+	}}
+}
+
+func compileSize(op node) node {
+	return node{typ: "int64", fn: func(params Params) (native, error) {
+		v, err := op.fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		return native{i: int64(listLen(op.typ, v))}, nil
+	}}
+}
+
+// compileQuantifier compiles the any and all operators. The predicate accesses
+// the current element through the params, like in the evaluation of the expression.
+func compileQuantifier(kind string, operands []Expr, ops []node) (node, error) {
+	name, err := quantifierName(kind, operands)
+	if err != nil {
+		return node{}, err
+	}
+
+	typ := ops[0].typ
+	stopOn := kind == "any"
+
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		l, err := ops[0].fn(params)
+		if err != nil {
+			return native{}, err
+		}
+
+		n := listLen(typ, l)
+		if n == 0 {
+			return native{b: !stopOn}, nil
+		}
+
+		ip := itemParams{Params: params, name: name}
+		for i := 0; i < n; i++ {
+			ip.item = fromNative(listElemType(typ), listItem(typ, l, i))
+
+			v, err := ops[2].fn(&ip)
+			if err != nil || v.b == stopOn {
+				return v, err
+			}
+		}
+
+		return native{b: !stopOn}, nil
+	}}, nil
+}
+
+func compileGeo(kind string, ops []node) node {
+	fns := nodeFuncs(ops)
+
+	typ := "bool"
+	if kind == "distance" {
+		typ = "float64"
+	}
+
+	return node{typ: typ, fn: func(params Params) (native, error) {
+		var v [3]native
+		for i, fn := range fns {
+			var err error
+			v[i], err = fn(params)
+			if err != nil {
+				return native{}, err
+			}
+		}
+
+		switch kind {
+		case "distance":
+			return native{f: roundFloat(haversine(v[0].p, v[1].p))}, nil
+		case "within-radius":
+			return native{b: haversine(v[0].p, v[1].p) <= v[2].f}, nil
+		}
+
+		return native{b: inPolygon(v[0].p, v[1].ps)}, nil
+	}}
+}
+
+func compileHas(op node) node {
+	return node{typ: "bool", fn: func(params Params) (native, error) {
+		_, err := op.fn(params)
+		if isMissingParam(err) {
+			return native{}, nil
+		}
+		if err != nil {
+			return native{}, err
+		}
+
+		return native{b: true}, nil
+	}}
+}
+
+func compileCoalesce(ops []node) node {
+	fns := nodeFuncs(ops)
+	last := len(fns) - 1
+
+	return node{typ: ops[0].typ, fn: func(params Params) (native, error) {
+		for _, fn := range fns[:last] {
+			v, err := fn(params)
+			if !isMissingParam(err) {
+				return v, err
+			}
+		}
+
+		return fns[last](params)
+	}}
+}
+
+func nodeFuncs(ops []node) []evalFn {
+	fns := make([]evalFn, len(ops))
+	for i := range ops {
+		fns[i] = ops[i].fn
+	}
+
+	return fns
+}
+
+// equalFunc returns a function reporting whether two values of the given type are equal.
+func equalFunc(typ string) func(a, b native) bool {
+	switch typ {
+	case "string":
+		return func(a, b native) bool { return a.s == b.s }
+	case "bool":
+		return func(a, b native) bool { return a.b == b.b }
+	case "int64", "duration":
+		return func(a, b native) bool { return a.i == b.i }
+	case "float64":
+		return func(a, b native) bool { return a.f == b.f }
+	case "time":
+		return func(a, b native) bool { return a.t.Equal(b.t) }
+	case "version":
+		return func(a, b native) bool { return a.v.Compare(b.v) == 0 }
+	case "object":
+		return func(a, b native) bool { return objectEqual(a.s, b.s) }
+	case "point":
+		return func(a, b native) bool { return a.p == b.p }
+	case "polygon":
+		return func(a, b native) bool {
+			if len(a.ps) != len(b.ps) {
+				return false
+			}
+			for i := range a.ps {
+				if a.ps[i] != b.ps[i] {
+					return false
+				}
+			}
+			return true
+		}
+	}
+
+	eq := equalFunc(listElemType(typ))
+	return func(a, b native) bool {
+		n := listLen(typ, a)
+		if n != listLen(typ, b) {
+			return false
+		}
+
+		for i := 0; i < n; i++ {
+			if !eq(listItem(typ, a, i), listItem(typ, b, i)) {
+				return false
+			}
+		}
+
+		return true
+	}
+}
+
+// compareFunc returns a function comparing two values of the given type.
+// It returns a negative number if a < b, zero if a == b and a positive number if a > b.
+func compareFunc(typ string) func(a, b native) int {
+	switch typ {
+	case "string":
+		return func(a, b native) int { return strings.Compare(a.s, b.s) }
+	case "bool":
+		return func(a, b native) int {
+			switch {
+			case a.b == b.b:
+				return 0
+			case a.b:
+				return 1
+			}
+			return -1
+		}
+	case "int64", "duration":
+		return func(a, b native) int {
+			switch {
+			case a.i < b.i:
+				return -1
+			case a.i > b.i:
+				return 1
+			}
+			return 0
+		}
+	case "float64":
+		return func(a, b native) int {
+			switch {
+			case a.f < b.f:
+				return -1
+			case a.f > b.f:
+				return 1
+			}
+			return 0
+		}
+	case "version":
+		return func(a, b native) int { return a.v.Compare(b.v) }
+	}
+
+	return func(a, b native) int {
+		switch {
+		case a.t.Before(b.t):
+			return -1
+		case a.t.After(b.t):
+			return 1
+		}
+		return 0
+	}
+}
+
+func listLen(typ string, l native) int {
+	switch typ {
+	case "string-list":
+		return len(l.ss)
+	case "int64-list":
+		return len(l.is)
+	}
+
+	return len(l.fs)
+}
+
+func listItem(typ string, l native, i int) native {
+	switch typ {
+	case "string-list":
+		return native{s: l.ss[i]}
+	case "int64-list":
+		return native{i: l.is[i]}
+	}
+
+	return native{f: roundFloat(l.fs[i])}
+}
+
+// roundFloat rounds f to 6 decimal places, which is the precision of float64 values.
+func roundFloat(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+
+	if math.Abs(f) < 1e9 {
+		// f*1e6 is below 2^53, so it is rounded exactly
+		return math.Round(f*1e6) / 1e6
+	}
+
+	f, _ = strconv.ParseFloat(strconv.FormatFloat(f, 'f', 6, 64), 64)
+	return f
+}
+
+// hashNative returns the FNV-1 32-bit hash of the data of the value, like the FNV expression.
+func hashNative(typ string, v native) int64 {
+	var buf [64]byte
+	var data []byte
+
+	switch typ {
+	case "string":
+		return fnv32(v.s)
+	case "bool":
+		data = strconv.AppendBool(buf[:0], v.b)
+	case "int64":
+		data = strconv.AppendInt(buf[:0], v.i, 10)
+	case "float64":
+		data = strconv.AppendFloat(buf[:0], v.f, 'f', 6, 64)
+	default:
+		return fnv32(fromNative(typ, v).Data)
+	}
+
+	return fnv32(string(data))
+}
+
+func fnv32(s string) int64 {
+	h := uint32(2166136261)
+	for i := 0; i < len(s); i++ {
+		h *= 16777619
+		h ^= uint32(s[i])
+	}
+
+	return int64(h)
+}
+
+// toNative decodes the data of the given value.
+func toNative(v *Value) (native, error) {
+	var n native
+	var err error
+
+	switch v.Type {
+	case "string":
+		n.s = v.Data
+	case "bool":
+		n.b, err = strconv.ParseBool(v.Data)
+	case "int64":
+		n.i, err = strconv.ParseInt(v.Data, 10, 64)
+	case "float64":
+		n.f, err = strconv.ParseFloat(v.Data, 64)
+		n.f = roundFloat(n.f)
+	case "time":
+		n.t, err = time.Parse(time.RFC3339Nano, v.Data)
+	case "duration":
+		var d time.Duration
+		d, err = time.ParseDuration(v.Data)
+		n.i = int64(d)
+	case "version":
+		n.v, err = ParseVersion(v.Data)
+	case "point":
+		n.p, err = decodePoint(v.Data)
+	case "polygon":
+		n.ps, err = decodePolygon(v.Data)
+	case "object":
+		n.s = v.Data
+	default:
+		var items []*Value
+		items, err = listItems("Compile", v)
+		for _, item := range items {
+			in, _ := toNative(item)
+			switch v.Type {
+			case "string-list":
+				n.ss = append(n.ss, in.s)
+			case "int64-list":
+				n.is = append(n.is, in.i)
+			case "float64-list":
+				n.fs = append(n.fs, in.f)
+			}
+		}
+	}
+
+	return n, err
+}
+
+// fromNative creates a Value of the given type.
+func fromNative(typ string, n native) *Value {
+	switch typ {
+	case "string":
+		return StringValue(n.s)
+	case "bool":
+		return BoolValue(n.b)
+	case "int64":
+		return Int64Value(n.i)
+	case "float64":
+		return Float64Value(n.f)
+	case "time":
+		return TimeValue(n.t)
+	case "duration":
+		return DurationValue(time.Duration(n.i))
+	case "string-list":
+		return StringListValue(n.ss...)
+	case "int64-list":
+		return Int64ListValue(n.is...)
+	case "version":
+		return VersionValue(n.v.String())
+	case "point":
+		return PointValue(n.p)
+	case "polygon":
+		return PolygonValue(n.ps...)
+	case "object":
+		return newValue("object", n.s)
+	}
+
+	return Float64ListValue(n.fs...)
+}
@@ -0,0 +1,225 @@
+package rule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heetch/regula"
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompile(t *testing.T) {
+	now := time.Date(2026, 10, 17, 21, 30, 0, 0, time.UTC)
+	params := regula.Params{
+		"city":     "Paris",
+		"email":    "Jane@Heetch.com",
+		"vip":      true,
+		"score":    int64(42),
+		"max":      int64(9223372036854775807),
+		"zero":     int64(0),
+		"ratio":    0.1,
+		"base":     2.5,
+		"tz":       "Europe/Paris",
+		"now":      now,
+		"deadline": time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		"wait":     5 * time.Minute,
+		"tags":     []string{"vip-gold", "new"},
+		"ids":      []int64{1, 2, 3},
+		"ratios":   []float64{0.1, 0.2},
+		"pickup":   rule.Point{Lat: 48.85661234, Lng: 2.3522},
+		"pole":     rule.Point{Lat: 90.0000001},
+		"app":      "5.12.3+42",
+	}
+
+	exprs := []rule.Expr{
+		rule.StringValue("foo"),
+		rule.Float64Value(0.1),
+		rule.StringParam("city"),
+		rule.Float64Param("ratio"),
+		rule.TimeParam("now"),
+		rule.StringListParam("tags"),
+		rule.Not(rule.BoolParam("vip")),
+		rule.And(rule.BoolParam("vip"), rule.Eq(rule.StringParam("city"), rule.StringValue("Paris"))),
+		rule.And(rule.Not(rule.BoolParam("vip")), rule.BoolParam("missing")),
+		rule.Or(rule.BoolParam("vip"), rule.BoolParam("missing")),
+		rule.Or(rule.Not(rule.BoolParam("vip")), rule.BoolParam("missing")),
+		rule.Eq(rule.Int64Param("score"), rule.Int64Value(42), rule.Int64Value(43)),
+		rule.Eq(rule.Add(rule.Float64Param("ratio"), rule.Float64Value(0.2)), rule.Float64Value(0.3)),
+		rule.Eq(rule.StringListParam("tags"), rule.StringListValue("vip-gold", "new")),
+		rule.In(rule.StringParam("city"), rule.StringValue("Lyon"), rule.StringValue("Paris")),
+		rule.In(rule.DurationParam("wait"), rule.DurationValue(time.Minute)),
+		rule.GT(rule.Int64Param("score"), rule.Int64Value(10), rule.Int64Value(50)),
+		rule.GTE(rule.Float64Param("base"), rule.Float64Value(2.5)),
+		rule.LT(rule.StringParam("city"), rule.StringValue("Rome")),
+		rule.LTE(rule.BoolParam("vip"), rule.BoolValue(false)),
+		rule.GT(rule.TimeParam("deadline"), rule.TimeParam("now")),
+		rule.LT(rule.DurationParam("wait"), rule.DurationValue(time.Hour)),
+		rule.FNV(rule.StringParam("city")),
+		rule.FNV(rule.Int64Param("score")),
+		rule.FNV(rule.Float64Param("base")),
+		rule.FNV(rule.BoolParam("vip")),
+		rule.FNV(rule.TimeParam("now")),
+		rule.FNV(rule.Float64Value(1.5)),
+		rule.Percentile(rule.StringParam("email"), rule.Int64Value(50)),
+		rule.Variant("exp", rule.StringParam("email"), rule.VariantWeight{Name: "a", Weight: 1}, rule.VariantWeight{Name: "b", Weight: 1}),
+		rule.Variant("exp", rule.Float64Param("base"), rule.VariantWeight{Name: "a", Weight: 1}, rule.VariantWeight{Name: "b", Weight: 2}),
+		rule.Variant("exp", rule.Float64Value(1.5), rule.VariantWeight{Name: "a", Weight: 1}, rule.VariantWeight{Name: "b", Weight: 2}),
+		rule.Add(rule.Int64Param("score"), rule.Int64Value(1), rule.Int64Value(-3)),
+		rule.Add(rule.Int64Param("max"), rule.Int64Value(1)),
+		rule.Sub(rule.Float64Param("base"), rule.Float64Param("ratio")),
+		rule.Mul(rule.Float64Param("ratio"), rule.Float64Value(3)),
+		rule.Div(rule.Int64Param("score"), rule.Int64Value(5)),
+		rule.Div(rule.Int64Param("score"), rule.Int64Param("zero")),
+		rule.Div(rule.Float64Param("base"), rule.Float64Value(3)),
+		rule.Mod(rule.Int64Param("score"), rule.Int64Value(5)),
+		rule.Mod(rule.Float64Param("base"), rule.Float64Value(0)),
+		rule.Min(rule.Int64Param("score"), rule.Int64Value(5)),
+		rule.Max(rule.Float64Param("base"), rule.Float64Value(5)),
+		rule.Abs(rule.Sub(rule.Int64Value(1), rule.Int64Param("score"))),
+		rule.Abs(rule.Float64Value(-0.5)),
+		rule.Contains(rule.StringParam("email"), rule.StringValue("@")),
+		rule.Contains(rule.Int64ListParam("ids"), rule.Int64Value(2)),
+		rule.Contains(rule.Float64ListParam("ratios"), rule.Float64Value(0.3)),
+		rule.Prefix(rule.StringParam("city"), rule.StringValue("Pa")),
+		rule.Suffix(rule.StringParam("city"), rule.StringValue("Pa")),
+		rule.Matches(rule.Lower(rule.StringParam("email")), `@heetch\.com$`),
+		rule.Upper(rule.StringParam("city")),
+		rule.Length(rule.StringValue("héhé")),
+		rule.Concat(rule.StringParam("city"), rule.StringValue("-"), rule.StringParam("email")),
+		rule.Before(rule.TimeParam("now"), rule.TimeParam("deadline")),
+		rule.After(rule.TimeParam("now"), rule.TimeValue(now)),
+		rule.HourOfDay(rule.TimeParam("now"), rule.StringValue("Europe/Paris")),
+		rule.DayOfWeek(rule.TimeParam("now"), rule.StringParam("tz")),
+		rule.HourOfDay(rule.TimeParam("now"), rule.StringParam("city")),
+		rule.Intersects(rule.StringListParam("tags"), rule.StringListValue("old", "new")),
+		rule.Size(rule.Int64ListParam("ids")),
+		rule.Any(rule.StringListParam("tags"), "tag", rule.Prefix(rule.StringParam("tag"), rule.StringValue("vip"))),
+		rule.All(rule.Int64ListParam("ids"), "id", rule.LT(rule.Int64Param("id"), rule.Int64Param("score"))),
+		rule.All(rule.Int64ListValue(), "id", rule.BoolParam("missing")),
+		rule.Any(rule.Float64ListParam("ratios"), "r", rule.Any(rule.Int64ListParam("ids"), "id", rule.GT(rule.Float64Param("r"), rule.Float64Param("base")))),
+		rule.VersionParam("app"),
+		rule.VersionParam("city"),
+		rule.GTE(rule.VersionParam("app"), rule.VersionValue("5.10")),
+		rule.In(rule.VersionParam("app"), rule.VersionValue("5.12.3")),
+		rule.FNV(rule.VersionParam("app")),
+		rule.ObjectValue(map[string]interface{}{"radius": 3.5, "drivers": 10}),
+		rule.Eq(rule.ObjectValue(map[string]interface{}{"a": 1}), &rule.Value{Kind: "value", Type: "object", Data: `{"a": 1.0}`}),
+		rule.In(rule.ObjectValue(nil), rule.ObjectValue(map[string]interface{}{"a": "b"})),
+		rule.Optional(rule.StringParam("city")),
+		rule.Optional(rule.StringParam("missing")),
+		rule.Has(rule.StringParam("missing")),
+		rule.Has(rule.Optional(rule.StringParam("city"))),
+		rule.Has(rule.StringParam("score")),
+		rule.Coalesce(rule.Optional(rule.StringParam("missing")), rule.StringParam("missing"), rule.StringParam("city")),
+		rule.Coalesce(rule.Optional(rule.Int64Param("missing")), rule.Int64Param("city"), rule.Int64Value(1)),
+		rule.Coalesce(rule.Optional(rule.Int64Param("missing")), rule.Int64Param("other")),
+		rule.PointParam("pickup"),
+		rule.Distance(rule.PointParam("pickup"), rule.PointValue(rule.Point{Lat: 45.764, Lng: 4.8357})),
+		rule.Distance(rule.PointParam("pole"), rule.PointParam("pickup")),
+		rule.WithinRadius(rule.PointParam("pickup"), rule.PointValue(rule.Point{Lat: 48.86, Lng: 2.35}), rule.Float64Value(0.5)),
+		rule.InPolygon(rule.PointParam("pickup"), rule.PolygonValue(rule.Point{Lat: 48, Lng: 2}, rule.Point{Lat: 49, Lng: 2}, rule.Point{Lat: 49, Lng: 3})),
+		rule.Eq(rule.PolygonValue(rule.Point{}, rule.Point{Lat: 1}, rule.Point{Lng: 1}), rule.PolygonValue(rule.Point{}, rule.Point{Lat: 1}, rule.Point{Lng: 1})),
+		rule.FNV(rule.PointParam("pickup")),
+		rule.Eq(rule.StringParam("missing"), rule.StringValue("a")),
+		rule.Eq(rule.Int64Param("city"), rule.Int64Value(1)),
+	}
+
+	for _, e := range exprs {
+		p, err := rule.Compile(e)
+		require.NoError(t, err)
+
+		exp, expErr := e.Eval(params)
+		v, err := p.Eval(params)
+		require.Equal(t, expErr, err, "%#v", e)
+		require.Equal(t, exp, v, "%#v", e)
+	}
+}
+
+func TestProgram(t *testing.T) {
+	t.Run("Type", func(t *testing.T) {
+		p, err := rule.Compile(rule.Size(rule.StringListParam("tags")))
+		require.NoError(t, err)
+		require.Equal(t, "int64", p.Type())
+	})
+
+	t.Run("Type error", func(t *testing.T) {
+		_, err := rule.Compile(rule.Not(rule.StringParam("a")))
+		require.Equal(t, &rule.TypeError{Path: "/operands/0", Msg: "not expects bool, got string"}, err)
+	})
+
+	t.Run("Constant", func(t *testing.T) {
+		p, err := rule.Compile(rule.Add(rule.Int64Value(1), rule.Int64Value(2)))
+		require.NoError(t, err)
+
+		v, err := p.Eval(nil)
+		require.NoError(t, err)
+		require.Equal(t, rule.Int64Value(3), v)
+
+		p, err = rule.Compile(rule.Div(rule.Int64Value(1), rule.Int64Value(0)))
+		require.NoError(t, err)
+		_, err = p.Eval(nil)
+		require.Equal(t, rule.ErrDivisionByZero, err)
+	})
+
+	t.Run("Now", func(t *testing.T) {
+		defer func(fn func() time.Time) { rule.NowFunc = fn }(rule.NowFunc)
+
+		p, err := rule.Compile(rule.HourOfDay(rule.Now(), rule.StringValue("UTC")))
+		require.NoError(t, err)
+
+		for _, h := range []int64{10, 11} {
+			rule.NowFunc = func() time.Time { return time.Date(2026, 10, 17, int(h), 0, 0, 0, time.UTC) }
+			v, err := p.Eval(nil)
+			require.NoError(t, err)
+			require.Equal(t, rule.Int64Value(h), v)
+		}
+	})
+
+	t.Run("Nil params", func(t *testing.T) {
+		p, err := rule.Compile(rule.BoolParam("a"))
+		require.NoError(t, err)
+
+		_, err = p.Eval(nil)
+		require.EqualError(t, err, "params is nil")
+	})
+
+	t.Run("EvalBool", func(t *testing.T) {
+		p, err := rule.Compile(rule.GT(rule.Int64Param("score"), rule.Int64Value(10)))
+		require.NoError(t, err)
+
+		ok, err := p.EvalBool(regula.Params{"score": int64(11)})
+		require.NoError(t, err)
+		require.True(t, ok)
+
+		p, err = rule.Compile(rule.Int64Param("score"))
+		require.NoError(t, err)
+		_, err = p.EvalBool(regula.Params{"score": int64(11)})
+		require.Error(t, err)
+	})
+
+	t.Run("No allocations", func(t *testing.T) {
+		p, err := rule.Compile(rule.And(
+			rule.Eq(rule.StringParam("city"), rule.StringValue("paris")),
+			rule.In(rule.StringParam("status"), rule.StringValue("gold"), rule.StringValue("silver")),
+			rule.GT(rule.Mul(rule.Float64Param("base"), rule.Float64Value(1.5)), rule.Float64Value(3)),
+			rule.Percentile(rule.StringParam("id"), rule.Int64Value(50)),
+			rule.LT(rule.HourOfDay(rule.TimeParam("now"), rule.StringValue("Europe/Paris")), rule.Int64Value(22)),
+		))
+		require.NoError(t, err)
+
+		params := regula.Params{
+			"city":   "paris",
+			"status": "gold",
+			"base":   2.5,
+			"id":     "123",
+			"now":    time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC),
+		}
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_, err = p.EvalBool(params)
+		})
+		require.NoError(t, err)
+		require.Zero(t, allocs)
+	})
+}
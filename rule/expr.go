@@ -1,10 +1,17 @@
 package rule
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"go/token"
+	"math"
+	"regexp"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 
 	"hash/fnv"
 )
@@ -21,6 +28,12 @@ type Params interface {
 	GetBool(key string) (bool, error)
 	GetInt64(key string) (int64, error)
 	GetFloat64(key string) (float64, error)
+	GetTime(key string) (time.Time, error)
+	GetDuration(key string) (time.Duration, error)
+	GetStringList(key string) ([]string, error)
+	GetInt64List(key string) ([]int64, error)
+	GetFloat64List(key string) ([]float64, error)
+	GetPoint(key string) (Point, error)
 	Keys() []string
 	EncodeValue(key string) (string, error)
 }
@@ -489,11 +502,1466 @@ func (n *exprPercentile) Eval(params Params) (*Value, error) {
 	return BoolValue(false), nil
 }
 
+type exprVariant struct {
+	operator
+
+	// salt is decoded from the first operand by compile, as the operands are wrapped when the expression is traced.
+	salt     string
+	variants []string
+	weights  []int64 // cumulative weights
+	err      error
+}
+
+// A VariantWeight associates the name of a variant with its weight.
+type VariantWeight struct {
+	Name   string
+	Weight int64
+}
+
+// Variant creates an expression that assigns the key to one of the given variants and returns its name.
+// It is intended to be used to run experiments with more than two groups, like A/B/C tests.
+// The assignment is deterministic and the probability of a variant to be selected is proportional to its weight.
+// The key is hashed along with the salt, so experiments using different salts assign the same keys independently.
+// If the variants are invalid, the error is reported by Validate and on evaluation.
+func Variant(salt string, key Expr, variants ...VariantWeight) Expr {
+	ops := []Expr{StringValue(salt), key}
+	for _, v := range variants {
+		ops = append(ops, StringValue(v.Name), Int64Value(v.Weight))
+	}
+
+	n := exprVariant{
+		operator: operator{
+			kind:     "variant",
+			operands: ops,
+		},
+	}
+
+	n.compile()
+	return &n
+}
+
+// compile decodes the variants and their weights, stored as pairs of values after the salt and the key.
+func (n *exprVariant) compile() {
+	n.salt, n.variants, n.weights, n.err = "", nil, nil, nil
+
+	if len(n.operands) < 4 || len(n.operands)%2 != 0 {
+		n.err = errors.New("invalid number of operands in Variant func")
+		return
+	}
+
+	salt, ok := n.operands[0].(*Value)
+	if !ok || salt.Type != "string" {
+		n.err = errors.New("the salt of the Variant func must be a string value")
+		return
+	}
+	n.salt = salt.Data
+
+	var total int64
+	for i := 2; i < len(n.operands); i += 2 {
+		name, ok := n.operands[i].(*Value)
+		if !ok || name.Type != "string" {
+			n.err = errors.New("the variants of the Variant func must be string values")
+			return
+		}
+
+		for _, v := range n.variants {
+			if v == name.Data {
+				n.err = fmt.Errorf("duplicate variant %q in Variant func", v)
+				return
+			}
+		}
+
+		weight, ok := n.operands[i+1].(*Value)
+		if !ok || weight.Type != "int64" {
+			n.err = errors.New("the weights of the Variant func must be int64 values")
+			return
+		}
+
+		w, err := strconv.ParseInt(weight.Data, 10, 64)
+		if err != nil || w < 0 || total+w < total {
+			n.err = fmt.Errorf("invalid weight %s for variant %q", weight.Data, name.Data)
+			return
+		}
+
+		total += w
+		n.variants = append(n.variants, name.Data)
+		n.weights = append(n.weights, total)
+	}
+
+	if total == 0 {
+		n.err = errors.New("the total weight of the variants must be positive")
+	}
+}
+
+func (n *exprVariant) UnmarshalJSON(data []byte) error {
+	err := n.operator.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+
+	n.compile()
+	return n.err
+}
+
+func (n *exprVariant) validate() error {
+	return n.err
+}
+
+func (n *exprVariant) Eval(params Params) (*Value, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+
+	v, err := n.operands[1].Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return StringValue(n.variants[pickVariant(n.weights, n.salt, v.Data)]), nil
+}
+
+// pickVariant returns the index of the variant the key is assigned to, given the cumulative weights of the variants.
+// The key and the salt are hashed using FNV-1a 64-bit, whose result is mixed to spread the entropy to the lower bits.
+func pickVariant(weights []int64, salt, key string) int {
+	const prime = 1099511628211
+
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(salt); i++ {
+		h ^= uint64(salt[i])
+		h *= prime
+	}
+	// separate the salt from the key, so that ("ab", "c") and ("a", "bc") hash differently
+	h *= prime
+	for i := 0; i < len(key); i++ {
+		h ^= uint64(key[i])
+		h *= prime
+	}
+
+	h ^= h >> 30
+	h *= 0xbf58476d1ce4e5b9
+	h ^= h >> 27
+	h *= 0x94d049bb133111eb
+	h ^= h >> 31
+
+	b := int64(h % uint64(weights[len(weights)-1]))
+	i := 0
+	for weights[i] <= b {
+		i++
+	}
+
+	return i
+}
+
+type exprAdd struct {
+	operator
+}
+
+// Add creates an expression that takes at least two operands and returns their sum.
+// All the operands must evaluate to numbers of the same type, either int64 or float64.
+func Add(v1, v2 Expr, vN ...Expr) Expr {
+	return &exprAdd{
+		operator: operator{
+			kind:     "add",
+			operands: append([]Expr{v1, v2}, vN...),
+		},
+	}
+}
+
+func (n *exprAdd) Eval(params Params) (*Value, error) {
+	if len(n.operands) < 2 {
+		return nil, errors.New("invalid number of operands in Add func")
+	}
+
+	return foldNumbers("Add", n.operands, params, addInt64, func(a, b float64) (float64, error) {
+		return a + b, nil
+	})
+}
+
+type exprSub struct {
+	operator
+}
+
+// Sub creates an expression that takes at least two operands and subtracts
+// each successive operand from the first one.
+// All the operands must evaluate to numbers of the same type, either int64 or float64.
+func Sub(v1, v2 Expr, vN ...Expr) Expr {
+	return &exprSub{
+		operator: operator{
+			kind:     "sub",
+			operands: append([]Expr{v1, v2}, vN...),
+		},
+	}
+}
+
+func (n *exprSub) Eval(params Params) (*Value, error) {
+	if len(n.operands) < 2 {
+		return nil, errors.New("invalid number of operands in Sub func")
+	}
+
+	return foldNumbers("Sub", n.operands, params, subInt64, func(a, b float64) (float64, error) {
+		return a - b, nil
+	})
+}
+
+type exprMul struct {
+	operator
+}
+
+// Mul creates an expression that takes at least two operands and returns their product.
+// All the operands must evaluate to numbers of the same type, either int64 or float64.
+func Mul(v1, v2 Expr, vN ...Expr) Expr {
+	return &exprMul{
+		operator: operator{
+			kind:     "mul",
+			operands: append([]Expr{v1, v2}, vN...),
+		},
+	}
+}
+
+func (n *exprMul) Eval(params Params) (*Value, error) {
+	if len(n.operands) < 2 {
+		return nil, errors.New("invalid number of operands in Mul func")
+	}
+
+	return foldNumbers("Mul", n.operands, params, mulInt64, func(a, b float64) (float64, error) {
+		return a * b, nil
+	})
+}
+
+type exprDiv struct {
+	operator
+}
+
+// Div creates an expression that takes at least two operands and divides
+// the first one by each successive operand. Integer divisions truncate toward zero.
+// All the operands must evaluate to numbers of the same type, either int64 or float64.
+// It returns ErrDivisionByZero if one of the divisors is zero.
+func Div(v1, v2 Expr, vN ...Expr) Expr {
+	return &exprDiv{
+		operator: operator{
+			kind:     "div",
+			operands: append([]Expr{v1, v2}, vN...),
+		},
+	}
+}
+
+func (n *exprDiv) Eval(params Params) (*Value, error) {
+	if len(n.operands) < 2 {
+		return nil, errors.New("invalid number of operands in Div func")
+	}
+
+	return foldNumbers("Div", n.operands, params, divInt64, func(a, b float64) (float64, error) {
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return a / b, nil
+	})
+}
+
+type exprMod struct {
+	operator
+}
+
+// Mod creates an expression that returns the remainder of the division of v1 by v2.
+// Both operands must evaluate to numbers of the same type, either int64 or float64.
+// It returns ErrDivisionByZero if v2 is zero.
+func Mod(v1, v2 Expr) Expr {
+	return &exprMod{
+		operator: operator{
+			kind:     "mod",
+			operands: []Expr{v1, v2},
+		},
+	}
+}
+
+func (n *exprMod) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in Mod func")
+	}
+
+	return foldNumbers("Mod", n.operands, params, func(a, b int64) (int64, error) {
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return a % b, nil
+	}, func(a, b float64) (float64, error) {
+		if b == 0 {
+			return 0, ErrDivisionByZero
+		}
+		return math.Mod(a, b), nil
+	})
+}
+
+type exprMin struct {
+	operator
+}
+
+// Min creates an expression that takes at least two operands and returns the smallest one.
+// All the operands must evaluate to numbers of the same type, either int64 or float64.
+func Min(v1, v2 Expr, vN ...Expr) Expr {
+	return &exprMin{
+		operator: operator{
+			kind:     "min",
+			operands: append([]Expr{v1, v2}, vN...),
+		},
+	}
+}
+
+func (n *exprMin) Eval(params Params) (*Value, error) {
+	if len(n.operands) < 2 {
+		return nil, errors.New("invalid number of operands in Min func")
+	}
+
+	return foldNumbers("Min", n.operands, params, func(a, b int64) (int64, error) {
+		if b < a {
+			return b, nil
+		}
+		return a, nil
+	}, func(a, b float64) (float64, error) {
+		return math.Min(a, b), nil
+	})
+}
+
+type exprMax struct {
+	operator
+}
+
+// Max creates an expression that takes at least two operands and returns the greatest one.
+// All the operands must evaluate to numbers of the same type, either int64 or float64.
+func Max(v1, v2 Expr, vN ...Expr) Expr {
+	return &exprMax{
+		operator: operator{
+			kind:     "max",
+			operands: append([]Expr{v1, v2}, vN...),
+		},
+	}
+}
+
+func (n *exprMax) Eval(params Params) (*Value, error) {
+	if len(n.operands) < 2 {
+		return nil, errors.New("invalid number of operands in Max func")
+	}
+
+	return foldNumbers("Max", n.operands, params, func(a, b int64) (int64, error) {
+		if b > a {
+			return b, nil
+		}
+		return a, nil
+	}, func(a, b float64) (float64, error) {
+		return math.Max(a, b), nil
+	})
+}
+
+type exprAbs struct {
+	operator
+}
+
+// Abs creates an expression that returns the absolute value of the given operand.
+// The operand must evaluate to an int64 or a float64.
+func Abs(v Expr) Expr {
+	return &exprAbs{
+		operator: operator{
+			kind:     "abs",
+			operands: []Expr{v},
+		},
+	}
+}
+
+func (n *exprAbs) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 1 {
+		return nil, errors.New("invalid number of operands in Abs func")
+	}
+
+	v, err := n.operands[0].Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	switch v.Type {
+	case "int64":
+		i, err := strconv.ParseInt(v.Data, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if i == math.MinInt64 {
+			return nil, ErrIntegerOverflow
+		}
+		if i < 0 {
+			i = -i
+		}
+		return Int64Value(i), nil
+	case "float64":
+		f, err := strconv.ParseFloat(v.Data, 64)
+		if err != nil {
+			return nil, err
+		}
+		return Float64Value(math.Abs(f)), nil
+	}
+
+	return nil, errors.New("invalid operand type for Abs func")
+}
+
+// foldNumbers evaluates the operands in order and combines them using intFn or floatFn,
+// depending on their type. All the operands must be of the same type.
+func foldNumbers(name string, ops []Expr, params Params, intFn func(a, b int64) (int64, error), floatFn func(a, b float64) (float64, error)) (*Value, error) {
+	vA, err := ops[0].Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if vA.Type != "int64" && vA.Type != "float64" {
+		return nil, fmt.Errorf("invalid operand type for %s func", name)
+	}
+
+	for i := 1; i < len(ops); i++ {
+		vB, err := ops[i].Eval(params)
+		if err != nil {
+			return nil, err
+		}
+
+		if vB.Type != vA.Type {
+			return nil, fmt.Errorf("invalid operand type for %s func: cannot mix %s and %s", name, vA.Type, vB.Type)
+		}
+
+		if vA.Type == "int64" {
+			i1, i2, err := parseInt64Values(vA, vB)
+			if err != nil {
+				return nil, err
+			}
+
+			res, err := intFn(i1, i2)
+			if err != nil {
+				return nil, err
+			}
+			vA = Int64Value(res)
+			continue
+		}
+
+		f1, f2, err := parseFloat64Values(vA, vB)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := floatFn(f1, f2)
+		if err != nil {
+			return nil, err
+		}
+		vA = Float64Value(res)
+	}
+
+	return vA, nil
+}
+
+func addInt64(a, b int64) (int64, error) {
+	if (b > 0 && a > math.MaxInt64-b) || (b < 0 && a < math.MinInt64-b) {
+		return 0, ErrIntegerOverflow
+	}
+
+	return a + b, nil
+}
+
+func subInt64(a, b int64) (int64, error) {
+	if (b < 0 && a > math.MaxInt64+b) || (b > 0 && a < math.MinInt64+b) {
+		return 0, ErrIntegerOverflow
+	}
+
+	return a - b, nil
+}
+
+func mulInt64(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+
+	c := a * b
+	if c/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, ErrIntegerOverflow
+	}
+
+	return c, nil
+}
+
+func divInt64(a, b int64) (int64, error) {
+	if b == 0 {
+		return 0, ErrDivisionByZero
+	}
+
+	if a == math.MinInt64 && b == -1 {
+		return 0, ErrIntegerOverflow
+	}
+
+	return a / b, nil
+}
+
+type exprContains struct {
+	operator
+}
+
+// Contains creates an expression that evaluates to true if s contains x.
+// If s evaluates to a string, x must evaluate to a string and the expression reports whether x is a substring of s.
+// If s evaluates to a list, x must evaluate to a value of the type of its elements and the expression reports whether x is one of them.
+func Contains(s, x Expr) Expr {
+	return &exprContains{
+		operator: operator{
+			kind:     "contains",
+			operands: []Expr{s, x},
+		},
+	}
+}
+
+func (n *exprContains) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in Contains func")
+	}
+
+	v, err := n.operands[0].Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type == "string" {
+		substr, err := evalString("Contains", n.operands[1], params)
+		if err != nil {
+			return nil, err
+		}
+
+		return BoolValue(strings.Contains(v.Data, substr)), nil
+	}
+
+	items, err := listItems("Contains", v)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := n.operands[1].Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if x.Type != listElemType(v.Type) {
+		return nil, errors.New("invalid operand type for Contains func")
+	}
+
+	for _, item := range items {
+		if item.Equal(x) {
+			return BoolValue(true), nil
+		}
+	}
+
+	return BoolValue(false), nil
+}
+
+type exprPrefix struct {
+	operator
+}
+
+// Prefix creates an expression that evaluates to true if s begins with prefix.
+// Both operands must evaluate to strings.
+func Prefix(s, prefix Expr) Expr {
+	return &exprPrefix{
+		operator: operator{
+			kind:     "prefix",
+			operands: []Expr{s, prefix},
+		},
+	}
+}
+
+func (n *exprPrefix) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in Prefix func")
+	}
+
+	s, prefix, err := evalStrings("Prefix", n.operands, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return BoolValue(strings.HasPrefix(s, prefix)), nil
+}
+
+type exprSuffix struct {
+	operator
+}
+
+// Suffix creates an expression that evaluates to true if s ends with suffix.
+// Both operands must evaluate to strings.
+func Suffix(s, suffix Expr) Expr {
+	return &exprSuffix{
+		operator: operator{
+			kind:     "suffix",
+			operands: []Expr{s, suffix},
+		},
+	}
+}
+
+func (n *exprSuffix) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in Suffix func")
+	}
+
+	s, suffix, err := evalStrings("Suffix", n.operands, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return BoolValue(strings.HasSuffix(s, suffix)), nil
+}
+
+type exprMatches struct {
+	operator
+
+	re  *regexp.Regexp
+	err error
+}
+
+// Matches creates an expression that evaluates to true if s matches the given regular expression.
+// The pattern uses the syntax of the regexp package and is compiled once, when the expression is created.
+// If the pattern is invalid, the error is reported by Validate and on evaluation.
+func Matches(s Expr, pattern string) Expr {
+	m := exprMatches{
+		operator: operator{
+			kind:     "matches",
+			operands: []Expr{s, StringValue(pattern)},
+		},
+	}
+
+	m.compile()
+	return &m
+}
+
+// compile compiles the pattern stored in the second operand.
+func (n *exprMatches) compile() {
+	if len(n.operands) != 2 {
+		n.err = errors.New("invalid number of operands in Matches func")
+		return
+	}
+
+	v, ok := n.operands[1].(*Value)
+	if !ok || v.Type != "string" {
+		n.err = errors.New("the pattern of the Matches func must be a string value")
+		return
+	}
+
+	n.re, n.err = regexp.Compile(v.Data)
+}
+
+func (n *exprMatches) UnmarshalJSON(data []byte) error {
+	err := n.operator.UnmarshalJSON(data)
+	if err != nil {
+		return err
+	}
+
+	n.compile()
+	return n.err
+}
+
+func (n *exprMatches) validate() error {
+	return n.err
+}
+
+func (n *exprMatches) Eval(params Params) (*Value, error) {
+	if n.err != nil {
+		return nil, n.err
+	}
+
+	v, err := n.operands[0].Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type != "string" {
+		return nil, errors.New("invalid operand type for Matches func")
+	}
+
+	return BoolValue(n.re.MatchString(v.Data)), nil
+}
+
+type exprLower struct {
+	operator
+}
+
+// Lower creates an expression that returns s with all its letters mapped to their lower case.
+// The operand must evaluate to a string.
+func Lower(s Expr) Expr {
+	return &exprLower{
+		operator: operator{
+			kind:     "lower",
+			operands: []Expr{s},
+		},
+	}
+}
+
+func (n *exprLower) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 1 {
+		return nil, errors.New("invalid number of operands in Lower func")
+	}
+
+	s, err := evalString("Lower", n.operands[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	return StringValue(strings.ToLower(s)), nil
+}
+
+type exprUpper struct {
+	operator
+}
+
+// Upper creates an expression that returns s with all its letters mapped to their upper case.
+// The operand must evaluate to a string.
+func Upper(s Expr) Expr {
+	return &exprUpper{
+		operator: operator{
+			kind:     "upper",
+			operands: []Expr{s},
+		},
+	}
+}
+
+func (n *exprUpper) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 1 {
+		return nil, errors.New("invalid number of operands in Upper func")
+	}
+
+	s, err := evalString("Upper", n.operands[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	return StringValue(strings.ToUpper(s)), nil
+}
+
+type exprLength struct {
+	operator
+}
+
+// Length creates an expression that returns the number of characters of s.
+// The operand must evaluate to a string.
+func Length(s Expr) Expr {
+	return &exprLength{
+		operator: operator{
+			kind:     "length",
+			operands: []Expr{s},
+		},
+	}
+}
+
+func (n *exprLength) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 1 {
+		return nil, errors.New("invalid number of operands in Length func")
+	}
+
+	s, err := evalString("Length", n.operands[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	return Int64Value(int64(utf8.RuneCountInString(s))), nil
+}
+
+type exprConcat struct {
+	operator
+}
+
+// Concat creates an expression that takes at least two operands and returns their concatenation.
+// All the operands must evaluate to strings.
+func Concat(v1, v2 Expr, vN ...Expr) Expr {
+	return &exprConcat{
+		operator: operator{
+			kind:     "concat",
+			operands: append([]Expr{v1, v2}, vN...),
+		},
+	}
+}
+
+func (n *exprConcat) Eval(params Params) (*Value, error) {
+	if len(n.operands) < 2 {
+		return nil, errors.New("invalid number of operands in Concat func")
+	}
+
+	var b strings.Builder
+	for _, op := range n.operands {
+		s, err := evalString("Concat", op, params)
+		if err != nil {
+			return nil, err
+		}
+
+		b.WriteString(s)
+	}
+
+	return StringValue(b.String()), nil
+}
+
+// evalString evaluates e and ensures it returns a string.
+func evalString(name string, e Expr, params Params) (string, error) {
+	v, err := e.Eval(params)
+	if err != nil {
+		return "", err
+	}
+
+	if v.Type != "string" {
+		return "", fmt.Errorf("invalid operand type for %s func", name)
+	}
+
+	return v.Data, nil
+}
+
+// evalStrings evaluates the two given operands and ensures they both return a string.
+func evalStrings(name string, ops []Expr, params Params) (s1, s2 string, err error) {
+	if s1, err = evalString(name, ops[0], params); err != nil {
+		return
+	}
+	s2, err = evalString(name, ops[1], params)
+	return
+}
+
+type exprIntersects struct {
+	operator
+}
+
+// Intersects creates an expression that evaluates to true if the lists a and b have at least one element in common.
+// Both operands must evaluate to lists of the same type.
+func Intersects(a, b Expr) Expr {
+	return &exprIntersects{
+		operator: operator{
+			kind:     "intersects",
+			operands: []Expr{a, b},
+		},
+	}
+}
+
+func (n *exprIntersects) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in Intersects func")
+	}
+
+	a, err := evalList("Intersects", n.operands[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := evalList("Intersects", n.operands[1], params)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Type != b.Type {
+		return nil, errors.New("invalid operand type for Intersects func")
+	}
+
+	itemsA, itemsB, err := listItemsPair("Intersects", a, b)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, x := range itemsA {
+		for _, y := range itemsB {
+			if x.Equal(y) {
+				return BoolValue(true), nil
+			}
+		}
+	}
+
+	return BoolValue(false), nil
+}
+
+type exprSize struct {
+	operator
+}
+
+// Size creates an expression that returns the number of elements of the given list.
+func Size(list Expr) Expr {
+	return &exprSize{
+		operator: operator{
+			kind:     "size",
+			operands: []Expr{list},
+		},
+	}
+}
+
+func (n *exprSize) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 1 {
+		return nil, errors.New("invalid number of operands in Size func")
+	}
+
+	v, err := evalList("Size", n.operands[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := listItems("Size", v)
+	if err != nil {
+		return nil, err
+	}
+
+	return Int64Value(int64(len(items))), nil
+}
+
+type exprAny struct {
+	operator
+}
+
+// Any creates an expression that evaluates to true if the predicate is true for at least one element of the list.
+// The predicate is evaluated once per element, with the element available as a parameter called name,
+// so that Any(StringListParam("tags"), "tag", Prefix(StringParam("tag"), StringValue("vip"))) reports whether
+// one of the tags starts with "vip". The predicate must evaluate to a boolean.
+func Any(list Expr, name string, predicate Expr) Expr {
+	return &exprAny{
+		operator: operator{
+			kind:     "any",
+			operands: []Expr{list, StringValue(name), predicate},
+		},
+	}
+}
+
+func (n *exprAny) validate() error {
+	_, err := quantifierName("Any", n.operands)
+	return err
+}
+
+func (n *exprAny) Eval(params Params) (*Value, error) {
+	return evalQuantifier("Any", n.operands, params, true)
+}
+
+type exprAll struct {
+	operator
+}
+
+// All creates an expression that evaluates to true if the predicate is true for every element of the list.
+// The predicate is evaluated once per element, with the element available as a parameter called name.
+// It evaluates to true if the list is empty. The predicate must evaluate to a boolean.
+func All(list Expr, name string, predicate Expr) Expr {
+	return &exprAll{
+		operator: operator{
+			kind:     "all",
+			operands: []Expr{list, StringValue(name), predicate},
+		},
+	}
+}
+
+func (n *exprAll) validate() error {
+	_, err := quantifierName("All", n.operands)
+	return err
+}
+
+func (n *exprAll) Eval(params Params) (*Value, error) {
+	return evalQuantifier("All", n.operands, params, false)
+}
+
+// quantifierName returns the name of the parameter holding the current element of an Any or All expression.
+func quantifierName(fn string, ops []Expr) (string, error) {
+	if len(ops) != 3 {
+		return "", fmt.Errorf("invalid number of operands in %s func", fn)
+	}
+
+	v, ok := ops[1].(*Value)
+	if !ok || v.Type != "string" || v.Data == "" {
+		return "", fmt.Errorf("the element name of the %s func must be a non empty string value", fn)
+	}
+
+	return v.Data, nil
+}
+
+// evalQuantifier evaluates the predicate for each element of the list and stops as soon as it returns stopOn.
+func evalQuantifier(fn string, ops []Expr, params Params, stopOn bool) (*Value, error) {
+	name, err := quantifierName(fn, ops)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := evalList(fn, ops[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := listItems(fn, list)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		v, err := ops[2].Eval(&itemParams{Params: params, name: name, item: item})
+		if err != nil {
+			return nil, err
+		}
+
+		if v.Type != "bool" {
+			return nil, fmt.Errorf("invalid operand type for %s func", fn)
+		}
+
+		if v.Equal(BoolValue(stopOn)) {
+			return v, nil
+		}
+	}
+
+	return BoolValue(!stopOn), nil
+}
+
+// itemParams exposes the element of a list currently evaluated by a quantifier as a parameter,
+// on top of the parameters passed during evaluation.
+type itemParams struct {
+	Params
+
+	name string
+	item *Value
+}
+
+// lookup returns the current element if key is its name.
+// ok is false if the lookup must be delegated to the parent parameters.
+func (p *itemParams) lookup(key, typ string) (v *Value, ok bool, err error) {
+	if key != p.name {
+		if p.Params == nil {
+			return nil, true, ErrParamNotFound
+		}
+		return nil, false, nil
+	}
+
+	if p.item.Type != typ {
+		return nil, true, ErrParamTypeMismatch
+	}
+
+	return p.item, true, nil
+}
+
+func (p *itemParams) GetString(key string) (string, error) {
+	v, ok, err := p.lookup(key, "string")
+	if !ok {
+		return p.Params.GetString(key)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return v.Data, nil
+}
+
+func (p *itemParams) GetBool(key string) (bool, error) {
+	v, ok, err := p.lookup(key, "bool")
+	if !ok {
+		return p.Params.GetBool(key)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return strconv.ParseBool(v.Data)
+}
+
+func (p *itemParams) GetInt64(key string) (int64, error) {
+	v, ok, err := p.lookup(key, "int64")
+	if !ok {
+		return p.Params.GetInt64(key)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(v.Data, 10, 64)
+}
+
+func (p *itemParams) GetFloat64(key string) (float64, error) {
+	v, ok, err := p.lookup(key, "float64")
+	if !ok {
+		return p.Params.GetFloat64(key)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseFloat(v.Data, 64)
+}
+
+func (p *itemParams) GetTime(key string) (time.Time, error) {
+	v, ok, err := p.lookup(key, "time")
+	if !ok {
+		return p.Params.GetTime(key)
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339Nano, v.Data)
+}
+
+func (p *itemParams) GetDuration(key string) (time.Duration, error) {
+	v, ok, err := p.lookup(key, "duration")
+	if !ok {
+		return p.Params.GetDuration(key)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return time.ParseDuration(v.Data)
+}
+
+func (p *itemParams) GetStringList(key string) ([]string, error) {
+	_, ok, err := p.lookup(key, "string-list")
+	if !ok {
+		return p.Params.GetStringList(key)
+	}
+
+	return nil, err
+}
+
+func (p *itemParams) GetInt64List(key string) ([]int64, error) {
+	_, ok, err := p.lookup(key, "int64-list")
+	if !ok {
+		return p.Params.GetInt64List(key)
+	}
+
+	return nil, err
+}
+
+func (p *itemParams) GetFloat64List(key string) ([]float64, error) {
+	_, ok, err := p.lookup(key, "float64-list")
+	if !ok {
+		return p.Params.GetFloat64List(key)
+	}
+
+	return nil, err
+}
+
+func (p *itemParams) GetPoint(key string) (Point, error) {
+	v, ok, err := p.lookup(key, "point")
+	if !ok {
+		return p.Params.GetPoint(key)
+	}
+	if err != nil {
+		return Point{}, err
+	}
+
+	return decodePoint(v.Data)
+}
+
+func (p *itemParams) Keys() []string {
+	keys := []string{p.name}
+	if p.Params != nil {
+		for _, k := range p.Params.Keys() {
+			if k != p.name {
+				keys = append(keys, k)
+			}
+		}
+	}
+
+	return keys
+}
+
+func (p *itemParams) EncodeValue(key string) (string, error) {
+	if key == p.name {
+		return p.item.Data, nil
+	}
+	if p.Params == nil {
+		return "", ErrParamNotFound
+	}
+
+	return p.Params.EncodeValue(key)
+}
+
+// evalList evaluates e and ensures it returns a list.
+func evalList(name string, e Expr, params Params) (*Value, error) {
+	v, err := e.Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if listElemType(v.Type) == "" {
+		return nil, fmt.Errorf("invalid operand type for %s func", name)
+	}
+
+	return v, nil
+}
+
+// listElemType returns the type of the elements of the given list type,
+// or an empty string if typ is not a list type.
+func listElemType(typ string) string {
+	switch typ {
+	case "string-list":
+		return "string"
+	case "int64-list":
+		return "int64"
+	case "float64-list":
+		return "float64"
+	}
+
+	return ""
+}
+
+// listItems decodes the elements of the given list value.
+func listItems(name string, v *Value) ([]*Value, error) {
+	var items []*Value
+
+	switch v.Type {
+	case "string-list":
+		var l []string
+		if err := json.Unmarshal([]byte(v.Data), &l); err != nil {
+			return nil, err
+		}
+		for _, s := range l {
+			items = append(items, StringValue(s))
+		}
+	case "int64-list":
+		var l []int64
+		if err := json.Unmarshal([]byte(v.Data), &l); err != nil {
+			return nil, err
+		}
+		for _, i := range l {
+			items = append(items, Int64Value(i))
+		}
+	case "float64-list":
+		var l []float64
+		if err := json.Unmarshal([]byte(v.Data), &l); err != nil {
+			return nil, err
+		}
+		for _, f := range l {
+			items = append(items, Float64Value(f))
+		}
+	default:
+		return nil, fmt.Errorf("invalid operand type for %s func", name)
+	}
+
+	return items, nil
+}
+
+func listItemsPair(name string, v1, v2 *Value) (l1, l2 []*Value, err error) {
+	if l1, err = listItems(name, v1); err != nil {
+		return
+	}
+	l2, err = listItems(name, v2)
+	return
+}
+
+// NowFunc returns the current time when evaluating the Now expression.
+// It can be replaced to make the evaluation of time based rules deterministic, in tests for example.
+var NowFunc = time.Now
+
+type exprNow struct {
+	operator
+}
+
+// Now creates an expression that evaluates to the current time, as returned by NowFunc.
+func Now() Expr {
+	return &exprNow{
+		operator: operator{
+			kind: "now",
+		},
+	}
+}
+
+func (n *exprNow) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 0 {
+		return nil, errors.New("invalid number of operands in Now func")
+	}
+
+	return TimeValue(NowFunc()), nil
+}
+
+type exprBefore struct {
+	operator
+}
+
+// Before creates an expression that evaluates to true if the time t1 is before t2.
+// Both operands must evaluate to times.
+func Before(t1, t2 Expr) Expr {
+	return &exprBefore{
+		operator: operator{
+			kind:     "before",
+			operands: []Expr{t1, t2},
+		},
+	}
+}
+
+func (n *exprBefore) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in Before func")
+	}
+
+	t1, t2, err := evalTimes("Before", n.operands, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return BoolValue(t1.Before(t2)), nil
+}
+
+type exprAfter struct {
+	operator
+}
+
+// After creates an expression that evaluates to true if the time t1 is after t2.
+// Both operands must evaluate to times.
+func After(t1, t2 Expr) Expr {
+	return &exprAfter{
+		operator: operator{
+			kind:     "after",
+			operands: []Expr{t1, t2},
+		},
+	}
+}
+
+func (n *exprAfter) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in After func")
+	}
+
+	t1, t2, err := evalTimes("After", n.operands, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return BoolValue(t1.After(t2)), nil
+}
+
+type exprHourOfDay struct {
+	operator
+}
+
+// HourOfDay creates an expression that returns the hour of the time t, between 0 and 23,
+// in the time zone tz. t must evaluate to a time and tz to a string containing
+// a location name of the IANA Time Zone database, like "Europe/Paris" or "UTC".
+func HourOfDay(t, tz Expr) Expr {
+	return &exprHourOfDay{
+		operator: operator{
+			kind:     "hour-of-day",
+			operands: []Expr{t, tz},
+		},
+	}
+}
+
+func (n *exprHourOfDay) validate() error {
+	return validateLocation(n.operands)
+}
+
+func (n *exprHourOfDay) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in HourOfDay func")
+	}
+
+	t, err := evalTimeIn("HourOfDay", n.operands, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return Int64Value(int64(t.Hour())), nil
+}
+
+type exprDayOfWeek struct {
+	operator
+}
+
+// DayOfWeek creates an expression that returns the day of the week of the time t
+// in the time zone tz, from 0 for Sunday to 6 for Saturday. t must evaluate to a time and tz to a string containing
+// a location name of the IANA Time Zone database, like "Europe/Paris" or "UTC".
+func DayOfWeek(t, tz Expr) Expr {
+	return &exprDayOfWeek{
+		operator: operator{
+			kind:     "day-of-week",
+			operands: []Expr{t, tz},
+		},
+	}
+}
+
+func (n *exprDayOfWeek) validate() error {
+	return validateLocation(n.operands)
+}
+
+func (n *exprDayOfWeek) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in DayOfWeek func")
+	}
+
+	t, err := evalTimeIn("DayOfWeek", n.operands, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return Int64Value(int64(t.Weekday())), nil
+}
+
+// evalTime evaluates e and ensures it returns a time.
+func evalTime(name string, e Expr, params Params) (time.Time, error) {
+	v, err := e.Eval(params)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if v.Type != "time" {
+		return time.Time{}, fmt.Errorf("invalid operand type for %s func", name)
+	}
+
+	return time.Parse(time.RFC3339Nano, v.Data)
+}
+
+// evalTimes evaluates the two given operands and ensures they both return a time.
+func evalTimes(name string, ops []Expr, params Params) (t1, t2 time.Time, err error) {
+	if t1, err = evalTime(name, ops[0], params); err != nil {
+		return
+	}
+	t2, err = evalTime(name, ops[1], params)
+	return
+}
+
+// evalTimeIn evaluates the time and the time zone operands and returns the time in that time zone.
+func evalTimeIn(name string, ops []Expr, params Params) (time.Time, error) {
+	t, err := evalTime(name, ops[0], params)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	tz, err := evalString(name, ops[1], params)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	loc, err := loadLocation(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return t.In(loc), nil
+}
+
+// validateLocation ensures the time zone operand, if it is a value, is a known location.
+func validateLocation(ops []Expr) error {
+	if len(ops) != 2 {
+		return nil
+	}
+
+	v, ok := ops[1].(*Value)
+	if !ok || v.Type != "string" {
+		return nil
+	}
+
+	_, err := loadLocation(v.Data)
+	return err
+}
+
+// locations caches the time zones already loaded, which requires reading the system database.
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+
+	locations.Store(name, loc)
+	return loc, nil
+}
+
 // Param is an expression used to select a parameter passed during evaluation and return its corresponding value.
 type Param struct {
 	Kind string `json:"kind"`
 	Type string `json:"type"`
 	Name string `json:"name"`
+
+	// Optional reports whether the param can be omitted during evaluation, see Optional.
+	Optional bool `json:"optional,omitempty"`
 }
 
 // StringParam creates a Param that looks up in the set of params passed during evaluation and returns the value
@@ -540,10 +2008,77 @@ func Float64Param(name string) *Param {
 	}
 }
 
+// TimeParam creates a Param that looks up in the set of params passed during evaluation and returns the value
+// of the variable that corresponds to the given name.
+// The corresponding value must be a time. If not found it returns an error.
+func TimeParam(name string) *Param {
+	return &Param{
+		Kind: "param",
+		Type: "time",
+		Name: name,
+	}
+}
+
+// DurationParam creates a Param that looks up in the set of params passed during evaluation and returns the value
+// of the variable that corresponds to the given name.
+// The corresponding value must be a duration. If not found it returns an error.
+func DurationParam(name string) *Param {
+	return &Param{
+		Kind: "param",
+		Type: "duration",
+		Name: name,
+	}
+}
+
+// StringListParam creates a Param that looks up in the set of params passed during evaluation and returns the value
+// of the variable that corresponds to the given name.
+// The corresponding value must be a list of strings. If not found it returns an error.
+func StringListParam(name string) *Param {
+	return &Param{
+		Kind: "param",
+		Type: "string-list",
+		Name: name,
+	}
+}
+
+// Int64ListParam creates a Param that looks up in the set of params passed during evaluation and returns the value
+// of the variable that corresponds to the given name.
+// The corresponding value must be a list of int64. If not found it returns an error.
+func Int64ListParam(name string) *Param {
+	return &Param{
+		Kind: "param",
+		Type: "int64-list",
+		Name: name,
+	}
+}
+
+// Float64ListParam creates a Param that looks up in the set of params passed during evaluation and returns the value
+// of the variable that corresponds to the given name.
+// The corresponding value must be a list of float64. If not found it returns an error.
+func Float64ListParam(name string) *Param {
+	return &Param{
+		Kind: "param",
+		Type: "float64-list",
+		Name: name,
+	}
+}
+
+var errNilParams = errors.New("params is nil")
+
 // Eval extracts a value from the given parameters.
+// If the param is optional and not found, it returns ErrOptionalParamNotFound.
 func (p *Param) Eval(params Params) (*Value, error) {
+	v, err := p.eval(params)
+	if err == ErrParamNotFound && p.Optional {
+		return nil, ErrOptionalParamNotFound
+	}
+
+	return v, err
+}
+
+func (p *Param) eval(params Params) (*Value, error) {
 	if params == nil {
-		return nil, errors.New("params is nil")
+		return nil, errNilParams
 	}
 
 	switch p.Type {
@@ -571,6 +2106,56 @@ func (p *Param) Eval(params Params) (*Value, error) {
 			return nil, err
 		}
 		return Float64Value(v), nil
+	case "time":
+		v, err := params.GetTime(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		return TimeValue(v), nil
+	case "duration":
+		v, err := params.GetDuration(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		return DurationValue(v), nil
+	case "string-list":
+		v, err := params.GetStringList(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		return StringListValue(v...), nil
+	case "int64-list":
+		v, err := params.GetInt64List(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		return Int64ListValue(v...), nil
+	case "float64-list":
+		v, err := params.GetFloat64List(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		return Float64ListValue(v...), nil
+	case "point":
+		v, err := params.GetPoint(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		pv := PointValue(v)
+		if _, err := decodePoint(pv.Data); err != nil {
+			return nil, err
+		}
+		return pv, nil
+	case "version":
+		s, err := params.GetString(p.Name)
+		if err != nil {
+			return nil, err
+		}
+		v, err := ParseVersion(s)
+		if err != nil {
+			return nil, ErrParamTypeMismatch
+		}
+		return VersionValue(v.String()), nil
 	}
 
 	return nil, errors.New("unsupported param type")
@@ -616,6 +2201,50 @@ func Float64Value(value float64) *Value {
 	return newValue("float64", strconv.FormatFloat(value, 'f', 6, 64))
 }
 
+// TimeValue creates a time type value.
+// The time is stored in UTC, using the RFC 3339 format with nanoseconds.
+func TimeValue(value time.Time) *Value {
+	return newValue("time", value.UTC().Format(time.RFC3339Nano))
+}
+
+// DurationValue creates a duration type value.
+func DurationValue(value time.Duration) *Value {
+	return newValue("duration", value.String())
+}
+
+// StringListValue creates a list of strings value.
+// The list is stored as a JSON array.
+func StringListValue(values ...string) *Value {
+	if values == nil {
+		values = []string{}
+	}
+
+	data, _ := json.Marshal(values)
+	return newValue("string-list", string(data))
+}
+
+// Int64ListValue creates a list of int64 value.
+// The list is stored as a JSON array.
+func Int64ListValue(values ...int64) *Value {
+	l := make([]string, len(values))
+	for i, v := range values {
+		l[i] = strconv.FormatInt(v, 10)
+	}
+
+	return newValue("int64-list", "["+strings.Join(l, ",")+"]")
+}
+
+// Float64ListValue creates a list of float64 value.
+// The list is stored as a JSON array.
+func Float64ListValue(values ...float64) *Value {
+	l := make([]string, len(values))
+	for i, v := range values {
+		l[i] = strconv.FormatFloat(v, 'f', 6, 64)
+	}
+
+	return newValue("float64-list", "["+strings.Join(l, ",")+"]")
+}
+
 // Eval evaluates the value to itself.
 func (v *Value) Eval(Params) (*Value, error) {
 	return v, nil
@@ -630,7 +2259,75 @@ func (v *Value) compare(op token.Token, other *Value) bool {
 }
 
 // Equal reports whether v and other represent the same value.
+// Numbers are compared numerically, whatever their formatting,
+// times are equal if they represent the same instant, whatever their time zone,
+// versions are equal if they have the same precedence, and lists are equal if they have the same elements in the same order.
 func (v *Value) Equal(other *Value) bool {
+	if v.Type == other.Type {
+		switch v.Type {
+		case "int64":
+			i1, i2, err := parseInt64Values(v, other)
+			if err == nil {
+				return i1 == i2
+			}
+		case "float64":
+			f1, f2, err := parseFloat64Values(v, other)
+			if err == nil {
+				return f1 == f2
+			}
+		case "time":
+			t1, t2, err := parseTimeValues(v, other)
+			if err == nil {
+				return t1.Equal(t2)
+			}
+		case "duration":
+			d1, d2, err := parseDurationValues(v, other)
+			if err == nil {
+				return d1 == d2
+			}
+		case "version":
+			v1, v2, err := parseVersionValues(v, other)
+			if err == nil {
+				return v1.Compare(v2) == 0
+			}
+		case "point":
+			p1, err1 := decodePoint(v.Data)
+			p2, err2 := decodePoint(other.Data)
+			if err1 == nil && err2 == nil {
+				return p1 == p2
+			}
+		case "polygon":
+			p1, err1 := decodePolygon(v.Data)
+			p2, err2 := decodePolygon(other.Data)
+			if err1 == nil && err2 == nil {
+				if len(p1) != len(p2) {
+					return false
+				}
+				for i := range p1 {
+					if p1[i] != p2[i] {
+						return false
+					}
+				}
+				return true
+			}
+		case "object":
+			return objectEqual(v.Data, other.Data)
+		case "string-list", "int64-list", "float64-list":
+			l1, l2, err := listItemsPair("Equal", v, other)
+			if err == nil {
+				if len(l1) != len(l2) {
+					return false
+				}
+				for i := range l1 {
+					if !l1[i].Equal(l2[i]) {
+						return false
+					}
+				}
+				return true
+			}
+		}
+	}
+
 	return v.compare(token.EQL, other)
 }
 
@@ -677,6 +2374,27 @@ func (v *Value) GT(other *Value) (bool, error) {
 			return false, nil
 		}
 		return true, nil
+	case "time":
+		t1, t2, err := parseTimeValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return t1.After(t2), nil
+	case "duration":
+		d1, d2, err := parseDurationValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return d1 > d2, nil
+	case "version":
+		s1, s2, err := parseVersionValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return s1.Compare(s2) > 0, nil
 	}
 	return false, fmt.Errorf("unknown Value type: %s", v.Type)
 }
@@ -719,6 +2437,27 @@ func (v *Value) GTE(other *Value) (bool, error) {
 			return false, nil
 		}
 		return true, nil
+	case "time":
+		t1, t2, err := parseTimeValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return !t1.Before(t2), nil
+	case "duration":
+		d1, d2, err := parseDurationValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return d1 >= d2, nil
+	case "version":
+		s1, s2, err := parseVersionValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return s1.Compare(s2) >= 0, nil
 	}
 	return false, fmt.Errorf("unknown Value type: %s", v.Type)
 }
@@ -766,6 +2505,27 @@ func (v *Value) LT(other *Value) (bool, error) {
 			return false, nil
 		}
 		return true, nil
+	case "time":
+		t1, t2, err := parseTimeValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return t1.Before(t2), nil
+	case "duration":
+		d1, d2, err := parseDurationValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return d1 < d2, nil
+	case "version":
+		s1, s2, err := parseVersionValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return s1.Compare(s2) < 0, nil
 	}
 	return false, fmt.Errorf("unknown Value type: %s", v.Type)
 }
@@ -808,6 +2568,27 @@ func (v *Value) LTE(other *Value) (bool, error) {
 			return false, nil
 		}
 		return true, nil
+	case "time":
+		t1, t2, err := parseTimeValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return !t1.After(t2), nil
+	case "duration":
+		d1, d2, err := parseDurationValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return d1 <= d2, nil
+	case "version":
+		s1, s2, err := parseVersionValues(v, other)
+		if err != nil {
+			return false, err
+		}
+
+		return s1.Compare(s2) <= 0, nil
 	}
 	return false, fmt.Errorf("unknown Value type: %s", v.Type)
 }
@@ -836,10 +2617,44 @@ func parseFloat64Values(v1, v2 *Value) (f1, f2 float64, err error) {
 	return
 }
 
+func parseTimeValues(v1, v2 *Value) (t1, t2 time.Time, err error) {
+	if t1, err = time.Parse(time.RFC3339Nano, v1.Data); err != nil {
+		return
+	}
+	t2, err = time.Parse(time.RFC3339Nano, v2.Data)
+	return
+}
+
+func parseDurationValues(v1, v2 *Value) (d1, d2 time.Duration, err error) {
+	if d1, err = time.ParseDuration(v1.Data); err != nil {
+		return
+	}
+	d2, err = time.ParseDuration(v2.Data)
+	return
+}
+
 type operander interface {
 	Operands() []Expr
 }
 
+// A validator is an expression that can detect invalid arguments
+// at construction time, before being evaluated.
+type validator interface {
+	validate() error
+}
+
+// Validate walks the expression tree and reports the first expression built with invalid arguments,
+// like a malformed regular expression.
+func Validate(e Expr) error {
+	return walk(e, func(e Expr) error {
+		if v, ok := e.(validator); ok {
+			return v.validate()
+		}
+
+		return nil
+	})
+}
+
 func walk(expr Expr, fn func(Expr) error) error {
 	err := fn(expr)
 	if err != nil {
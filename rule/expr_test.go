@@ -1,7 +1,10 @@
 package rule_test
 
 import (
+	"encoding/json"
+	"math"
 	"testing"
+	"time"
 
 	"github.com/heetch/regula"
 	"github.com/heetch/regula/rule"
@@ -498,6 +501,75 @@ func TestPercentile(t *testing.T) {
 	require.Equal(t, rule.BoolValue(false), res)
 }
 
+func TestVariant(t *testing.T) {
+	variants := []rule.VariantWeight{{Name: "control", Weight: 50}, {Name: "a", Weight: 25}, {Name: "b", Weight: 25}}
+
+	t.Run("Deterministic", func(t *testing.T) {
+		v := rule.Variant("checkout", rule.StringParam("user-id"), variants...)
+
+		res1, err := v.Eval(regula.Params{"user-id": "123"})
+		require.NoError(t, err)
+		res2, err := v.Eval(regula.Params{"user-id": "123"})
+		require.NoError(t, err)
+		require.Equal(t, res1, res2)
+		require.Equal(t, "string", res1.Type)
+	})
+
+	t.Run("Distribution", func(t *testing.T) {
+		const n = 10000
+
+		e1 := rule.Variant("checkout", rule.Int64Param("user-id"), variants...)
+		e2 := rule.Variant("pricing", rule.Int64Param("user-id"), rule.VariantWeight{Name: "on", Weight: 1}, rule.VariantWeight{Name: "off", Weight: 1})
+
+		counts := make(map[string]int)
+		for i := 0; i < n; i++ {
+			params := regula.Params{"user-id": int64(i)}
+			v1, err := e1.Eval(params)
+			require.NoError(t, err)
+			v2, err := e2.Eval(params)
+			require.NoError(t, err)
+
+			counts[v1.Data]++
+			counts[v1.Data+"/"+v2.Data]++
+		}
+
+		// the variants are selected proportionally to their weight
+		require.InDelta(t, n/2, counts["control"], n/50)
+		require.InDelta(t, n/4, counts["a"], n/50)
+		require.InDelta(t, n/4, counts["b"], n/50)
+
+		// and independently from the experiments using another salt
+		require.InDelta(t, n/4, counts["control/on"], n/50)
+		require.InDelta(t, n/8, counts["a/on"], n/50)
+		require.InDelta(t, n/8, counts["b/off"], n/50)
+	})
+
+	t.Run("Zero weight", func(t *testing.T) {
+		v := rule.Variant("checkout", rule.StringParam("user-id"), rule.VariantWeight{Name: "a", Weight: 0}, rule.VariantWeight{Name: "b", Weight: 3})
+		for _, id := range []string{"1", "2", "3", "4"} {
+			res, err := v.Eval(regula.Params{"user-id": id})
+			require.NoError(t, err)
+			require.Equal(t, rule.StringValue("b"), res)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		exprs := []rule.Expr{
+			rule.Variant("checkout", rule.StringParam("user-id")),
+			rule.Variant("checkout", rule.StringParam("user-id"), rule.VariantWeight{Name: "a", Weight: 0}),
+			rule.Variant("checkout", rule.StringParam("user-id"), rule.VariantWeight{Name: "a", Weight: -1}, rule.VariantWeight{Name: "b", Weight: 2}),
+			rule.Variant("checkout", rule.StringParam("user-id"), rule.VariantWeight{Name: "a", Weight: 1}, rule.VariantWeight{Name: "a", Weight: 2}),
+			rule.Variant("checkout", rule.StringParam("user-id"), rule.VariantWeight{Name: "a", Weight: math.MaxInt64}, rule.VariantWeight{Name: "b", Weight: 1}),
+		}
+
+		for _, e := range exprs {
+			require.Error(t, rule.Validate(e))
+			_, err := e.Eval(regula.Params{"user-id": "123"})
+			require.Error(t, err)
+		}
+	})
+}
+
 func TestLTE(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -618,6 +690,49 @@ func TestParam(t *testing.T) {
 		_, err := v.Eval(nil)
 		require.Error(t, err)
 	})
+
+	t.Run("Time and duration", func(t *testing.T) {
+		now := time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC)
+		params := regula.Params{
+			"now":      now,
+			"duration": 90 * time.Minute,
+		}
+
+		val, err := rule.TimeParam("now").Eval(params)
+		require.NoError(t, err)
+		require.Equal(t, rule.TimeValue(now), val)
+
+		val, err = rule.DurationParam("duration").Eval(params)
+		require.NoError(t, err)
+		require.Equal(t, rule.DurationValue(90*time.Minute), val)
+
+		_, err = rule.TimeParam("duration").Eval(params)
+		require.Equal(t, rule.ErrParamTypeMismatch, err)
+	})
+
+	t.Run("Lists", func(t *testing.T) {
+		params := regula.Params{
+			"tags":    []string{"a", "b"},
+			"ids":     []int64{1, 2},
+			"ratios":  []float64{0.5},
+			"missing": "a",
+		}
+
+		val, err := rule.StringListParam("tags").Eval(params)
+		require.NoError(t, err)
+		require.Equal(t, rule.StringListValue("a", "b"), val)
+
+		val, err = rule.Int64ListParam("ids").Eval(params)
+		require.NoError(t, err)
+		require.Equal(t, rule.Int64ListValue(1, 2), val)
+
+		val, err = rule.Float64ListParam("ratios").Eval(params)
+		require.NoError(t, err)
+		require.Equal(t, rule.Float64ListValue(0.5), val)
+
+		_, err = rule.StringListParam("missing").Eval(params)
+		require.Equal(t, rule.ErrParamTypeMismatch, err)
+	})
 }
 
 func TestValue(t *testing.T) {
@@ -626,4 +741,616 @@ func TestValue(t *testing.T) {
 	require.True(t, v1.Equal(rule.BoolValue(true)))
 	require.False(t, v1.Equal(rule.BoolValue(false)))
 	require.False(t, v1.Equal(rule.StringValue("true")))
+
+	require.True(t, rule.Float64Value(3.5).Equal(&rule.Value{Kind: "value", Type: "float64", Data: "3.5"}))
+	require.False(t, rule.Float64Value(3.5).Equal(rule.Float64Value(3.4)))
+	require.True(t, rule.Int64Value(2).Equal(&rule.Value{Kind: "value", Type: "int64", Data: "02"}))
+	require.False(t, rule.Int64Value(2).Equal(rule.Float64Value(2)))
+
+	paris, err := time.LoadLocation("Europe/Paris")
+	require.NoError(t, err)
+	t1 := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	require.True(t, rule.TimeValue(t1).Equal(rule.TimeValue(t1.In(paris))))
+	require.True(t, rule.TimeValue(t1).Equal(&rule.Value{Kind: "value", Type: "time", Data: "2026-10-17T14:00:00+02:00"}))
+	require.False(t, rule.TimeValue(t1).Equal(rule.TimeValue(t1.Add(time.Nanosecond))))
+	require.True(t, rule.DurationValue(time.Hour).Equal(&rule.Value{Kind: "value", Type: "duration", Data: "60m"}))
+	require.False(t, rule.DurationValue(time.Hour).Equal(rule.StringValue("1h0m0s")))
+
+	require.Equal(t, `["a","b"]`, rule.StringListValue("a", "b").Data)
+	require.Equal(t, `[]`, rule.StringListValue().Data)
+	require.Equal(t, `[1,-2]`, rule.Int64ListValue(1, -2).Data)
+	require.True(t, rule.Int64ListValue(1, 2).Equal(&rule.Value{Kind: "value", Type: "int64-list", Data: "[1, 2]"}))
+	require.True(t, rule.Float64ListValue(1).Equal(&rule.Value{Kind: "value", Type: "float64-list", Data: "[1.0]"}))
+	require.False(t, rule.Int64ListValue(1, 2).Equal(rule.Int64ListValue(2, 1)))
+	require.False(t, rule.Int64ListValue(1, 2).Equal(rule.Int64ListValue(1)))
+	require.False(t, rule.StringListValue("1").Equal(rule.Int64ListValue(1)))
+}
+
+func TestArithmetic(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expr     rule.Expr
+			expected *rule.Value
+		}{
+			{"Add/int64", rule.Add(rule.Int64Value(1), rule.Int64Value(2), rule.Int64Value(3)), rule.Int64Value(6)},
+			{"Add/float64", rule.Add(rule.Float64Value(1.5), rule.Float64Value(2)), rule.Float64Value(3.5)},
+			{"Sub/int64", rule.Sub(rule.Int64Value(10), rule.Int64Value(2), rule.Int64Value(3)), rule.Int64Value(5)},
+			{"Sub/float64", rule.Sub(rule.Float64Value(1.5), rule.Float64Value(2)), rule.Float64Value(-0.5)},
+			{"Mul/int64", rule.Mul(rule.Int64Value(-4), rule.Int64Value(3)), rule.Int64Value(-12)},
+			{"Mul/float64", rule.Mul(rule.Float64Value(2), rule.Float64Value(1.5)), rule.Float64Value(3)},
+			{"Div/int64", rule.Div(rule.Int64Value(7), rule.Int64Value(2)), rule.Int64Value(3)},
+			{"Div/float64", rule.Div(rule.Float64Value(7), rule.Float64Value(2)), rule.Float64Value(3.5)},
+			{"Mod/int64", rule.Mod(rule.Int64Value(7), rule.Int64Value(3)), rule.Int64Value(1)},
+			{"Mod/float64", rule.Mod(rule.Float64Value(7.5), rule.Float64Value(2)), rule.Float64Value(1.5)},
+			{"Min/int64", rule.Min(rule.Int64Value(7), rule.Int64Value(-3), rule.Int64Value(2)), rule.Int64Value(-3)},
+			{"Min/float64", rule.Min(rule.Float64Value(7), rule.Float64Value(3.5)), rule.Float64Value(3.5)},
+			{"Max/int64", rule.Max(rule.Int64Value(7), rule.Int64Value(-3), rule.Int64Value(12)), rule.Int64Value(12)},
+			{"Max/float64", rule.Max(rule.Float64Value(7), rule.Float64Value(3.5)), rule.Float64Value(7)},
+			{"Abs/int64", rule.Abs(rule.Int64Value(-7)), rule.Int64Value(7)},
+			{"Abs/float64", rule.Abs(rule.Float64Value(-7.5)), rule.Float64Value(7.5)},
+			{
+				"Nested",
+				rule.Mul(rule.Float64Param("base"), rule.Add(rule.Float64Value(1), rule.Float64Value(0.5))),
+				rule.Float64Value(3),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				val, err := test.expr.Eval(regula.Params{"base": 2.0})
+				require.NoError(t, err)
+				require.Equal(t, test.expected, val)
+			})
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expr     rule.Expr
+			expected error
+		}{
+			{"Div/int64 by zero", rule.Div(rule.Int64Value(7), rule.Int64Value(0)), rule.ErrDivisionByZero},
+			{"Div/float64 by zero", rule.Div(rule.Float64Value(7), rule.Float64Value(0)), rule.ErrDivisionByZero},
+			{"Mod/int64 by zero", rule.Mod(rule.Int64Value(7), rule.Int64Value(0)), rule.ErrDivisionByZero},
+			{"Mod/float64 by zero", rule.Mod(rule.Float64Value(7), rule.Float64Value(0)), rule.ErrDivisionByZero},
+			{"Add/overflow", rule.Add(rule.Int64Value(math.MaxInt64), rule.Int64Value(1)), rule.ErrIntegerOverflow},
+			{"Add/underflow", rule.Add(rule.Int64Value(math.MinInt64), rule.Int64Value(-1)), rule.ErrIntegerOverflow},
+			{"Sub/overflow", rule.Sub(rule.Int64Value(math.MaxInt64), rule.Int64Value(-1)), rule.ErrIntegerOverflow},
+			{"Sub/underflow", rule.Sub(rule.Int64Value(math.MinInt64), rule.Int64Value(1)), rule.ErrIntegerOverflow},
+			{"Mul/overflow", rule.Mul(rule.Int64Value(math.MaxInt64/2), rule.Int64Value(3)), rule.ErrIntegerOverflow},
+			{"Mul/min int", rule.Mul(rule.Int64Value(math.MinInt64), rule.Int64Value(-1)), rule.ErrIntegerOverflow},
+			{"Div/min int", rule.Div(rule.Int64Value(math.MinInt64), rule.Int64Value(-1)), rule.ErrIntegerOverflow},
+			{"Abs/min int", rule.Abs(rule.Int64Value(math.MinInt64)), rule.ErrIntegerOverflow},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := test.expr.Eval(nil)
+				require.Equal(t, test.expected, err)
+			})
+		}
+	})
+
+	t.Run("Type mismatch", func(t *testing.T) {
+		exprs := []rule.Expr{
+			rule.Add(rule.Int64Value(1), rule.Float64Value(2)),
+			rule.Sub(rule.StringValue("a"), rule.StringValue("b")),
+			rule.Max(rule.Float64Value(1), rule.Int64Value(2)),
+			rule.Abs(rule.BoolValue(true)),
+		}
+
+		for _, e := range exprs {
+			_, err := e.Eval(nil)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestStrings(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		params := regula.Params{
+			"email": "bob@heetch.com",
+			"promo": "SUMMER-2026",
+		}
+
+		tests := []struct {
+			name     string
+			expr     rule.Expr
+			expected *rule.Value
+		}{
+			{"Contains/true", rule.Contains(rule.StringParam("email"), rule.StringValue("@")), rule.BoolValue(true)},
+			{"Contains/false", rule.Contains(rule.StringParam("email"), rule.StringValue("#")), rule.BoolValue(false)},
+			{"Prefix/true", rule.Prefix(rule.StringParam("promo"), rule.StringValue("SUMMER")), rule.BoolValue(true)},
+			{"Prefix/false", rule.Prefix(rule.StringParam("promo"), rule.StringValue("WINTER")), rule.BoolValue(false)},
+			{"Suffix/true", rule.Suffix(rule.StringParam("email"), rule.StringValue("@heetch.com")), rule.BoolValue(true)},
+			{"Suffix/false", rule.Suffix(rule.StringParam("email"), rule.StringValue("@example.com")), rule.BoolValue(false)},
+			{"Matches/true", rule.Matches(rule.StringParam("promo"), `^[A-Z]+-\d{4}$`), rule.BoolValue(true)},
+			{"Matches/false", rule.Matches(rule.StringParam("email"), `^[A-Z]+$`), rule.BoolValue(false)},
+			{"Lower", rule.Lower(rule.StringParam("promo")), rule.StringValue("summer-2026")},
+			{"Upper", rule.Upper(rule.StringParam("email")), rule.StringValue("BOB@HEETCH.COM")},
+			{"Length", rule.Length(rule.StringValue("héé")), rule.Int64Value(3)},
+			{"Concat", rule.Concat(rule.StringValue("a"), rule.StringValue("b"), rule.StringParam("promo")), rule.StringValue("abSUMMER-2026")},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				val, err := test.expr.Eval(params)
+				require.NoError(t, err)
+				require.Equal(t, test.expected, val)
+			})
+		}
+	})
+
+	t.Run("Type mismatch", func(t *testing.T) {
+		exprs := []rule.Expr{
+			rule.Contains(rule.Int64Value(1), rule.StringValue("1")),
+			rule.Prefix(rule.StringValue("a"), rule.BoolValue(true)),
+			rule.Suffix(rule.Float64Value(1), rule.StringValue("1")),
+			rule.Matches(rule.Int64Value(1), "1"),
+			rule.Lower(rule.Int64Value(1)),
+			rule.Upper(rule.BoolValue(true)),
+			rule.Length(rule.Int64Value(1)),
+			rule.Concat(rule.StringValue("a"), rule.Int64Value(1)),
+		}
+
+		for _, e := range exprs {
+			_, err := e.Eval(nil)
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		m := rule.Matches(rule.StringValue("a"), `a(`)
+		require.Error(t, rule.Validate(m))
+		require.Error(t, rule.Validate(rule.Not(m)))
+
+		_, err := m.Eval(nil)
+		require.Error(t, err)
+	})
+}
+
+func TestTime(t *testing.T) {
+	// Saturday 17 October 2026, 23:30 in Paris.
+	now := time.Date(2026, 10, 17, 21, 30, 0, 0, time.UTC)
+	params := regula.Params{
+		"now":      now,
+		"deadline": time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+		"wait":     5 * time.Minute,
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expr     rule.Expr
+			expected *rule.Value
+		}{
+			{"Before/true", rule.Before(rule.TimeParam("now"), rule.TimeParam("deadline")), rule.BoolValue(true)},
+			{"Before/false", rule.Before(rule.TimeParam("deadline"), rule.TimeParam("now")), rule.BoolValue(false)},
+			{"After/true", rule.After(rule.TimeParam("deadline"), rule.TimeParam("now")), rule.BoolValue(true)},
+			{"After/false", rule.After(rule.TimeParam("now"), rule.TimeParam("now")), rule.BoolValue(false)},
+			{"HourOfDay/UTC", rule.HourOfDay(rule.TimeParam("now"), rule.StringValue("UTC")), rule.Int64Value(21)},
+			{"HourOfDay/Paris", rule.HourOfDay(rule.TimeParam("now"), rule.StringValue("Europe/Paris")), rule.Int64Value(23)},
+			{"DayOfWeek/Paris", rule.DayOfWeek(rule.TimeParam("now"), rule.StringValue("Europe/Paris")), rule.Int64Value(6)},
+			{"DayOfWeek/Tokyo", rule.DayOfWeek(rule.TimeParam("now"), rule.StringValue("Asia/Tokyo")), rule.Int64Value(0)},
+			{"GT/time", rule.GT(rule.TimeParam("deadline"), rule.TimeParam("now")), rule.BoolValue(true)},
+			{"LTE/time", rule.LTE(rule.TimeParam("deadline"), rule.TimeParam("now")), rule.BoolValue(false)},
+			{"GTE/duration", rule.GTE(rule.DurationParam("wait"), rule.DurationValue(5*time.Minute)), rule.BoolValue(true)},
+			{"LT/duration", rule.LT(rule.DurationParam("wait"), rule.DurationValue(time.Minute)), rule.BoolValue(false)},
+			{"Eq/duration", rule.Eq(rule.DurationParam("wait"), rule.DurationValue(300*time.Second)), rule.BoolValue(true)},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				val, err := test.expr.Eval(params)
+				require.NoError(t, err)
+				require.Equal(t, test.expected, val)
+			})
+		}
+	})
+
+	t.Run("Now", func(t *testing.T) {
+		defer func(fn func() time.Time) { rule.NowFunc = fn }(rule.NowFunc)
+		rule.NowFunc = func() time.Time { return now }
+
+		val, err := rule.Now().Eval(nil)
+		require.NoError(t, err)
+		require.Equal(t, rule.TimeValue(now), val)
+	})
+
+	t.Run("Type mismatch", func(t *testing.T) {
+		exprs := []rule.Expr{
+			rule.Before(rule.TimeParam("now"), rule.StringValue("2026-12-31T00:00:00Z")),
+			rule.After(rule.DurationParam("wait"), rule.TimeParam("now")),
+			rule.HourOfDay(rule.DurationParam("wait"), rule.StringValue("UTC")),
+			rule.DayOfWeek(rule.TimeParam("now"), rule.Int64Value(1)),
+		}
+
+		for _, e := range exprs {
+			_, err := e.Eval(params)
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("Unknown time zone", func(t *testing.T) {
+		e := rule.HourOfDay(rule.TimeParam("now"), rule.StringValue("Mars/Olympus"))
+		require.Error(t, rule.Validate(e))
+		require.Error(t, rule.Validate(rule.DayOfWeek(rule.TimeParam("now"), rule.StringValue("Mars/Olympus"))))
+
+		_, err := e.Eval(params)
+		require.Error(t, err)
+
+		// time zones coming from params are only known on evaluation.
+		require.NoError(t, rule.Validate(rule.HourOfDay(rule.TimeParam("now"), rule.StringParam("tz"))))
+	})
+}
+
+func TestLists(t *testing.T) {
+	params := regula.Params{
+		"tags":       []string{"vip-gold", "pet-friendly"},
+		"categories": []string{"berline", "van"},
+		"scores":     []int64{10, 20, 30},
+		"none":       []int64{},
+		"ratios":     []float64{0.5, 1.5},
+		"min":        int64(5),
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expr     rule.Expr
+			expected *rule.Value
+		}{
+			{"Contains/list/true", rule.Contains(rule.StringListParam("tags"), rule.StringValue("pet-friendly")), rule.BoolValue(true)},
+			{"Contains/list/false", rule.Contains(rule.Int64ListParam("scores"), rule.Int64Value(15)), rule.BoolValue(false)},
+			{"Contains/string", rule.Contains(rule.StringValue("pet-friendly"), rule.StringValue("pet")), rule.BoolValue(true)},
+			{"Intersects/true", rule.Intersects(rule.StringListParam("categories"), rule.StringListValue("van", "moto")), rule.BoolValue(true)},
+			{"Intersects/false", rule.Intersects(rule.StringListParam("categories"), rule.StringListParam("tags")), rule.BoolValue(false)},
+			{"Intersects/empty", rule.Intersects(rule.Int64ListParam("none"), rule.Int64ListParam("scores")), rule.BoolValue(false)},
+			{"Size", rule.Size(rule.Int64ListParam("scores")), rule.Int64Value(3)},
+			{"Size/empty", rule.Size(rule.Int64ListParam("none")), rule.Int64Value(0)},
+			{
+				"Any/true",
+				rule.Any(rule.StringListParam("tags"), "tag", rule.Prefix(rule.StringParam("tag"), rule.StringValue("vip"))),
+				rule.BoolValue(true),
+			},
+			{
+				"Any/false",
+				rule.Any(rule.Float64ListParam("ratios"), "r", rule.GT(rule.Float64Param("r"), rule.Float64Value(2))),
+				rule.BoolValue(false),
+			},
+			{
+				"Any/empty",
+				rule.Any(rule.Int64ListParam("none"), "s", rule.True()),
+				rule.BoolValue(false),
+			},
+			{
+				"All/true",
+				rule.All(rule.Int64ListParam("scores"), "s", rule.GT(rule.Int64Param("s"), rule.Int64Param("min"))),
+				rule.BoolValue(true),
+			},
+			{
+				"All/false",
+				rule.All(rule.Int64ListParam("scores"), "s", rule.GT(rule.Int64Param("s"), rule.Int64Value(10))),
+				rule.BoolValue(false),
+			},
+			{
+				"All/empty",
+				rule.All(rule.Int64ListParam("none"), "s", rule.BoolValue(false)),
+				rule.BoolValue(true),
+			},
+			{
+				"Nested",
+				rule.Any(rule.StringListParam("tags"), "tag",
+					rule.All(rule.StringListParam("categories"), "c", rule.Not(rule.Eq(rule.StringParam("c"), rule.StringParam("tag")))),
+				),
+				rule.BoolValue(true),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				val, err := test.expr.Eval(params)
+				require.NoError(t, err)
+				require.Equal(t, test.expected, val)
+			})
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		exprs := []rule.Expr{
+			rule.Contains(rule.StringListParam("tags"), rule.Int64Value(1)),
+			rule.Contains(rule.Int64Value(1), rule.Int64Value(1)),
+			rule.Intersects(rule.StringListParam("tags"), rule.Int64ListParam("scores")),
+			rule.Intersects(rule.StringValue("a"), rule.StringValue("a")),
+			rule.Size(rule.StringValue("a")),
+			rule.Any(rule.StringValue("a"), "s", rule.True()),
+			rule.Any(rule.Int64ListParam("scores"), "s", rule.Int64Param("s")),
+			rule.All(rule.Int64ListParam("scores"), "s", rule.BoolParam("s")),
+			rule.All(rule.Int64ListParam("scores"), "s", rule.Int64Param("unknown")),
+		}
+
+		for _, e := range exprs {
+			_, err := e.Eval(params)
+			require.Error(t, err)
+		}
+	})
+
+	t.Run("Invalid element name", func(t *testing.T) {
+		e := rule.Any(rule.Int64ListParam("scores"), "", rule.True())
+		require.Error(t, rule.Validate(e))
+		require.Error(t, rule.Validate(rule.All(rule.Int64ListParam("scores"), "", rule.True())))
+
+		_, err := e.Eval(params)
+		require.Error(t, err)
+	})
+
+	t.Run("Nil params", func(t *testing.T) {
+		val, err := rule.Any(rule.Int64ListValue(1, 2), "i", rule.Eq(rule.Int64Param("i"), rule.Int64Value(2))).Eval(nil)
+		require.NoError(t, err)
+		require.Equal(t, rule.BoolValue(true), val)
+
+		_, err = rule.Any(rule.Int64ListValue(1, 2), "i", rule.Int64Param("j")).Eval(nil)
+		require.Equal(t, rule.ErrParamNotFound, err)
+	})
+}
+
+func TestGeo(t *testing.T) {
+	paris := rule.Point{Lat: 48.8566, Lng: 2.3522}
+	params := regula.Params{
+		"pickup": paris,
+		"lyon":   rule.Point{Lat: 45.764, Lng: 4.8357},
+		"radius": 25.0,
+	}
+
+	// roughly the area of the Charles de Gaulle airport
+	cdg := rule.PolygonValue(
+		rule.Point{Lat: 49.0250, Lng: 2.5100},
+		rule.Point{Lat: 49.0250, Lng: 2.5900},
+		rule.Point{Lat: 48.9900, Lng: 2.5900},
+		rule.Point{Lat: 48.9900, Lng: 2.5100},
+	)
+
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expr     rule.Expr
+			expected *rule.Value
+		}{
+			{"Distance", rule.Distance(rule.PointParam("pickup"), rule.PointParam("lyon")), rule.Float64Value(391.499472)},
+			{"Distance/same", rule.Distance(rule.PointParam("pickup"), rule.PointValue(paris)), rule.Float64Value(0)},
+			{
+				"WithinRadius/true",
+				rule.WithinRadius(rule.PointValue(rule.Point{Lat: 49.0097, Lng: 2.5479}), rule.PointParam("pickup"), rule.Float64Param("radius")),
+				rule.BoolValue(true),
+			},
+			{
+				"WithinRadius/false",
+				rule.WithinRadius(rule.PointParam("lyon"), rule.PointParam("pickup"), rule.Float64Param("radius")),
+				rule.BoolValue(false),
+			},
+			{"InPolygon/true", rule.InPolygon(rule.PointValue(rule.Point{Lat: 49.0097, Lng: 2.5479}), cdg), rule.BoolValue(true)},
+			{"InPolygon/false", rule.InPolygon(rule.PointParam("pickup"), cdg), rule.BoolValue(false)},
+			{"Eq", rule.Eq(rule.PointParam("pickup"), rule.PointValue(paris)), rule.BoolValue(true)},
+			{"Param", rule.PointParam("lyon"), &rule.Value{Kind: "value", Type: "point", Data: "[45.764000,4.835700]"}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				val, err := test.expr.Eval(params)
+				require.NoError(t, err)
+				require.Equal(t, test.expected, val)
+			})
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		exprs := []rule.Expr{
+			rule.Distance(rule.PointParam("pickup"), rule.StringValue("paris")),
+			rule.Distance(rule.PointParam("pickup"), rule.PointParam("unknown")),
+			rule.WithinRadius(rule.PointParam("pickup"), rule.PointParam("lyon"), rule.Int64Value(10)),
+			rule.InPolygon(rule.PointParam("pickup"), rule.PointParam("lyon")),
+			rule.InPolygon(rule.PointParam("pickup"), rule.PolygonValue(paris, paris)),
+			rule.Distance(rule.PointParam("pickup"), rule.PointValue(rule.Point{Lat: 91})),
+			rule.PointParam("radius"),
+		}
+
+		for _, e := range exprs {
+			_, err := e.Eval(params)
+			require.Error(t, err)
+		}
+	})
+}
+
+func TestVersion(t *testing.T) {
+	t.Run("Parse", func(t *testing.T) {
+		tests := []struct {
+			s        string
+			expected rule.Version
+		}{
+			{"5.12.3", rule.Version{Major: 5, Minor: 12, Patch: 3}},
+			{"6", rule.Version{Major: 6}},
+			{"6.1", rule.Version{Major: 6, Minor: 1}},
+			{"1.0.0-beta.11", rule.Version{Major: 1, Pre: "beta.11"}},
+			{"1.0.0-rc-1+build.042", rule.Version{Major: 1, Pre: "rc-1", Build: "build.042"}},
+		}
+
+		for _, test := range tests {
+			v, err := rule.ParseVersion(test.s)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v)
+		}
+
+		for _, s := range []string{"", "v1.2.3", "1.2.3.4", "1..2", "1.2.", "01.2.3", "1.2.3-", "1.2.3-01", "1.2.3-beta..1", "1.2.3+", "1.2.3+a_b", "1.2.x"} {
+			_, err := rule.ParseVersion(s)
+			require.Error(t, err, s)
+		}
+	})
+
+	t.Run("Compare", func(t *testing.T) {
+		// ordered as in the semantic versioning specification
+		versions := []string{
+			"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11",
+			"1.0.0-rc.1", "1.0.0", "1.0.1", "1.2.0", "1.10.0", "2.0.0",
+		}
+
+		for i := range versions {
+			v1, err := rule.ParseVersion(versions[i])
+			require.NoError(t, err)
+			require.Zero(t, v1.Compare(v1))
+
+			for _, s := range versions[i+1:] {
+				v2, err := rule.ParseVersion(s)
+				require.NoError(t, err)
+				require.Equal(t, -1, v1.Compare(v2), "%s < %s", versions[i], s)
+				require.Equal(t, 1, v2.Compare(v1), "%s > %s", s, versions[i])
+			}
+		}
+
+		v1, _ := rule.ParseVersion("1.0.0+1")
+		v2, _ := rule.ParseVersion("1.0.0+2")
+		require.Zero(t, v1.Compare(v2))
+	})
+
+	params := regula.Params{
+		"app-version": "5.12.3",
+		"invalid":     "5.x",
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expr     rule.Expr
+			expected *rule.Value
+		}{
+			{"Param", rule.VersionParam("app-version"), rule.VersionValue("5.12.3")},
+			{"GT", rule.GT(rule.VersionParam("app-version"), rule.VersionValue("5.9.0")), rule.BoolValue(true)},
+			{"GTE", rule.GTE(rule.VersionParam("app-version"), rule.VersionValue("5.12.3+42")), rule.BoolValue(true)},
+			{"LT", rule.LT(rule.VersionParam("app-version"), rule.VersionValue("6")), rule.BoolValue(true)},
+			{"LTE", rule.LTE(rule.VersionParam("app-version"), rule.VersionValue("5.12.3-rc.1")), rule.BoolValue(false)},
+			{"Eq", rule.Eq(rule.VersionValue("6"), rule.VersionValue("6.0.0")), rule.BoolValue(true)},
+			{"In", rule.In(rule.VersionParam("app-version"), rule.VersionValue("5.12"), rule.VersionValue("5.12.3")), rule.BoolValue(true)},
+			{
+				"Range",
+				rule.And(
+					rule.GTE(rule.VersionParam("app-version"), rule.VersionValue("5.10.0")),
+					rule.LT(rule.VersionParam("app-version"), rule.VersionValue("6")),
+				),
+				rule.BoolValue(true),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				val, err := test.expr.Eval(params)
+				require.NoError(t, err)
+				require.Equal(t, test.expected, val)
+			})
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := rule.VersionParam("invalid").Eval(params)
+		require.Equal(t, rule.ErrParamTypeMismatch, err)
+
+		_, err = rule.GT(rule.VersionParam("app-version"), rule.VersionValue("5.x")).Eval(params)
+		require.Error(t, err)
+	})
+}
+
+func TestOptional(t *testing.T) {
+	params := regula.Params{
+		"age":  int64(30),
+		"city": "paris",
+	}
+
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			expr     rule.Expr
+			expected *rule.Value
+		}{
+			{"Optional", rule.Optional(rule.Int64Param("age")), rule.Int64Value(30)},
+			{"Has/true", rule.Has(rule.Int64Param("age")), rule.BoolValue(true)},
+			{"Has/false", rule.Has(rule.StringParam("promo")), rule.BoolValue(false)},
+			{"Has/optional", rule.Has(rule.Optional(rule.StringParam("promo"))), rule.BoolValue(false)},
+			{"Coalesce/defined", rule.Coalesce(rule.Int64Param("age"), rule.Int64Value(18)), rule.Int64Value(30)},
+			{"Coalesce/missing", rule.Coalesce(rule.Optional(rule.Int64Param("height")), rule.Int64Value(170)), rule.Int64Value(170)},
+			{
+				"Coalesce/expression",
+				rule.Coalesce(rule.Concat(rule.StringParam("city"), rule.StringParam("zip")), rule.StringParam("country"), rule.StringParam("city")),
+				rule.StringValue("paris"),
+			},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				val, err := test.expr.Eval(params)
+				require.NoError(t, err)
+				require.Equal(t, test.expected, val)
+			})
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			expr rule.Expr
+			err  error
+		}{
+			{rule.Optional(rule.StringParam("promo")), rule.ErrOptionalParamNotFound},
+			{rule.Has(rule.StringParam("age")), rule.ErrParamTypeMismatch},
+			{rule.Coalesce(rule.StringParam("promo"), rule.StringParam("zip")), rule.ErrParamNotFound},
+			{rule.Coalesce(rule.StringParam("age"), rule.StringParam("city")), rule.ErrParamTypeMismatch},
+		}
+
+		for _, test := range tests {
+			_, err := test.expr.Eval(params)
+			require.Equal(t, test.err, err)
+		}
+	})
+}
+
+func TestObject(t *testing.T) {
+	t.Run("Decode", func(t *testing.T) {
+		v := rule.ObjectValue(map[string]interface{}{"radius": 3.5, "drivers": int64(1) << 60, "zone": "paris", "vip": false})
+		require.Equal(t, "object", v.Type)
+
+		fields, err := rule.DecodeObject(v)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			"radius":  json.Number("3.5"),
+			"drivers": json.Number("1152921504606846976"),
+			"zone":    "paris",
+			"vip":     false,
+		}, fields)
+
+		for _, data := range []string{"", "null", "[]", `{"a": {}}`, `{"a": null}`, `{"a": 1} {}`} {
+			_, err = rule.DecodeObject(&rule.Value{Type: "object", Data: data})
+			require.Error(t, err, data)
+		}
+
+		_, err = rule.DecodeObject(rule.StringValue("{}"))
+		require.Error(t, err)
+	})
+
+	t.Run("Equal", func(t *testing.T) {
+		tests := []struct {
+			a, b  string
+			equal bool
+		}{
+			{`{"a": 1, "b": "c"}`, `{"b": "c", "a": 1}`, true},
+			{`{"a": 1}`, `{"a": 1.0}`, true},
+			{`{"a": 9007199254740993}`, `{"a": 9007199254740992}`, false},
+			{`{"a": 1}`, `{"a": "1"}`, false},
+			{`{"a": 1}`, `{"b": 1}`, false},
+			{`{"a": 1}`, `{"a": 1, "b": 1}`, false},
+		}
+
+		for _, test := range tests {
+			a := &rule.Value{Type: "object", Data: test.a}
+			b := &rule.Value{Type: "object", Data: test.b}
+			require.Equal(t, test.equal, a.Equal(b), "%s == %s", test.a, test.b)
+		}
+	})
 }
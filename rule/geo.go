@@ -0,0 +1,266 @@
+package rule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// earthRadius is the mean radius of the Earth, in kilometers.
+const earthRadius = 6371.0088
+
+// A Point is a geographic location, in decimal degrees.
+type Point struct {
+	Lat float64
+	Lng float64
+}
+
+func (p Point) validate() error {
+	if math.IsNaN(p.Lat) || p.Lat < -90 || p.Lat > 90 {
+		return fmt.Errorf("invalid latitude %v", p.Lat)
+	}
+
+	if math.IsNaN(p.Lng) || p.Lng < -180 || p.Lng > 180 {
+		return fmt.Errorf("invalid longitude %v", p.Lng)
+	}
+
+	return nil
+}
+
+func (p Point) format() string {
+	return "[" + strconv.FormatFloat(p.Lat, 'f', 6, 64) + "," + strconv.FormatFloat(p.Lng, 'f', 6, 64) + "]"
+}
+
+// haversine returns the great-circle distance between a and b, in kilometers.
+func haversine(a, b Point) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLng := (b.Lng - a.Lng) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// inPolygon reports whether p is inside the polygon, using the even-odd rule.
+// Latitudes and longitudes are treated as planar coordinates, which is precise enough for areas the size of a city.
+func inPolygon(p Point, polygon []Point) bool {
+	in := false
+
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Lat > p.Lat) != (b.Lat > p.Lat) &&
+			p.Lng < (b.Lng-a.Lng)*(p.Lat-a.Lat)/(b.Lat-a.Lat)+a.Lng {
+			in = !in
+		}
+	}
+
+	return in
+}
+
+// PointParam creates a Param that looks up in the set of params passed during evaluation and returns the value
+// of the variable that corresponds to the given name.
+// The corresponding value must be a point. If not found it returns an error.
+func PointParam(name string) *Param {
+	return &Param{
+		Kind: "param",
+		Type: "point",
+		Name: name,
+	}
+}
+
+// PointValue creates a point value.
+// The point is stored as a JSON array holding its latitude and longitude.
+func PointValue(p Point) *Value {
+	return newValue("point", p.format())
+}
+
+// PolygonValue creates a polygon value from its vertices.
+// The polygon is stored as a JSON array of points, and is implicitly closed.
+func PolygonValue(points ...Point) *Value {
+	l := make([]string, len(points))
+	for i, p := range points {
+		l[i] = p.format()
+	}
+
+	return newValue("polygon", "["+strings.Join(l, ",")+"]")
+}
+
+// decodePoint decodes the data of a point value.
+func decodePoint(data string) (Point, error) {
+	var c []float64
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return Point{}, err
+	}
+
+	if len(c) != 2 {
+		return Point{}, errors.New("a point must have a latitude and a longitude")
+	}
+
+	p := Point{Lat: c[0], Lng: c[1]}
+	return p, p.validate()
+}
+
+// decodePolygon decodes the data of a polygon value.
+func decodePolygon(data string) ([]Point, error) {
+	var l [][]float64
+	if err := json.Unmarshal([]byte(data), &l); err != nil {
+		return nil, err
+	}
+
+	if len(l) < 3 {
+		return nil, errors.New("a polygon must have at least 3 points")
+	}
+
+	points := make([]Point, len(l))
+	for i, c := range l {
+		if len(c) != 2 {
+			return nil, errors.New("a point must have a latitude and a longitude")
+		}
+
+		points[i] = Point{Lat: c[0], Lng: c[1]}
+		if err := points[i].validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	return points, nil
+}
+
+// evalPoint evaluates e and ensures it returns a point.
+func evalPoint(name string, e Expr, params Params) (Point, error) {
+	v, err := e.Eval(params)
+	if err != nil {
+		return Point{}, err
+	}
+
+	if v.Type != "point" {
+		return Point{}, fmt.Errorf("invalid operand type for %s func", name)
+	}
+
+	return decodePoint(v.Data)
+}
+
+type exprDistance struct {
+	operator
+}
+
+// Distance creates an expression that returns the great-circle distance between the points a and b, in kilometers,
+// computed using the haversine formula. Both operands must evaluate to points.
+func Distance(a, b Expr) Expr {
+	return &exprDistance{
+		operator: operator{
+			kind:     "distance",
+			operands: []Expr{a, b},
+		},
+	}
+}
+
+func (n *exprDistance) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in Distance func")
+	}
+
+	a, err := evalPoint("Distance", n.operands[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := evalPoint("Distance", n.operands[1], params)
+	if err != nil {
+		return nil, err
+	}
+
+	return Float64Value(haversine(a, b)), nil
+}
+
+type exprWithinRadius struct {
+	operator
+}
+
+// WithinRadius creates an expression that evaluates to true if the point p is at most radius kilometers
+// away from center. p and center must evaluate to points and radius to a float64.
+func WithinRadius(p, center, radius Expr) Expr {
+	return &exprWithinRadius{
+		operator: operator{
+			kind:     "within-radius",
+			operands: []Expr{p, center, radius},
+		},
+	}
+}
+
+func (n *exprWithinRadius) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 3 {
+		return nil, errors.New("invalid number of operands in WithinRadius func")
+	}
+
+	p, err := evalPoint("WithinRadius", n.operands[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	center, err := evalPoint("WithinRadius", n.operands[1], params)
+	if err != nil {
+		return nil, err
+	}
+
+	radius, err := n.operands[2].Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if radius.Type != "float64" {
+		return nil, errors.New("invalid operand type for WithinRadius func")
+	}
+
+	r, err := strconv.ParseFloat(radius.Data, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	return BoolValue(haversine(p, center) <= r), nil
+}
+
+type exprInPolygon struct {
+	operator
+}
+
+// InPolygon creates an expression that evaluates to true if the point p is inside the given polygon.
+// p must evaluate to a point and polygon to a polygon.
+func InPolygon(p, polygon Expr) Expr {
+	return &exprInPolygon{
+		operator: operator{
+			kind:     "in-polygon",
+			operands: []Expr{p, polygon},
+		},
+	}
+}
+
+func (n *exprInPolygon) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 2 {
+		return nil, errors.New("invalid number of operands in InPolygon func")
+	}
+
+	p, err := evalPoint("InPolygon", n.operands[0], params)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := n.operands[1].Eval(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type != "polygon" {
+		return nil, errors.New("invalid operand type for InPolygon func")
+	}
+
+	polygon, err := decodePolygon(v.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return BoolValue(inPolygon(p, polygon)), nil
+}
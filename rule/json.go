@@ -48,6 +48,16 @@ func (o *operator) Operands() []Expr {
 	return o.operands
 }
 
+func (o *operator) node() *operator {
+	return o
+}
+
+// operatorExpr is implemented by every expression built on top of an operator.
+type operatorExpr interface {
+	Expr
+	node() *operator
+}
+
 type operands struct {
 	Ops   []json.RawMessage `json:"operands"`
 	Exprs []Expr
@@ -117,6 +127,10 @@ func unmarshalExpr(kind string, data []byte) (Expr, error) {
 		var percentile exprPercentile
 		e = &percentile
 		err = percentile.UnmarshalJSON(data)
+	case "variant":
+		var variant exprVariant
+		e = &variant
+		err = variant.UnmarshalJSON(data)
 	case "fnv":
 		var fnv exprFNV
 		e = &fnv
@@ -129,6 +143,126 @@ func unmarshalExpr(kind string, data []byte) (Expr, error) {
 		var lte exprLTE
 		e = &lte
 		err = lte.UnmarshalJSON(data)
+	case "add":
+		var add exprAdd
+		e = &add
+		err = add.UnmarshalJSON(data)
+	case "sub":
+		var sub exprSub
+		e = &sub
+		err = sub.UnmarshalJSON(data)
+	case "mul":
+		var mul exprMul
+		e = &mul
+		err = mul.UnmarshalJSON(data)
+	case "div":
+		var div exprDiv
+		e = &div
+		err = div.UnmarshalJSON(data)
+	case "mod":
+		var mod exprMod
+		e = &mod
+		err = mod.UnmarshalJSON(data)
+	case "min":
+		var min exprMin
+		e = &min
+		err = min.UnmarshalJSON(data)
+	case "max":
+		var max exprMax
+		e = &max
+		err = max.UnmarshalJSON(data)
+	case "abs":
+		var abs exprAbs
+		e = &abs
+		err = abs.UnmarshalJSON(data)
+	case "contains":
+		var contains exprContains
+		e = &contains
+		err = contains.UnmarshalJSON(data)
+	case "prefix":
+		var prefix exprPrefix
+		e = &prefix
+		err = prefix.UnmarshalJSON(data)
+	case "suffix":
+		var suffix exprSuffix
+		e = &suffix
+		err = suffix.UnmarshalJSON(data)
+	case "matches":
+		var matches exprMatches
+		e = &matches
+		err = matches.UnmarshalJSON(data)
+	case "lower":
+		var lower exprLower
+		e = &lower
+		err = lower.UnmarshalJSON(data)
+	case "upper":
+		var upper exprUpper
+		e = &upper
+		err = upper.UnmarshalJSON(data)
+	case "length":
+		var length exprLength
+		e = &length
+		err = length.UnmarshalJSON(data)
+	case "concat":
+		var concat exprConcat
+		e = &concat
+		err = concat.UnmarshalJSON(data)
+	case "now":
+		var now exprNow
+		e = &now
+		err = now.UnmarshalJSON(data)
+	case "before":
+		var before exprBefore
+		e = &before
+		err = before.UnmarshalJSON(data)
+	case "after":
+		var after exprAfter
+		e = &after
+		err = after.UnmarshalJSON(data)
+	case "hour-of-day":
+		var hourOfDay exprHourOfDay
+		e = &hourOfDay
+		err = hourOfDay.UnmarshalJSON(data)
+	case "day-of-week":
+		var dayOfWeek exprDayOfWeek
+		e = &dayOfWeek
+		err = dayOfWeek.UnmarshalJSON(data)
+	case "intersects":
+		var intersects exprIntersects
+		e = &intersects
+		err = intersects.UnmarshalJSON(data)
+	case "distance":
+		var distance exprDistance
+		e = &distance
+		err = distance.UnmarshalJSON(data)
+	case "within-radius":
+		var withinRadius exprWithinRadius
+		e = &withinRadius
+		err = withinRadius.UnmarshalJSON(data)
+	case "in-polygon":
+		var inPolygon exprInPolygon
+		e = &inPolygon
+		err = inPolygon.UnmarshalJSON(data)
+	case "has":
+		var has exprHas
+		e = &has
+		err = has.UnmarshalJSON(data)
+	case "coalesce":
+		var coalesce exprCoalesce
+		e = &coalesce
+		err = coalesce.UnmarshalJSON(data)
+	case "size":
+		var size exprSize
+		e = &size
+		err = size.UnmarshalJSON(data)
+	case "any":
+		var anyExpr exprAny
+		e = &anyExpr
+		err = anyExpr.UnmarshalJSON(data)
+	case "all":
+		var allExpr exprAll
+		e = &allExpr
+		err = allExpr.UnmarshalJSON(data)
 	default:
 		err = errors.New("unknown expression kind " + kind)
 	}
@@ -3,6 +3,7 @@ package rule
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -43,6 +44,19 @@ func TestUnmarshalExpr(t *testing.T) {
 		require.Error(t, err)
 	})
 
+	t.Run("Invalid pattern", func(t *testing.T) {
+		_, err := unmarshalExpr("matches", []byte(`{"kind":"matches","operands": [{"kind": "param"}, {"kind": "value", "type": "string", "data": "a("}]}`))
+		require.Error(t, err)
+
+		_, err = unmarshalExpr("matches", []byte(`{"kind":"matches","operands": [{"kind": "param"}, {"kind": "param", "type": "string", "name": "a"}]}`))
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid variants", func(t *testing.T) {
+		_, err := unmarshalExpr("variant", []byte(`{"kind":"variant","operands": [{"kind": "value", "type": "string", "data": "exp"}, {"kind": "param"}, {"kind": "value", "type": "string", "data": "a"}, {"kind": "value", "type": "int64", "data": "0"}]}`))
+		require.Error(t, err)
+	})
+
 	t.Run("OK", func(t *testing.T) {
 		tests := []struct {
 			kind string
@@ -55,10 +69,38 @@ func TestUnmarshalExpr(t *testing.T) {
 			{"and", []byte(`{"kind":"and","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprAnd)},
 			{"or", []byte(`{"kind":"or","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprOr)},
 			{"percentile", []byte(`{"kind":"percentile","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprPercentile)},
+			{"variant", []byte(`{"kind":"variant","operands": [{"kind": "value", "type": "string", "data": "exp"}, {"kind": "param"}, {"kind": "value", "type": "string", "data": "a"}, {"kind": "value", "type": "int64", "data": "1"}]}`), new(exprVariant)},
 			{"gt", []byte(`{"kind":"gt","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprGT)},
 			{"gte", []byte(`{"kind":"gte","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprGTE)},
 			{"lt", []byte(`{"kind":"lt","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprLT)},
 			{"lte", []byte(`{"kind":"lte","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprLTE)},
+			{"add", []byte(`{"kind":"add","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprAdd)},
+			{"sub", []byte(`{"kind":"sub","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprSub)},
+			{"mul", []byte(`{"kind":"mul","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprMul)},
+			{"div", []byte(`{"kind":"div","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprDiv)},
+			{"mod", []byte(`{"kind":"mod","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprMod)},
+			{"min", []byte(`{"kind":"min","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprMin)},
+			{"max", []byte(`{"kind":"max","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprMax)},
+			{"abs", []byte(`{"kind":"abs","operands": [{"kind": "value"}]}`), new(exprAbs)},
+			{"contains", []byte(`{"kind":"contains","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprContains)},
+			{"prefix", []byte(`{"kind":"prefix","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprPrefix)},
+			{"suffix", []byte(`{"kind":"suffix","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprSuffix)},
+			{"matches", []byte(`{"kind":"matches","operands": [{"kind": "param"}, {"kind": "value", "type": "string", "data": "^a+$"}]}`), new(exprMatches)},
+			{"lower", []byte(`{"kind":"lower","operands": [{"kind": "value"}]}`), new(exprLower)},
+			{"upper", []byte(`{"kind":"upper","operands": [{"kind": "value"}]}`), new(exprUpper)},
+			{"length", []byte(`{"kind":"length","operands": [{"kind": "value"}]}`), new(exprLength)},
+			{"concat", []byte(`{"kind":"concat","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprConcat)},
+			{"now", []byte(`{"kind":"now","operands": []}`), new(exprNow)},
+			{"before", []byte(`{"kind":"before","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprBefore)},
+			{"after", []byte(`{"kind":"after","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprAfter)},
+			{"hour-of-day", []byte(`{"kind":"hour-of-day","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprHourOfDay)},
+			{"day-of-week", []byte(`{"kind":"day-of-week","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprDayOfWeek)},
+			{"intersects", []byte(`{"kind":"intersects","operands": [{"kind": "value"}, {"kind": "param"}]}`), new(exprIntersects)},
+			{"size", []byte(`{"kind":"size","operands": [{"kind": "param"}]}`), new(exprSize)},
+			{"has", []byte(`{"kind":"has","operands": [{"kind": "param"}]}`), new(exprHas)},
+			{"coalesce", []byte(`{"kind":"coalesce","operands": [{"kind": "param"}, {"kind": "value"}]}`), new(exprCoalesce)},
+			{"any", []byte(`{"kind":"any","operands": [{"kind": "param"}, {"kind": "value"}, {"kind": "value"}]}`), new(exprAny)},
+			{"all", []byte(`{"kind":"all","operands": [{"kind": "param"}, {"kind": "value"}, {"kind": "value"}]}`), new(exprAll)},
 			{"param", []byte(`{"kind":"param"}`), new(Param)},
 			{"value", []byte(`{"kind":"value"}`), new(Value)},
 		}
@@ -86,8 +128,8 @@ func TestRuleUnmarshalling(t *testing.T) {
 				"operands": [
 					{
 						"kind": "value",
-						"type": "string",
-						"data": "bar"
+						"type": "bool",
+						"data": "true"
 					},
 					{
 						"kind": "eq",
@@ -108,8 +150,7 @@ func TestRuleUnmarshalling(t *testing.T) {
 			}
 		}`))
 		require.NoError(t, err)
-		require.Equal(t, "string", rule.Result.Type)
-		require.Equal(t, "foo", rule.Result.Data)
+		require.Equal(t, StringValue("foo"), rule.Result)
 		require.IsType(t, new(exprEq), rule.Expr)
 		eq := rule.Expr.(*exprEq)
 		require.Len(t, eq.operands, 2)
@@ -123,15 +164,19 @@ func TestRuleUnmarshalling(t *testing.T) {
 				Or(
 					Eq(
 						StringValue("foo"),
+						StringParam("foo"),
+					),
+					Eq(
 						Int64Value(10),
-						Float64Value(10),
-						BoolValue(true),
+						Int64Param("i"),
 					),
 					In(
-						StringParam("foo"),
-						Int64Param("foo"),
-						Float64Param("foo"),
-						BoolParam("foo"),
+						Float64Param("f"),
+						Float64Value(10),
+					),
+					Eq(
+						BoolParam("b"),
+						BoolValue(true),
 					),
 					Not(
 						BoolValue(true),
@@ -143,7 +188,7 @@ func TestRuleUnmarshalling(t *testing.T) {
 					Not(
 						Eq(
 							FNV(StringValue("Bob Dylan")),
-							StringValue("Bob Dylan"),
+							Int64Value(1),
 						),
 					),
 					GT(
@@ -162,6 +207,27 @@ func TestRuleUnmarshalling(t *testing.T) {
 						Int64Value(10),
 						Int64Value(10),
 					),
+					Contains(Lower(Concat(StringValue("a"), Upper(StringParam("b")))), StringValue("a")),
+					Prefix(StringParam("b"), StringValue("a")),
+					Suffix(StringParam("b"), StringValue("a")),
+					Matches(StringParam("email"), `@heetch\.com$`),
+					GT(Length(StringParam("b")), Int64Value(3)),
+					Before(Now(), TimeValue(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))),
+					After(TimeParam("t"), Now()),
+					LT(HourOfDay(Now(), StringValue("Europe/Paris")), DayOfWeek(Now(), StringParam("tz"))),
+					GT(DurationParam("d"), DurationValue(time.Minute)),
+					LT(VersionParam("app-version"), VersionValue("6.0.0-beta.1")),
+					Or(Has(Optional(StringParam("promo"))), GT(Coalesce(Optional(Int64Param("age")), Int64Value(18)), Int64Value(21))),
+					Eq(Variant("checkout", Int64Param("user-id"), VariantWeight{Name: "a", Weight: 1}, VariantWeight{Name: "b", Weight: 2}), StringValue("a")),
+					Contains(StringListParam("tags"), StringValue("vip")),
+					Intersects(Int64ListParam("ids"), Int64ListValue(1, 2)),
+					GT(Size(Float64ListParam("ratios")), Int64Value(1)),
+					Any(StringListParam("tags"), "tag", All(Float64ListValue(0.5), "r", GT(Float64Param("r"), Float64Value(0)))),
+					GT(
+						Abs(Sub(Mul(Int64Param("a"), Int64Value(2)), Div(Int64Value(10), Int64Value(3)))),
+						Max(Mod(Int64Value(7), Int64Value(2)), Min(Int64Value(1), Int64Value(2))),
+						Add(Int64Value(1), Int64Value(2)),
+					),
 				),
 				True(),
 			),
@@ -178,6 +244,61 @@ func TestRuleUnmarshalling(t *testing.T) {
 		require.Equal(t, r1, &r2)
 	})
 
+	t.Run("Computed result", func(t *testing.T) {
+		var rule Rule
+
+		err := rule.UnmarshalJSON([]byte(`{
+			"expr": {"kind": "value", "type": "bool", "data": "true"},
+			"result": {
+				"kind": "mul",
+				"operands": [
+					{"kind": "param", "type": "float64", "name": "base"},
+					{"kind": "value", "type": "float64", "data": "1.5"}
+				]
+			}
+		}`))
+		require.NoError(t, err)
+		require.Equal(t, Mul(Float64Param("base"), &Value{Kind: "value", Type: "float64", Data: "1.5"}), rule.Result)
+	})
+
+	t.Run("Metadata", func(t *testing.T) {
+		enabled := false
+		until := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+		r1 := New(True(), StringValue("ok"))
+		r1.Priority = 10
+		r1.ID = "sale"
+		r1.Name = "Sale"
+		r1.Description = "Winter sale"
+		r1.Enabled = &enabled
+		r1.ValidUntil = &until
+
+		raw, err := json.Marshal(r1)
+		require.NoError(t, err)
+		require.Contains(t, string(raw), `"priority":10`)
+		require.Contains(t, string(raw), `"valid-until":"2027-01-01T00:00:00Z"`)
+
+		var r2 Rule
+		err = json.Unmarshal(raw, &r2)
+		require.NoError(t, err)
+		require.Equal(t, r1, &r2)
+	})
+
+	t.Run("Invalid version", func(t *testing.T) {
+		var rule Rule
+
+		err := rule.UnmarshalJSON([]byte(`{
+			"expr": {
+				"kind": "gte",
+				"operands": [
+					{"kind": "param", "type": "version", "name": "app-version"},
+					{"kind": "value", "type": "version", "data": "5.x"}
+				]
+			},
+			"result": {"kind": "value", "type": "bool", "data": "true"}
+		}`))
+		require.EqualError(t, err, `/expr/operands/1: invalid version value "5.x"`)
+	})
+
 	t.Run("Missing result type", func(t *testing.T) {
 		var rule Rule
 
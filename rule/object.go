@@ -0,0 +1,101 @@
+package rule
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ObjectValue creates an object value from the given fields, which must be strings, bools or numbers.
+// The object is stored as a JSON object.
+func ObjectValue(fields map[string]interface{}) *Value {
+	if fields == nil {
+		fields = map[string]interface{}{}
+	}
+
+	data, _ := json.Marshal(fields)
+	return newValue("object", string(data))
+}
+
+// DecodeObject decodes the fields of an object value. Numbers are decoded as json.Number
+// to preserve the precision of int64 fields.
+func DecodeObject(v *Value) (map[string]interface{}, error) {
+	if v.Type != "object" {
+		return nil, fmt.Errorf("expected object value, got %s", v.Type)
+	}
+
+	return decodeObject(v.Data)
+}
+
+func decodeObject(data string) (map[string]interface{}, error) {
+	var fields map[string]interface{}
+
+	dec := json.NewDecoder(bytes.NewReader([]byte(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return nil, err
+	}
+	if fields == nil || dec.More() {
+		return nil, errors.New("invalid object")
+	}
+
+	for name, f := range fields {
+		switch f.(type) {
+		case string, bool, json.Number:
+		default:
+			return nil, fmt.Errorf("invalid field %q: fields must be strings, bools or numbers", name)
+		}
+	}
+
+	return fields, nil
+}
+
+// objectEqual reports whether two objects have the same fields, regardless of their order.
+func objectEqual(a, b string) bool {
+	f1, err := decodeObject(a)
+	if err != nil {
+		return false
+	}
+	f2, err := decodeObject(b)
+	if err != nil {
+		return false
+	}
+
+	if len(f1) != len(f2) {
+		return false
+	}
+
+	for name, v1 := range f1 {
+		v2, ok := f2[name]
+		if !ok {
+			return false
+		}
+
+		n1, ok1 := v1.(json.Number)
+		n2, ok2 := v2.(json.Number)
+		if ok1 && ok2 {
+			i, err1 := n1.Int64()
+			j, err2 := n2.Int64()
+			if err1 == nil && err2 == nil {
+				if i != j {
+					return false
+				}
+				continue
+			}
+
+			x, err1 := n1.Float64()
+			y, err2 := n2.Float64()
+			if err1 != nil || err2 != nil || x != y {
+				return false
+			}
+			continue
+		}
+
+		if v1 != v2 {
+			return false
+		}
+	}
+
+	return true
+}
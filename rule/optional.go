@@ -0,0 +1,92 @@
+package rule
+
+import (
+	"errors"
+)
+
+// Optional marks the given param as optional and returns it.
+// Optional params can be omitted during evaluation: the rules using a missing optional param
+// don't match instead of failing with ErrParamNotFound, unless the param is guarded by Has or Coalesce.
+func Optional(p *Param) *Param {
+	p.Optional = true
+	return p
+}
+
+// isMissingParam reports whether err was returned because a param is not defined.
+func isMissingParam(err error) bool {
+	return err == ErrParamNotFound || err == ErrOptionalParamNotFound
+}
+
+type exprHas struct {
+	operator
+}
+
+// Has creates an expression that evaluates to true if the given param is defined.
+// It returns an error if the param is defined but its value doesn't match the type of the param.
+func Has(p *Param) Expr {
+	return &exprHas{
+		operator: operator{
+			kind:     "has",
+			operands: []Expr{p},
+		},
+	}
+}
+
+func (n *exprHas) validate() error {
+	if len(n.operands) == 1 {
+		if _, ok := n.operands[0].(*Param); ok {
+			return nil
+		}
+	}
+
+	return errors.New("the operand of the Has func must be a param")
+}
+
+func (n *exprHas) Eval(params Params) (*Value, error) {
+	if len(n.operands) != 1 {
+		return nil, errors.New("invalid number of operands in Has func")
+	}
+
+	_, err := n.operands[0].Eval(params)
+	if isMissingParam(err) {
+		return BoolValue(false), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return BoolValue(true), nil
+}
+
+type exprCoalesce struct {
+	operator
+}
+
+// Coalesce creates an expression that takes at least two operands and returns the value of the first one
+// which doesn't use a missing param. It is intended to provide default values to optional params,
+// like in Coalesce(Optional(Int64Param("age")), Int64Value(18)).
+// All the operands must evaluate to the same type.
+func Coalesce(v1, v2 Expr, vN ...Expr) Expr {
+	return &exprCoalesce{
+		operator: operator{
+			kind:     "coalesce",
+			operands: append([]Expr{v1, v2}, vN...),
+		},
+	}
+}
+
+func (n *exprCoalesce) Eval(params Params) (*Value, error) {
+	if len(n.operands) < 2 {
+		return nil, errors.New("invalid number of operands in Coalesce func")
+	}
+
+	last := len(n.operands) - 1
+	for _, op := range n.operands[:last] {
+		v, err := op.Eval(params)
+		if !isMissingParam(err) {
+			return v, err
+		}
+	}
+
+	return n.operands[last].Eval(params)
+}
@@ -0,0 +1,1308 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+	"unicode"
+)
+
+// A ParseError describes a syntax error encountered while parsing a rule.
+// Line and Column are 1-based and point to the offending token.
+type ParseError struct {
+	Line   int
+	Column int
+	Msg    string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Parse parses a single expression written in the rule syntax and returns the corresponding expression tree.
+//
+//	city == "paris" and driver-status in ("gold", "silver")
+//
+// Bare identifiers are string params, other types are selected using the name:type notation (e.g. score:int64).
+// Optional params are followed by a question mark (e.g. promo? or age:int64?).
+// Params named after keywords, or with names that aren't identifiers, are enclosed in backquotes (e.g. `in`:bool).
+// Any operator can also be written as a call using its kind, e.g. percentile(user-id, 50).
+func Parse(src string) (Expr, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.skipNewlines(); err != nil {
+		return nil, err
+	}
+
+	e, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.skipNewlines(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf(p.tok, "unexpected %s", p.tok)
+	}
+
+	return e, nil
+}
+
+// ParseRule parses a rule written in the rule syntax.
+// A rule is made of an expression followed by an arrow and its result,
+// optionally followed by the priority keyword and the priority of the rule.
+// It can be preceded by attributes, one per line, setting the metadata of the rule.
+//
+//	city == "paris" -> base:float64 * 1.5
+//	city == "lyon" -> 2.0 priority 10
+//
+//	@id "winter-sale"
+//	@name "Winter sale"
+//	@description "Discount applied during the winter sale"
+//	@enabled false
+//	@valid-from "2026-12-01T00:00:00Z"
+//	@valid-until "2027-01-01T00:00:00Z"
+//	true -> 0.8
+func ParseRule(src string) (*Rule, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.skipNewlines(); err != nil {
+		return nil, err
+	}
+
+	r, err := p.parseRule()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = p.skipNewlines(); err != nil {
+		return nil, err
+	}
+
+	if p.tok.kind != tokEOF {
+		return nil, p.errorf(p.tok, "unexpected %s", p.tok)
+	}
+
+	return r, nil
+}
+
+// ParseRules parses a list of rules written in the rule syntax, one rule per line.
+// Rules can span multiple lines as long as the line breaks are enclosed in parentheses.
+// Comments start with a # and end with the line.
+func ParseRules(src string) ([]*Rule, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules []*Rule
+
+	for {
+		if err = p.skipNewlines(); err != nil {
+			return nil, err
+		}
+		if p.tok.kind == tokEOF {
+			break
+		}
+
+		r, err := p.parseRule()
+		if err != nil {
+			return nil, err
+		}
+
+		if p.tok.kind != tokNewline && p.tok.kind != tokEOF {
+			return nil, p.errorf(p.tok, "unexpected %s, expected end of line", p.tok)
+		}
+
+		rules = append(rules, r)
+	}
+
+	return rules, nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNewline
+	tokIdent
+	tokString
+	tokInt
+	tokFloat
+	tokLParen
+	tokRParen
+	tokComma
+	tokColon
+	tokQuestion
+	tokAt
+	tokArrow
+	tokEq
+	tokNeq
+	tokGT
+	tokGTE
+	tokLT
+	tokLTE
+	tokPlus
+	tokMinus
+	tokStar
+	tokSlash
+	tokPercent
+)
+
+var tokenNames = map[tokenKind]string{
+	tokEOF:      "end of input",
+	tokNewline:  "end of line",
+	tokIdent:    "identifier",
+	tokString:   "string",
+	tokInt:      "integer",
+	tokLParen:   "'('",
+	tokRParen:   "')'",
+	tokComma:    "','",
+	tokColon:    "':'",
+	tokQuestion: "'?'",
+	tokAt:       "'@'",
+	tokArrow:    "'->'",
+	tokEq:       "'=='",
+	tokNeq:      "'!='",
+	tokGT:       "'>'",
+	tokGTE:      "'>='",
+	tokLT:       "'<'",
+	tokLTE:      "'<='",
+	tokPlus:     "'+'",
+	tokMinus:    "'-'",
+	tokStar:     "'*'",
+	tokSlash:    "'/'",
+	tokPercent:  "'%'",
+}
+
+type lexeme struct {
+	kind tokenKind
+	text string
+	line int
+	col  int
+	// adjacent reports whether the token immediately follows the previous one, without any space.
+	adjacent bool
+	// quoted reports whether the identifier is enclosed in backquotes, in which case it is always a param name.
+	quoted bool
+}
+
+func (t lexeme) String() string {
+	switch t.kind {
+	case tokIdent, tokInt, tokFloat, tokString:
+		return fmt.Sprintf("'%s'", t.text)
+	}
+
+	return tokenNames[t.kind]
+}
+
+// lexer splits the source into tokens.
+type lexer struct {
+	src   []rune
+	pos   int
+	line  int
+	col   int
+	depth int // parenthesis depth, line breaks are ignored inside parentheses.
+	last  tokenKind
+}
+
+func (l *lexer) peek(n int) rune {
+	if l.pos+n >= len(l.src) {
+		return 0
+	}
+	return l.src[l.pos+n]
+}
+
+func (l *lexer) advance() rune {
+	r := l.src[l.pos]
+	l.pos++
+	if r == '\n' {
+		l.line++
+		l.col = 1
+	} else {
+		l.col++
+	}
+	return r
+}
+
+func (l *lexer) errorf(line, col int, format string, args ...interface{}) error {
+	return &ParseError{Line: line, Column: col, Msg: fmt.Sprintf(format, args...)}
+}
+
+// next returns the next token of the source.
+func (l *lexer) next() (lexeme, error) {
+	adjacent := true
+
+loop:
+	for l.pos < len(l.src) {
+		r := l.peek(0)
+		switch {
+		case r == '#':
+			for l.pos < len(l.src) && l.peek(0) != '\n' {
+				l.advance()
+			}
+		case r == '\n' && l.depth == 0:
+			break loop
+		case unicode.IsSpace(r):
+			l.advance()
+		default:
+			break loop
+		}
+		adjacent = false
+	}
+
+	tok := lexeme{line: l.line, col: l.col, adjacent: adjacent}
+
+	if l.pos >= len(l.src) {
+		tok.kind = tokEOF
+		return tok, nil
+	}
+
+	r := l.peek(0)
+	switch {
+	case r == '\n':
+		l.advance()
+		tok.kind = tokNewline
+	case r == '"':
+		s, err := l.scanString()
+		if err != nil {
+			return tok, err
+		}
+		tok.kind = tokString
+		tok.text = s
+	case isDigit(r) || (r == '-' && isDigit(l.peek(1)) && !l.afterOperand()):
+		tok.kind, tok.text = l.scanNumber()
+	case isLetter(r):
+		tok.kind = tokIdent
+		tok.text = l.scanIdent()
+	case r == '`':
+		s, err := l.scanQuotedIdent()
+		if err != nil {
+			return tok, err
+		}
+		tok.kind = tokIdent
+		tok.text = s
+		tok.quoted = true
+	default:
+		kind, n := l.scanPunct()
+		if n == 0 {
+			return tok, l.errorf(tok.line, tok.col, "unexpected character %q", r)
+		}
+		for i := 0; i < n; i++ {
+			l.advance()
+		}
+		tok.kind = kind
+		switch kind {
+		case tokLParen:
+			l.depth++
+		case tokRParen:
+			if l.depth > 0 {
+				l.depth--
+			}
+		}
+	}
+
+	l.last = tok.kind
+	return tok, nil
+}
+
+// afterOperand reports whether the last token ends an operand.
+// It is used to distinguish negative numbers from the minus operator.
+func (l *lexer) afterOperand() bool {
+	switch l.last {
+	case tokIdent, tokString, tokInt, tokFloat, tokRParen:
+		return true
+	}
+
+	return false
+}
+
+func (l *lexer) scanString() (string, error) {
+	line, col := l.line, l.col
+	start := l.pos
+	l.advance()
+
+	for {
+		if l.pos >= len(l.src) || l.peek(0) == '\n' {
+			return "", l.errorf(line, col, "unterminated string")
+		}
+
+		r := l.advance()
+		if r == '\\' && l.pos < len(l.src) {
+			l.advance()
+			continue
+		}
+		if r == '"' {
+			break
+		}
+	}
+
+	s, err := strconv.Unquote(string(l.src[start:l.pos]))
+	if err != nil {
+		return "", l.errorf(line, col, "invalid string literal")
+	}
+
+	return s, nil
+}
+
+func (l *lexer) scanNumber() (tokenKind, string) {
+	start := l.pos
+	kind := tokInt
+
+	if l.peek(0) == '-' {
+		l.advance()
+	}
+
+	for isDigit(l.peek(0)) {
+		l.advance()
+	}
+
+	if l.peek(0) == '.' && isDigit(l.peek(1)) {
+		kind = tokFloat
+		l.advance()
+		for isDigit(l.peek(0)) {
+			l.advance()
+		}
+	}
+
+	if r := l.peek(0); r == 'e' || r == 'E' {
+		n := 1
+		if s := l.peek(1); s == '+' || s == '-' {
+			n++
+		}
+		if isDigit(l.peek(n)) {
+			kind = tokFloat
+			for i := 0; i < n; i++ {
+				l.advance()
+			}
+			for isDigit(l.peek(0)) {
+				l.advance()
+			}
+		}
+	}
+
+	return kind, string(l.src[start:l.pos])
+}
+
+// scanIdent scans identifiers. Dashes are allowed within identifiers
+// as long as they are followed by a letter or a digit.
+func (l *lexer) scanIdent() string {
+	start := l.pos
+
+	for {
+		r := l.peek(0)
+		if isLetter(r) || isDigit(r) {
+			l.advance()
+			continue
+		}
+
+		if r == '-' && (isLetter(l.peek(1)) || isDigit(l.peek(1))) {
+			l.advance()
+			continue
+		}
+
+		break
+	}
+
+	return string(l.src[start:l.pos])
+}
+
+// scanQuotedIdent scans identifiers enclosed in backquotes, which can hold any character
+// but backquotes and line breaks.
+func (l *lexer) scanQuotedIdent() (string, error) {
+	line, col := l.line, l.col
+	l.advance()
+	start := l.pos
+
+	for l.peek(0) != '`' {
+		if l.pos >= len(l.src) || l.peek(0) == '\n' {
+			return "", l.errorf(line, col, "unterminated identifier")
+		}
+		l.advance()
+	}
+
+	s := string(l.src[start:l.pos])
+	l.advance()
+	if s == "" {
+		return "", l.errorf(line, col, "empty identifier")
+	}
+
+	return s, nil
+}
+
+func (l *lexer) scanPunct() (tokenKind, int) {
+	switch l.peek(0) {
+	case '(':
+		return tokLParen, 1
+	case ')':
+		return tokRParen, 1
+	case ',':
+		return tokComma, 1
+	case ':':
+		return tokColon, 1
+	case '?':
+		return tokQuestion, 1
+	case '@':
+		return tokAt, 1
+	case '-':
+		if l.peek(1) == '>' {
+			return tokArrow, 2
+		}
+		return tokMinus, 1
+	case '+':
+		return tokPlus, 1
+	case '*':
+		return tokStar, 1
+	case '/':
+		return tokSlash, 1
+	case '%':
+		return tokPercent, 1
+	case '=':
+		if l.peek(1) == '=' {
+			return tokEq, 2
+		}
+	case '!':
+		if l.peek(1) == '=' {
+			return tokNeq, 2
+		}
+	case '>':
+		if l.peek(1) == '=' {
+			return tokGTE, 2
+		}
+		return tokGT, 1
+	case '<':
+		if l.peek(1) == '=' {
+			return tokLTE, 2
+		}
+		return tokLT, 1
+	}
+
+	return 0, 0
+}
+
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
+func isLetter(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// parser builds expression trees from the tokens returned by the lexer.
+type parser struct {
+	lex *lexer
+	tok lexeme
+}
+
+func newParser(src string) (*parser, error) {
+	p := parser{
+		lex: &lexer{src: []rune(src), line: 1, col: 1},
+	}
+
+	err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+func (p *parser) next() error {
+	tok, err := p.lex.next()
+	if err != nil {
+		return err
+	}
+
+	p.tok = tok
+	return nil
+}
+
+func (p *parser) errorf(tok lexeme, format string, args ...interface{}) error {
+	return &ParseError{Line: tok.line, Column: tok.col, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) skipNewlines() error {
+	for p.tok.kind == tokNewline {
+		if err := p.next(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *parser) expect(kind tokenKind) (lexeme, error) {
+	tok := p.tok
+	if tok.kind != kind {
+		return tok, p.errorf(tok, "unexpected %s, expected %s", tok, tokenNames[kind])
+	}
+
+	return tok, p.next()
+}
+
+func (p *parser) isKeyword(word string) bool {
+	return p.tok.kind == tokIdent && !p.tok.quoted && p.tok.text == word
+}
+
+func (p *parser) parseRule() (*Rule, error) {
+	var r Rule
+	for p.tok.kind == tokAt {
+		if err := p.parseAttribute(&r); err != nil {
+			return nil, err
+		}
+	}
+
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = p.expect(tokArrow); err != nil {
+		return nil, err
+	}
+
+	res, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+
+	r.Expr, r.Result = expr, res
+	if p.isKeyword("priority") {
+		r.Priority, err = p.parsePriority()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &r, nil
+}
+
+// parseAttribute parses an attribute of the rule, made of its name and its value, and the line break that follows it.
+func (p *parser) parseAttribute(r *Rule) error {
+	if err := p.next(); err != nil {
+		return err
+	}
+
+	name, err := p.expect(tokIdent)
+	if err != nil {
+		return err
+	}
+
+	switch name.text {
+	case "id", "name", "description":
+		tok, err := p.expect(tokString)
+		if err != nil {
+			return err
+		}
+
+		switch name.text {
+		case "id":
+			r.ID = tok.text
+		case "name":
+			r.Name = tok.text
+		default:
+			r.Description = tok.text
+		}
+	case "enabled":
+		if !p.isKeyword("true") && !p.isKeyword("false") {
+			return p.errorf(p.tok, "unexpected %s, expected true or false", p.tok)
+		}
+
+		enabled := p.tok.text == "true"
+		r.Enabled = &enabled
+		if err := p.next(); err != nil {
+			return err
+		}
+	case "valid-from", "valid-until":
+		tok, err := p.expect(tokString)
+		if err != nil {
+			return err
+		}
+
+		t, err := time.Parse(time.RFC3339Nano, tok.text)
+		if err != nil {
+			return p.errorf(tok, "invalid time %q, expected RFC 3339 format", tok.text)
+		}
+
+		if name.text == "valid-from" {
+			r.ValidFrom = &t
+		} else {
+			r.ValidUntil = &t
+		}
+	default:
+		return p.errorf(name, "unknown attribute %s", name.text)
+	}
+
+	if _, err := p.expect(tokNewline); err != nil {
+		return err
+	}
+
+	return p.skipNewlines()
+}
+
+func (p *parser) parsePriority() (int64, error) {
+	if err := p.next(); err != nil {
+		return 0, err
+	}
+
+	sign := ""
+	if p.tok.kind == tokMinus {
+		sign = "-"
+		if err := p.next(); err != nil {
+			return 0, err
+		}
+	}
+
+	tok, err := p.expect(tokInt)
+	if err != nil {
+		return 0, err
+	}
+
+	i, err := strconv.ParseInt(sign+tok.text, 10, 64)
+	if err != nil {
+		return 0, p.errorf(tok, "invalid priority %s", sign+tok.text)
+	}
+
+	return i, nil
+}
+
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	ops, err := p.parseList("or", p.parseAnd)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ops) == 1 {
+		return ops[0], nil
+	}
+
+	return Or(ops[0], ops[1], ops[2:]...), nil
+}
+
+func (p *parser) parseAnd() (Expr, error) {
+	ops, err := p.parseList("and", p.parseNot)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(ops) == 1 {
+		return ops[0], nil
+	}
+
+	return And(ops[0], ops[1], ops[2:]...), nil
+}
+
+// parseList parses one or more operands separated by the given keyword.
+func (p *parser) parseList(keyword string, parseFn func() (Expr, error)) ([]Expr, error) {
+	e, err := parseFn()
+	if err != nil {
+		return nil, err
+	}
+
+	ops := []Expr{e}
+	for p.isKeyword(keyword) {
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+
+		e, err = parseFn()
+		if err != nil {
+			return nil, err
+		}
+
+		ops = append(ops, e)
+	}
+
+	return ops, nil
+}
+
+func (p *parser) parseNot() (Expr, error) {
+	if !p.isKeyword("not") {
+		return p.parseComparison()
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	e, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+
+	return Not(e), nil
+}
+
+func (p *parser) parseComparison() (Expr, error) {
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	var e Expr
+
+	op := p.tok
+	switch {
+	case p.isKeyword("in"):
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		if _, err = p.expect(tokLParen); err != nil {
+			return nil, err
+		}
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		if len(args) == 0 {
+			return nil, p.errorf(op, "in requires at least one value")
+		}
+		e = In(left, args[0], args[1:]...)
+	case op.kind >= tokEq && op.kind <= tokLTE:
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		e = newComparison(op.kind, left, right)
+	default:
+		return left, nil
+	}
+
+	if p.isKeyword("in") || (p.tok.kind >= tokEq && p.tok.kind <= tokLTE) {
+		return nil, p.errorf(p.tok, "comparison operators cannot be chained")
+	}
+
+	return e, nil
+}
+
+func newComparison(kind tokenKind, left, right Expr) Expr {
+	switch kind {
+	case tokNeq:
+		return Not(Eq(left, right))
+	case tokGT:
+		return GT(left, right)
+	case tokGTE:
+		return GTE(left, right)
+	case tokLT:
+		return LT(left, right)
+	case tokLTE:
+		return LTE(left, right)
+	}
+
+	return Eq(left, right)
+}
+
+func (p *parser) parseOperand() (Expr, error) {
+	return p.parseArithmetic(p.parseTerm, tokPlus, tokMinus)
+}
+
+func (p *parser) parseTerm() (Expr, error) {
+	return p.parseArithmetic(p.parsePrimary, tokStar, tokSlash, tokPercent)
+}
+
+// parseArithmetic parses left associative arithmetic operators.
+// Successive uses of the same operator are merged into a single expression, except for the modulo.
+func (p *parser) parseArithmetic(parseFn func() (Expr, error), kinds ...tokenKind) (Expr, error) {
+	left, err := parseFn()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op := p.tok.kind
+
+		found := false
+		for _, k := range kinds {
+			found = found || k == op
+		}
+		if !found {
+			return left, nil
+		}
+
+		ops := []Expr{left}
+		for p.tok.kind == op {
+			if err = p.next(); err != nil {
+				return nil, err
+			}
+
+			right, err := parseFn()
+			if err != nil {
+				return nil, err
+			}
+			ops = append(ops, right)
+
+			if op == tokPercent {
+				break
+			}
+		}
+
+		left = newArithmetic(op, ops)
+	}
+}
+
+func newArithmetic(kind tokenKind, ops []Expr) Expr {
+	switch kind {
+	case tokPlus:
+		return Add(ops[0], ops[1], ops[2:]...)
+	case tokMinus:
+		return Sub(ops[0], ops[1], ops[2:]...)
+	case tokStar:
+		return Mul(ops[0], ops[1], ops[2:]...)
+	case tokSlash:
+		return Div(ops[0], ops[1], ops[2:]...)
+	}
+
+	return Mod(ops[0], ops[1])
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.tok
+
+	switch tok.kind {
+	case tokString:
+		return StringValue(tok.text), p.next()
+	case tokInt:
+		i, err := strconv.ParseInt(tok.text, 10, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid integer %s", tok.text)
+		}
+		return Int64Value(i), p.next()
+	case tokFloat:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %s", tok.text)
+		}
+		return Float64Value(f), p.next()
+	case tokLParen:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.expect(tokRParen)
+		return e, err
+	case tokIdent:
+		return p.parseIdent()
+	}
+
+	return nil, p.errorf(tok, "unexpected %s", tok)
+}
+
+func (p *parser) parseIdent() (Expr, error) {
+	tok := p.tok
+
+	switch {
+	case tok.quoted:
+	case tok.text == "true":
+		return BoolValue(true), p.next()
+	case tok.text == "false":
+		return BoolValue(false), p.next()
+	case tok.text == "and", tok.text == "or", tok.text == "not", tok.text == "in":
+		return nil, p.errorf(tok, "unexpected keyword %s", tok)
+	}
+
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case p.tok.kind == tokLParen && p.tok.adjacent && !tok.quoted:
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, err
+		}
+		return p.newCall(tok, args)
+	}
+
+	prm := StringParam(tok.text)
+	if p.tok.kind == tokColon && p.tok.adjacent {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		typ, err := p.expect(tokIdent)
+		if err != nil {
+			return nil, err
+		}
+		if prm, err = p.newParam(tok, typ); err != nil {
+			return nil, err
+		}
+	}
+
+	// optional params are followed by a question mark
+	if p.tok.kind == tokQuestion && p.tok.adjacent {
+		prm.Optional = true
+		return prm, p.next()
+	}
+
+	return prm, nil
+}
+
+// parseArgs parses a comma separated list of expressions, up to the closing parenthesis.
+func (p *parser) parseArgs() ([]Expr, error) {
+	var args []Expr
+
+	if p.tok.kind == tokRParen {
+		return args, p.next()
+	}
+
+	for {
+		e, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, e)
+
+		if p.tok.kind != tokComma {
+			break
+		}
+		if err = p.next(); err != nil {
+			return nil, err
+		}
+	}
+
+	_, err := p.expect(tokRParen)
+	return args, err
+}
+
+func (p *parser) newParam(name, typ lexeme) (*Param, error) {
+	switch typ.text {
+	case "string":
+		return StringParam(name.text), nil
+	case "bool":
+		return BoolParam(name.text), nil
+	case "int64":
+		return Int64Param(name.text), nil
+	case "float64":
+		return Float64Param(name.text), nil
+	case "time":
+		return TimeParam(name.text), nil
+	case "duration":
+		return DurationParam(name.text), nil
+	case "string-list":
+		return StringListParam(name.text), nil
+	case "int64-list":
+		return Int64ListParam(name.text), nil
+	case "float64-list":
+		return Float64ListParam(name.text), nil
+	case "version":
+		return VersionParam(name.text), nil
+	case "point":
+		return PointParam(name.text), nil
+	}
+
+	return nil, p.errorf(typ, "unknown param type %s", typ)
+}
+
+// newCall creates the operator corresponding to the given function name.
+func (p *parser) newCall(fn lexeme, args []Expr) (Expr, error) {
+	arity := func(min, max int) error {
+		if len(args) < min || (max >= 0 && len(args) > max) {
+			return p.errorf(fn, "invalid number of arguments for %s", fn.text)
+		}
+		return nil
+	}
+
+	switch fn.text {
+	case "list":
+		if err := arity(1, -1); err != nil {
+			return nil, err
+		}
+	case "eq", "gt", "gte", "lt", "lte", "add", "sub", "mul", "div", "min", "max", "concat", "coalesce":
+		if err := arity(2, -1); err != nil {
+			return nil, err
+		}
+	case "now":
+		if err := arity(0, 0); err != nil {
+			return nil, err
+		}
+	case "fnv", "abs", "lower", "upper", "length", "time", "duration", "version", "object", "size", "has":
+		if err := arity(1, 1); err != nil {
+			return nil, err
+		}
+	case "percentile", "mod", "contains", "prefix", "suffix", "matches",
+		"before", "after", "hour-of-day", "day-of-week", "intersects", "point", "distance", "in-polygon":
+		if err := arity(2, 2); err != nil {
+			return nil, err
+		}
+	case "polygon":
+		if err := arity(3, -1); err != nil {
+			return nil, err
+		}
+	case "variant":
+		if err := arity(4, -1); err != nil {
+			return nil, err
+		}
+	case "any", "all", "within-radius":
+		if err := arity(3, 3); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.errorf(fn, "unknown function %s", fn.text)
+	}
+
+	switch fn.text {
+	case "eq":
+		return Eq(args[0], args[1], args[2:]...), nil
+	case "gt":
+		return GT(args[0], args[1], args[2:]...), nil
+	case "gte":
+		return GTE(args[0], args[1], args[2:]...), nil
+	case "lt":
+		return LT(args[0], args[1], args[2:]...), nil
+	case "lte":
+		return LTE(args[0], args[1], args[2:]...), nil
+	case "fnv":
+		return FNV(args[0]), nil
+	case "add":
+		return Add(args[0], args[1], args[2:]...), nil
+	case "sub":
+		return Sub(args[0], args[1], args[2:]...), nil
+	case "mul":
+		return Mul(args[0], args[1], args[2:]...), nil
+	case "div":
+		return Div(args[0], args[1], args[2:]...), nil
+	case "mod":
+		return Mod(args[0], args[1]), nil
+	case "min":
+		return Min(args[0], args[1], args[2:]...), nil
+	case "max":
+		return Max(args[0], args[1], args[2:]...), nil
+	case "abs":
+		return Abs(args[0]), nil
+	case "contains":
+		return Contains(args[0], args[1]), nil
+	case "prefix":
+		return Prefix(args[0], args[1]), nil
+	case "suffix":
+		return Suffix(args[0], args[1]), nil
+	case "matches":
+		v, ok := args[1].(*Value)
+		if !ok || v.Type != "string" {
+			return nil, p.errorf(fn, "the pattern of %s must be a string", fn.text)
+		}
+		m := Matches(args[0], v.Data)
+		if err := Validate(m); err != nil {
+			return nil, p.errorf(fn, "invalid pattern: %v", err)
+		}
+		return m, nil
+	case "variant":
+		// the variants and their weights are written as a flat list of values
+		v := exprVariant{operator: operator{kind: "variant", operands: args}}
+		v.compile()
+		if v.err != nil {
+			return nil, p.errorf(fn, "%v", v.err)
+		}
+		return &v, nil
+	case "lower":
+		return Lower(args[0]), nil
+	case "upper":
+		return Upper(args[0]), nil
+	case "length":
+		return Length(args[0]), nil
+	case "concat":
+		return Concat(args[0], args[1], args[2:]...), nil
+	case "time", "duration", "version", "object":
+		return p.newLiteral(fn, args[0])
+	case "now":
+		return Now(), nil
+	case "before":
+		return Before(args[0], args[1]), nil
+	case "after":
+		return After(args[0], args[1]), nil
+	case "hour-of-day":
+		return p.checkTimeZone(fn, HourOfDay(args[0], args[1]))
+	case "day-of-week":
+		return p.checkTimeZone(fn, DayOfWeek(args[0], args[1]))
+	case "list":
+		return p.newList(fn, args)
+	case "intersects":
+		return Intersects(args[0], args[1]), nil
+	case "size":
+		return Size(args[0]), nil
+	case "any", "all":
+		var name string
+		switch t := args[1].(type) {
+		case *Param:
+			name = t.Name
+		case *Value:
+			if t.Type == "string" {
+				name = t.Data
+			}
+		}
+		if name == "" {
+			return nil, p.errorf(fn, "the second argument of %s must be the name of the element", fn.text)
+		}
+		if fn.text == "any" {
+			return Any(args[0], name, args[2]), nil
+		}
+		return All(args[0], name, args[2]), nil
+	case "point":
+		return p.newPoint(fn, args)
+	case "polygon":
+		return p.newPolygon(fn, args)
+	case "distance":
+		return Distance(args[0], args[1]), nil
+	case "within-radius":
+		return WithinRadius(args[0], args[1], args[2]), nil
+	case "in-polygon":
+		return InPolygon(args[0], args[1]), nil
+	case "has":
+		prm, ok := args[0].(*Param)
+		if !ok {
+			return nil, p.errorf(fn, "the argument of has must be a param")
+		}
+		return Has(prm), nil
+	case "coalesce":
+		return Coalesce(args[0], args[1], args[2:]...), nil
+	}
+
+	return Percentile(args[0], args[1]), nil
+}
+
+// newLiteral creates the time, duration or version value described by the given string.
+func (p *parser) newLiteral(fn lexeme, arg Expr) (Expr, error) {
+	v, ok := arg.(*Value)
+	if !ok || v.Type != "string" {
+		return nil, p.errorf(fn, "the argument of %s must be a string", fn.text)
+	}
+
+	switch fn.text {
+	case "duration":
+		d, err := time.ParseDuration(v.Data)
+		if err != nil {
+			return nil, p.errorf(fn, "invalid duration %q", v.Data)
+		}
+		return DurationValue(d), nil
+	case "version":
+		if _, err := ParseVersion(v.Data); err != nil {
+			return nil, p.errorf(fn, "invalid version %q", v.Data)
+		}
+		return VersionValue(v.Data), nil
+	case "object":
+		if _, err := decodeObject(v.Data); err != nil {
+			return nil, p.errorf(fn, "invalid object %q", v.Data)
+		}
+		return newValue("object", v.Data), nil
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, v.Data)
+	if err != nil {
+		return nil, p.errorf(fn, "invalid time %q, expected RFC 3339 format", v.Data)
+	}
+	return TimeValue(t), nil
+}
+
+// newList creates a list value from the given values, which must all be of the same type.
+func (p *parser) newList(fn lexeme, args []Expr) (Expr, error) {
+	var typ string
+	for _, arg := range args {
+		v, ok := arg.(*Value)
+		if !ok || (typ != "" && v.Type != typ) {
+			return nil, p.errorf(fn, "the elements of a list must be values of the same type")
+		}
+		typ = v.Type
+	}
+
+	switch typ {
+	case "string":
+		l := make([]string, len(args))
+		for i, arg := range args {
+			l[i] = arg.(*Value).Data
+		}
+		return StringListValue(l...), nil
+	case "int64":
+		l := make([]int64, len(args))
+		for i, arg := range args {
+			l[i], _ = strconv.ParseInt(arg.(*Value).Data, 10, 64)
+		}
+		return Int64ListValue(l...), nil
+	case "float64":
+		l := make([]float64, len(args))
+		for i, arg := range args {
+			l[i], _ = strconv.ParseFloat(arg.(*Value).Data, 64)
+		}
+		return Float64ListValue(l...), nil
+	}
+
+	return nil, p.errorf(fn, "unsupported list of %s", typ)
+}
+
+// newPoint creates a point value from its latitude and longitude, which must be numbers.
+func (p *parser) newPoint(fn lexeme, args []Expr) (Expr, error) {
+	var c [2]float64
+	for i, arg := range args {
+		v, ok := arg.(*Value)
+		if !ok || (v.Type != "int64" && v.Type != "float64") {
+			return nil, p.errorf(fn, "the coordinates of a point must be numbers")
+		}
+		c[i], _ = strconv.ParseFloat(v.Data, 64)
+	}
+
+	pt := Point{Lat: c[0], Lng: c[1]}
+	if err := pt.validate(); err != nil {
+		return nil, p.errorf(fn, "%v", err)
+	}
+
+	return PointValue(pt), nil
+}
+
+// newPolygon creates a polygon value from its vertices, which must be point values.
+func (p *parser) newPolygon(fn lexeme, args []Expr) (Expr, error) {
+	points := make([]Point, len(args))
+	for i, arg := range args {
+		v, ok := arg.(*Value)
+		if !ok || v.Type != "point" {
+			return nil, p.errorf(fn, "the vertices of a polygon must be point values")
+		}
+		points[i], _ = decodePoint(v.Data)
+	}
+
+	return PolygonValue(points...), nil
+}
+
+// checkTimeZone reports an error if the time zone passed to fn is an unknown location.
+func (p *parser) checkTimeZone(fn lexeme, e Expr) (Expr, error) {
+	if err := Validate(e); err != nil {
+		return nil, p.errorf(fn, "invalid time zone: %v", err)
+	}
+
+	return e, nil
+}
+
+// isIdent reports whether s can be written as a bare identifier.
+func isIdent(s string) bool {
+	if s == "" {
+		return false
+	}
+
+	switch s {
+	case "true", "false", "and", "or", "not", "in":
+		return false
+	}
+
+	l := lexer{src: []rune(s), line: 1, col: 1}
+	if !isLetter(l.peek(0)) {
+		return false
+	}
+
+	return l.scanIdent() == s
+}
@@ -0,0 +1,360 @@
+package rule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			src      string
+			expected rule.Expr
+		}{
+			{`"foo"`, rule.StringValue("foo")},
+			{`10`, rule.Int64Value(10)},
+			{`-10`, rule.Int64Value(-10)},
+			{`1.5`, rule.Float64Value(1.5)},
+			{`1e3`, rule.Float64Value(1000)},
+			{`true`, rule.BoolValue(true)},
+			{`city`, rule.StringParam("city")},
+			{`driver-status`, rule.StringParam("driver-status")},
+			{`score:int64`, rule.Int64Param("score")},
+			{`ratio:float64`, rule.Float64Param("ratio")},
+			{`vip:bool`, rule.BoolParam("vip")},
+			{`created-at:time`, rule.TimeParam("created-at")},
+			{`wait:duration`, rule.DurationParam("wait")},
+			{`tags:string-list`, rule.StringListParam("tags")},
+			{`ids:int64-list`, rule.Int64ListParam("ids")},
+			{`ratios:float64-list`, rule.Float64ListParam("ratios")},
+			{`list("a", "b")`, rule.StringListValue("a", "b")},
+			{`list(1, -2)`, rule.Int64ListValue(1, -2)},
+			{`list(1.5)`, rule.Float64ListValue(1.5)},
+			{`time("2026-12-31T00:00:00+01:00")`, rule.TimeValue(time.Date(2026, 12, 30, 23, 0, 0, 0, time.UTC))},
+			{`duration("1h30m")`, rule.DurationValue(90 * time.Minute)},
+			{
+				`variant("checkout", user-id, "control", 50, "a", 25, "b", 25) == "a"`,
+				rule.Eq(
+					rule.Variant("checkout", rule.StringParam("user-id"),
+						rule.VariantWeight{Name: "control", Weight: 50},
+						rule.VariantWeight{Name: "a", Weight: 25},
+						rule.VariantWeight{Name: "b", Weight: 25},
+					),
+					rule.StringValue("a"),
+				),
+			},
+			{`promo?`, rule.Optional(rule.StringParam("promo"))},
+			{"`and`:bool", rule.BoolParam("and")},
+			{"`in`? == `true`", rule.Eq(rule.Optional(rule.StringParam("in")), rule.StringParam("true"))},
+			{"`now`", rule.StringParam("now")},
+			{`age:int64? > 18`, rule.GT(rule.Optional(rule.Int64Param("age")), rule.Int64Value(18))},
+			{
+				`has(promo?) or coalesce(age:int64?, 18) > 21`,
+				rule.Or(
+					rule.Has(rule.Optional(rule.StringParam("promo"))),
+					rule.GT(rule.Coalesce(rule.Optional(rule.Int64Param("age")), rule.Int64Value(18)), rule.Int64Value(21)),
+				),
+			},
+			{`app-version:version`, rule.VersionParam("app-version")},
+			{`version("5.12")`, rule.VersionValue("5.12")},
+			{`object("{\"radius\": 3.5}")`, &rule.Value{Kind: "value", Type: "object", Data: `{"radius": 3.5}`}},
+			{
+				`app-version:version >= version("5.10.0") and app-version:version < version("6")`,
+				rule.And(
+					rule.GTE(rule.VersionParam("app-version"), rule.VersionValue("5.10.0")),
+					rule.LT(rule.VersionParam("app-version"), rule.VersionValue("6")),
+				),
+			},
+			{`pickup:point`, rule.PointParam("pickup")},
+			{`point(48.8566, -2)`, rule.PointValue(rule.Point{Lat: 48.8566, Lng: -2})},
+			{
+				`polygon(point(1, 1), point(1.5, 2), point(0, 2))`,
+				rule.PolygonValue(rule.Point{Lat: 1, Lng: 1}, rule.Point{Lat: 1.5, Lng: 2}, rule.Point{Lat: 0, Lng: 2}),
+			},
+			{
+				`distance(pickup:point, point(48.8566, 2.3522)) < 5.0 or within-radius(pickup:point, airport:point, 2.5)`,
+				rule.Or(
+					rule.LT(rule.Distance(rule.PointParam("pickup"), rule.PointValue(rule.Point{Lat: 48.8566, Lng: 2.3522})), rule.Float64Value(5)),
+					rule.WithinRadius(rule.PointParam("pickup"), rule.PointParam("airport"), rule.Float64Value(2.5)),
+				),
+			},
+			{
+				`in-polygon(pickup:point, polygon(point(0, 0), point(0, 1), point(1, 1)))`,
+				rule.InPolygon(rule.PointParam("pickup"), rule.PolygonValue(rule.Point{}, rule.Point{Lng: 1}, rule.Point{Lat: 1, Lng: 1})),
+			},
+			{`city == "paris"`, rule.Eq(rule.StringParam("city"), rule.StringValue("paris"))},
+			{`city != "paris"`, rule.Not(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")))},
+			{`score:int64 > 10`, rule.GT(rule.Int64Param("score"), rule.Int64Value(10))},
+			{`score:int64 >= -10`, rule.GTE(rule.Int64Param("score"), rule.Int64Value(-10))},
+			{`score:int64 < 10`, rule.LT(rule.Int64Param("score"), rule.Int64Value(10))},
+			{`score:int64 <= 10`, rule.LTE(rule.Int64Param("score"), rule.Int64Value(10))},
+			{`city in ("a")`, rule.In(rule.StringParam("city"), rule.StringValue("a"))},
+			{
+				`city == "paris" and driver-status in ("gold","silver")`,
+				rule.And(
+					rule.Eq(rule.StringParam("city"), rule.StringValue("paris")),
+					rule.In(rule.StringParam("driver-status"), rule.StringValue("gold"), rule.StringValue("silver")),
+				),
+			},
+			{
+				`a:bool or b:bool and not c:bool`,
+				rule.Or(rule.BoolParam("a"), rule.And(rule.BoolParam("b"), rule.Not(rule.BoolParam("c")))),
+			},
+			{
+				`a:bool and b:bool and c:bool`,
+				rule.And(rule.BoolParam("a"), rule.BoolParam("b"), rule.BoolParam("c")),
+			},
+			{
+				`(a:bool or b:bool) and c:bool`,
+				rule.And(rule.Or(rule.BoolParam("a"), rule.BoolParam("b")), rule.BoolParam("c")),
+			},
+			{
+				`percentile(user-id, 50)`,
+				rule.Percentile(rule.StringParam("user-id"), rule.Int64Value(50)),
+			},
+			{
+				`eq(fnv("a"), 1, 2)`,
+				rule.Eq(rule.FNV(rule.StringValue("a")), rule.Int64Value(1), rule.Int64Value(2)),
+			},
+			{
+				`base:float64 * 1.5 + 1.0 > 3.0`,
+				rule.GT(rule.Add(rule.Mul(rule.Float64Param("base"), rule.Float64Value(1.5)), rule.Float64Value(1)), rule.Float64Value(3)),
+			},
+			{
+				`a:int64 - 1 - -2 + 3`,
+				rule.Add(rule.Sub(rule.Int64Param("a"), rule.Int64Value(1), rule.Int64Value(-2)), rule.Int64Value(3)),
+			},
+			{
+				`a:int64 * (b:int64 + 1) % 3 / 2`,
+				rule.Div(rule.Mod(rule.Mul(rule.Int64Param("a"), rule.Add(rule.Int64Param("b"), rule.Int64Value(1))), rule.Int64Value(3)), rule.Int64Value(2)),
+			},
+			{
+				`max(abs(a:int64), min(1, 2), 3)`,
+				rule.Max(rule.Abs(rule.Int64Param("a")), rule.Min(rule.Int64Value(1), rule.Int64Value(2)), rule.Int64Value(3)),
+			},
+			{
+				`suffix(lower(email), "@heetch.com") or matches(promo, "^SUMMER")`,
+				rule.Or(
+					rule.Suffix(rule.Lower(rule.StringParam("email")), rule.StringValue("@heetch.com")),
+					rule.Matches(rule.StringParam("promo"), "^SUMMER"),
+				),
+			},
+			{
+				`before(now(), time("2026-12-31T00:00:00Z")) and hour-of-day(now(), "Europe/Paris") >= 22`,
+				rule.And(
+					rule.Before(rule.Now(), rule.TimeValue(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC))),
+					rule.GTE(rule.HourOfDay(rule.Now(), rule.StringValue("Europe/Paris")), rule.Int64Value(22)),
+				),
+			},
+			{
+				`day-of-week(at:time, tz) in (0, 6) or after(at:time, now())`,
+				rule.Or(
+					rule.In(rule.DayOfWeek(rule.TimeParam("at"), rule.StringParam("tz")), rule.Int64Value(0), rule.Int64Value(6)),
+					rule.After(rule.TimeParam("at"), rule.Now()),
+				),
+			},
+			{
+				`contains(tags:string-list, "vip") or intersects(categories:string-list, list("berline", "van"))`,
+				rule.Or(
+					rule.Contains(rule.StringListParam("tags"), rule.StringValue("vip")),
+					rule.Intersects(rule.StringListParam("categories"), rule.StringListValue("berline", "van")),
+				),
+			},
+			{
+				`size(ids:int64-list) > 2 and all(ids:int64-list, id, id:int64 > 0) and any(tags:string-list, "tag", prefix(tag, "vip"))`,
+				rule.And(
+					rule.GT(rule.Size(rule.Int64ListParam("ids")), rule.Int64Value(2)),
+					rule.All(rule.Int64ListParam("ids"), "id", rule.GT(rule.Int64Param("id"), rule.Int64Value(0))),
+					rule.Any(rule.StringListParam("tags"), "tag", rule.Prefix(rule.StringParam("tag"), rule.StringValue("vip"))),
+				),
+			},
+			{
+				"# leading comment\n(a:bool\n  and b:bool) # trailing comment\n",
+				rule.And(rule.BoolParam("a"), rule.BoolParam("b")),
+			},
+		}
+
+		for _, test := range tests {
+			e, err := rule.Parse(test.src)
+			require.NoError(t, err, test.src)
+			require.Equal(t, test.expected, e, test.src)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			src       string
+			line, col int
+		}{
+			{``, 1, 1},
+			{`city ==`, 1, 8},
+			{`city == "paris`, 1, 9},
+			{`a == b == c`, 1, 8},
+			{`score:int32`, 1, 7},
+			{`unknown(a)`, 1, 1},
+			{`fnv(a, b)`, 1, 1},
+			{`a and`, 1, 6},
+			{"a == b\nand c", 2, 1},
+			{"(a ==\n  b ==\n c)", 2, 5},
+			{`a @ b`, 1, 3},
+			{`(a`, 1, 3},
+			{`a in ()`, 1, 3},
+			{`matches(a, b)`, 1, 1},
+			{`matches(a, "a(")`, 1, 1},
+			{`now(a)`, 1, 1},
+			{`time("2026-12-31")`, 1, 1},
+			{`duration(a)`, 1, 1},
+			{`version("5.x")`, 1, 1},
+			{`object("[1]")`, 1, 1},
+			{`object("{\"a\": [1]}")`, 1, 1},
+			{`has("promo")`, 1, 1},
+			{`coalesce(age:int64?)`, 1, 1},
+			{`promo ?`, 1, 7},
+			{"`promo", 1, 1},
+			{"``", 1, 1},
+			{"`now`()", 1, 6},
+			{`?`, 1, 1},
+			{`variant("checkout", user-id, "a")`, 1, 1},
+			{`variant("checkout", user-id, "a", 1, "b")`, 1, 1},
+			{`variant(salt, user-id, "a", 1)`, 1, 1},
+			{`variant("checkout", user-id, "a", 1.5)`, 1, 1},
+			{`variant("checkout", user-id, "a", 0, "b", 0)`, 1, 1},
+			{`hour-of-day(now(), "Mars/Olympus")`, 1, 1},
+			{`list(1, "a")`, 1, 1},
+			{`list(a)`, 1, 1},
+			{`list(true)`, 1, 1},
+			{`any(a:string-list, 1, true)`, 1, 1},
+			{`point(1)`, 1, 1},
+			{`point("a", 1)`, 1, 1},
+			{`point(91, 0)`, 1, 1},
+			{`polygon(point(0, 0), point(1, 1))`, 1, 1},
+			{`polygon(point(0, 0), point(1, 1), a:point)`, 1, 1},
+		}
+
+		for _, test := range tests {
+			_, err := rule.Parse(test.src)
+			require.Error(t, err, test.src)
+			perr, ok := err.(*rule.ParseError)
+			require.True(t, ok, test.src)
+			require.Equal(t, test.line, perr.Line, test.src)
+			require.Equal(t, test.col, perr.Column, test.src)
+		}
+	})
+}
+
+func TestParseRule(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		r, err := rule.ParseRule(`city == "paris" -> 3.0`)
+		require.NoError(t, err)
+		require.Equal(t, rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.Float64Value(3)), r)
+	})
+
+	t.Run("Computed result", func(t *testing.T) {
+		r, err := rule.ParseRule(`surge:bool -> base:float64 * 1.5`)
+		require.NoError(t, err)
+		require.Equal(t, rule.New(rule.BoolParam("surge"), rule.Mul(rule.Float64Param("base"), rule.Float64Value(1.5))), r)
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		r, err := rule.ParseRule(`city == "paris" -> 3.0 priority 10`)
+		require.NoError(t, err)
+		require.Equal(t, int64(10), r.Priority)
+
+		r, err = rule.ParseRule(`true -> priority priority -2`)
+		require.NoError(t, err)
+		require.Equal(t, rule.StringParam("priority"), r.Result)
+		require.Equal(t, int64(-2), r.Priority)
+
+		_, err = rule.ParseRule(`true -> 3.0 priority high`)
+		require.EqualError(t, err, "1:22: unexpected 'high', expected integer")
+	})
+
+	t.Run("Attributes", func(t *testing.T) {
+		r, err := rule.ParseRule(`
+			@id "winter-sale"
+			@name "Winter sale"
+			@description "Discount applied during the winter sale"
+			@enabled false
+			@valid-from "2026-12-01T00:00:00Z"
+			# the sale ends at midnight
+			@valid-until "2027-01-01T00:00:00Z"
+
+			true -> 0.8
+		`)
+		require.NoError(t, err)
+
+		enabled := false
+		from := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+		until := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+		require.Equal(t, &rule.Rule{
+			Expr:        rule.True(),
+			Result:      rule.Float64Value(0.8),
+			ID:          "winter-sale",
+			Name:        "Winter sale",
+			Description: "Discount applied during the winter sale",
+			Enabled:     &enabled,
+			ValidFrom:   &from,
+			ValidUntil:  &until,
+		}, r)
+
+		tests := []struct {
+			src     string
+			line    int
+			col     int
+			message string
+		}{
+			{`@owner "me"` + "\ntrue -> 1", 1, 2, "unknown attribute owner"},
+			{`@id winter` + "\ntrue -> 1", 1, 5, "unexpected 'winter', expected string"},
+			{`@enabled "no"` + "\ntrue -> 1", 1, 10, "unexpected 'no', expected true or false"},
+			{`@valid-from "2026-12-01"` + "\ntrue -> 1", 1, 13, `invalid time "2026-12-01", expected RFC 3339 format`},
+			{`@id "a" true -> 1`, 1, 9, "unexpected 'true', expected end of line"},
+		}
+
+		for _, test := range tests {
+			_, err := rule.ParseRule(test.src)
+			require.Equal(t, &rule.ParseError{Line: test.line, Column: test.col, Msg: test.message}, err, test.src)
+		}
+	})
+
+	t.Run("Missing arrow", func(t *testing.T) {
+		_, err := rule.ParseRule(`true 3.0`)
+		require.Error(t, err)
+		require.EqualError(t, err, "1:6: unexpected '3.0', expected '->'")
+	})
+}
+
+func TestParseRules(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		rules, err := rule.ParseRules(`
+			# surge
+			city == "paris" -> 3.0
+			(city == "lyon"
+				and score:int64 > 10) -> 2.0
+
+			true -> 1.0
+		`)
+		require.NoError(t, err)
+		require.Equal(t, []*rule.Rule{
+			rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.Float64Value(3)),
+			rule.New(rule.And(
+				rule.Eq(rule.StringParam("city"), rule.StringValue("lyon")),
+				rule.GT(rule.Int64Param("score"), rule.Int64Value(10)),
+			), rule.Float64Value(2)),
+			rule.New(rule.True(), rule.Float64Value(1)),
+		}, rules)
+	})
+
+	t.Run("Empty", func(t *testing.T) {
+		rules, err := rule.ParseRules("\n# nothing\n")
+		require.NoError(t, err)
+		require.Empty(t, rules)
+	})
+
+	t.Run("Two rules on the same line", func(t *testing.T) {
+		_, err := rule.ParseRules(`true -> 1.0 true -> 2.0`)
+		require.EqualError(t, err, "1:13: unexpected 'true', expected end of line")
+	})
+}
@@ -0,0 +1,328 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format returns the representation of the given expression in the rule syntax.
+// The output can be parsed back using Parse.
+func Format(e Expr) (string, error) {
+	var pr printer
+
+	err := pr.print(e, precLowest)
+	if err != nil {
+		return "", err
+	}
+
+	return pr.String(), nil
+}
+
+// FormatRule returns the representation of the given rule in the rule syntax.
+// The metadata of the rule are written as attributes, on the lines preceding the rule.
+// The output can be parsed back using ParseRule.
+func FormatRule(r *Rule) (string, error) {
+	var pr printer
+
+	if r.ID != "" {
+		pr.WriteString("@id " + strconv.Quote(r.ID) + "\n")
+	}
+	if r.Name != "" {
+		pr.WriteString("@name " + strconv.Quote(r.Name) + "\n")
+	}
+	if r.Description != "" {
+		pr.WriteString("@description " + strconv.Quote(r.Description) + "\n")
+	}
+	if r.Enabled != nil {
+		pr.WriteString("@enabled " + strconv.FormatBool(*r.Enabled) + "\n")
+	}
+	if r.ValidFrom != nil {
+		pr.WriteString("@valid-from " + strconv.Quote(r.ValidFrom.Format(time.RFC3339Nano)) + "\n")
+	}
+	if r.ValidUntil != nil {
+		pr.WriteString("@valid-until " + strconv.Quote(r.ValidUntil.Format(time.RFC3339Nano)) + "\n")
+	}
+
+	err := pr.print(r.Expr, precLowest)
+	if err != nil {
+		return "", err
+	}
+
+	pr.WriteString(" -> ")
+
+	err = pr.print(r.Result, precLowest)
+	if err != nil {
+		return "", err
+	}
+
+	if r.Priority != 0 {
+		pr.WriteString(" priority " + strconv.FormatInt(r.Priority, 10))
+	}
+
+	return pr.String(), nil
+}
+
+// precedence of the operators written using infix or prefix notation.
+const (
+	precLowest = iota
+	precOr
+	precAnd
+	precNot
+	precComparison
+	precAdditive
+	precMultiplicative
+	precPrimary
+)
+
+var comparisonOperators = map[string]string{
+	"eq":  "==",
+	"gt":  ">",
+	"gte": ">=",
+	"lt":  "<",
+	"lte": "<=",
+}
+
+var arithmeticOperators = map[string]string{
+	"add": "+",
+	"sub": "-",
+	"mul": "*",
+	"div": "/",
+	"mod": "%",
+}
+
+type printer struct {
+	strings.Builder
+}
+
+// print writes e to the buffer, surrounded by parentheses if e binds less tightly than prec.
+func (p *printer) print(e Expr, prec int) error {
+	switch t := e.(type) {
+	case *Value:
+		return p.printValue(t)
+	case *Param:
+		return p.printParam(t)
+	case operatorExpr:
+		return p.printOperator(t.node(), prec)
+	}
+
+	return fmt.Errorf("cannot format expression of type %T", e)
+}
+
+func (p *printer) printValue(v *Value) error {
+	switch v.Type {
+	case "string":
+		p.WriteString(strconv.Quote(v.Data))
+	case "bool", "int64":
+		p.WriteString(v.Data)
+	case "float64":
+		f, err := strconv.ParseFloat(v.Data, 64)
+		if err != nil {
+			return err
+		}
+
+		s := strconv.FormatFloat(f, 'g', -1, 64)
+		if strings.ContainsAny(s, "IN") {
+			return fmt.Errorf("cannot format float64 value %s", v.Data)
+		}
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		p.WriteString(s)
+	case "time", "duration", "version", "object":
+		p.WriteString(v.Type + "(" + strconv.Quote(v.Data) + ")")
+	case "point":
+		pt, err := decodePoint(v.Data)
+		if err != nil {
+			return err
+		}
+		return p.printPoint(pt)
+	case "polygon":
+		points, err := decodePolygon(v.Data)
+		if err != nil {
+			return err
+		}
+
+		p.WriteString("polygon(")
+		for i, pt := range points {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			if err := p.printPoint(pt); err != nil {
+				return err
+			}
+		}
+		p.WriteByte(')')
+	case "string-list", "int64-list", "float64-list":
+		items, err := listItems("Format", v)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return fmt.Errorf("cannot format empty %s value", v.Type)
+		}
+
+		p.WriteString("list(")
+		for i, item := range items {
+			if i > 0 {
+				p.WriteString(", ")
+			}
+			if err := p.printValue(item); err != nil {
+				return err
+			}
+		}
+		p.WriteByte(')')
+	default:
+		return fmt.Errorf("cannot format value of type %s", v.Type)
+	}
+
+	return nil
+}
+
+func (p *printer) printPoint(pt Point) error {
+	p.WriteString("point(")
+	if err := p.printValue(Float64Value(pt.Lat)); err != nil {
+		return err
+	}
+	p.WriteString(", ")
+	if err := p.printValue(Float64Value(pt.Lng)); err != nil {
+		return err
+	}
+	p.WriteByte(')')
+
+	return nil
+}
+
+func (p *printer) printParam(prm *Param) error {
+	switch {
+	case isIdent(prm.Name):
+		p.WriteString(prm.Name)
+	case prm.Name != "" && !strings.ContainsAny(prm.Name, "`\n"):
+		// keywords and names that aren't identifiers are enclosed in backquotes
+		p.WriteString("`" + prm.Name + "`")
+	default:
+		return fmt.Errorf("cannot format param name %q", prm.Name)
+	}
+
+	if prm.Type != "string" {
+		p.WriteString(":" + prm.Type)
+	}
+	if prm.Optional {
+		p.WriteByte('?')
+	}
+
+	return nil
+}
+
+func (p *printer) printOperator(o *operator, prec int) error {
+	ops := o.operands
+
+	switch o.kind {
+	case "and", "or", "in":
+		if len(ops) < 2 {
+			return fmt.Errorf("invalid number of operands for %s", o.kind)
+		}
+	case "not":
+		if len(ops) != 1 {
+			return fmt.Errorf("invalid number of operands for %s", o.kind)
+		}
+	}
+
+	switch o.kind {
+	case "and", "or":
+		opPrec := precAnd
+		if o.kind == "or" {
+			opPrec = precOr
+		}
+		return p.printInfix(o.kind, ops, opPrec, prec)
+	case "not":
+		// Not(Eq(a, b)) is written a != b
+		if eq, ok := ops[0].(*exprEq); ok && len(eq.operands) == 2 {
+			return p.printInfix("!=", eq.operands, precComparison, prec)
+		}
+		return p.parens(precNot, prec, func() error {
+			p.WriteString("not ")
+			return p.print(ops[0], precNot)
+		})
+	case "in":
+		return p.parens(precComparison, prec, func() error {
+			err := p.print(ops[0], precComparison+1)
+			if err != nil {
+				return err
+			}
+			p.WriteString(" in ")
+			return p.printArgs(ops[1:])
+		})
+	case "eq", "gt", "gte", "lt", "lte":
+		if len(ops) != 2 {
+			break
+		}
+		return p.printInfix(comparisonOperators[o.kind], ops, precComparison, prec)
+	case "add", "sub":
+		if len(ops) < 2 {
+			break
+		}
+		return p.printInfix(arithmeticOperators[o.kind], ops, precAdditive, prec)
+	case "mul", "div", "mod":
+		if len(ops) < 2 || (o.kind == "mod" && len(ops) != 2) {
+			break
+		}
+		return p.printInfix(arithmeticOperators[o.kind], ops, precMultiplicative, prec)
+	case "any", "all":
+		// the name of the element is written as a bare identifier when possible.
+		if len(ops) != 3 {
+			break
+		}
+		if v, ok := ops[1].(*Value); ok && v.Type == "string" && isIdent(v.Data) {
+			ops = []Expr{ops[0], StringParam(v.Data), ops[2]}
+		}
+	}
+
+	p.WriteString(o.kind)
+	return p.printArgs(ops)
+}
+
+// printInfix writes the operands separated by the given operator.
+// Operands binding as tightly as the operator are surrounded by parentheses to preserve the tree structure.
+func (p *printer) printInfix(op string, ops []Expr, opPrec, prec int) error {
+	return p.parens(opPrec, prec, func() error {
+		for i, e := range ops {
+			if i > 0 {
+				p.WriteString(" " + op + " ")
+			}
+			err := p.print(e, opPrec+1)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (p *printer) printArgs(ops []Expr) error {
+	p.WriteByte('(')
+	for i, e := range ops {
+		if i > 0 {
+			p.WriteString(", ")
+		}
+		err := p.print(e, precLowest)
+		if err != nil {
+			return err
+		}
+	}
+	p.WriteByte(')')
+
+	return nil
+}
+
+func (p *printer) parens(opPrec, prec int, fn func() error) error {
+	if opPrec >= prec {
+		return fn()
+	}
+
+	p.WriteByte('(')
+	err := fn()
+	p.WriteByte(')')
+	return err
+}
@@ -0,0 +1,175 @@
+package rule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			expr     rule.Expr
+			expected string
+		}{
+			{rule.StringValue("a \"quoted\"\nstring"), `"a \"quoted\"\nstring"`},
+			{rule.Int64Value(-10), `-10`},
+			{rule.Float64Value(3), `3.0`},
+			{rule.Float64Value(0.25), `0.25`},
+			{rule.BoolValue(false), `false`},
+			{rule.StringParam("city"), `city`},
+			{rule.Int64Param("score"), `score:int64`},
+			{
+				rule.And(
+					rule.Eq(rule.StringParam("city"), rule.StringValue("paris")),
+					rule.In(rule.StringParam("driver-status"), rule.StringValue("gold"), rule.StringValue("silver")),
+				),
+				`city == "paris" and driver-status in ("gold", "silver")`,
+			},
+			{rule.Not(rule.Eq(rule.StringParam("a"), rule.StringValue("b"))), `a != "b"`},
+			{rule.Not(rule.And(rule.BoolParam("a"), rule.BoolParam("b"))), `not (a:bool and b:bool)`},
+			{rule.And(rule.Or(rule.BoolParam("a"), rule.BoolParam("b")), rule.BoolParam("c")), `(a:bool or b:bool) and c:bool`},
+			{rule.And(rule.BoolParam("a"), rule.And(rule.BoolParam("b"), rule.BoolParam("c"))), `a:bool and (b:bool and c:bool)`},
+			{rule.Eq(rule.Eq(rule.BoolParam("a"), rule.BoolValue(true)), rule.BoolValue(false)), `(a:bool == true) == false`},
+			{rule.GT(rule.Int64Param("a"), rule.Int64Value(1), rule.Int64Value(2)), `gt(a:int64, 1, 2)`},
+			{rule.Percentile(rule.StringParam("id"), rule.Int64Value(50)), `percentile(id, 50)`},
+			{rule.Mul(rule.Add(rule.Int64Param("a"), rule.Int64Value(1)), rule.Int64Value(2)), `(a:int64 + 1) * 2`},
+			{rule.Add(rule.Int64Param("a"), rule.Mul(rule.Int64Value(1), rule.Int64Value(2))), `a:int64 + 1 * 2`},
+			{rule.Sub(rule.Int64Param("a"), rule.Int64Value(-1)), `a:int64 - -1`},
+			{rule.TimeValue(time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC)), `time("2026-12-31T00:00:00Z")`},
+			{rule.DurationValue(90 * time.Minute), `duration("1h30m0s")`},
+			{rule.Before(rule.Now(), rule.TimeParam("end")), `before(now(), end:time)`},
+			{rule.StringListValue("a", "b"), `list("a", "b")`},
+			{rule.Float64ListValue(1, 2.5), `list(1.0, 2.5)`},
+			{
+				rule.Any(rule.StringListParam("tags"), "tag", rule.Prefix(rule.StringParam("tag"), rule.StringValue("vip"))),
+				`any(tags:string-list, tag, prefix(tag, "vip"))`,
+			},
+			{rule.All(rule.Int64ListParam("ids"), "not", rule.True()), `all(ids:int64-list, "not", true)`},
+			{rule.PointValue(rule.Point{Lat: 48.8566, Lng: -2}), `point(48.8566, -2.0)`},
+			{rule.GTE(rule.VersionParam("app-version"), rule.VersionValue("5.10.0")), `app-version:version >= version("5.10.0")`},
+			{rule.ObjectValue(map[string]interface{}{"radius": 3.5, "vip": true}), `object("{\"radius\":3.5,\"vip\":true}")`},
+			{
+				rule.InPolygon(rule.PointParam("pickup"), rule.PolygonValue(rule.Point{}, rule.Point{Lng: 1}, rule.Point{Lat: 1, Lng: 1})),
+				`in-polygon(pickup:point, polygon(point(0.0, 0.0), point(0.0, 1.0), point(1.0, 1.0)))`,
+			},
+		}
+
+		for _, test := range tests {
+			s, err := rule.Format(test.expr)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, s)
+		}
+	})
+
+	t.Run("Round trip", func(t *testing.T) {
+		exprs := []rule.Expr{
+			rule.Float64Value(1e21),
+			rule.Float64Value(-1.5e-7),
+			rule.Or(
+				rule.Not(rule.Not(rule.BoolParam("a"))),
+				rule.Eq(rule.StringValue("foo"), rule.StringParam("bar"), rule.StringParam("baz")),
+				rule.In(rule.Int64Param("score"), rule.Int64Value(-1), rule.Int64Value(20)),
+				rule.Not(rule.GT(rule.Float64Param("ratio"), rule.Float64Value(0.5))),
+				rule.LTE(rule.FNV(rule.StringParam("id")), rule.Int64Value(10)),
+				rule.And(rule.True(), rule.Percentile(rule.StringParam("id"), rule.Int64Value(96))),
+				rule.GT(
+					rule.Sub(rule.Int64Param("a"), rule.Sub(rule.Int64Value(-1), rule.Int64Param("b"))),
+					rule.Mod(rule.Mod(rule.Int64Value(7), rule.Int64Value(3)), rule.Abs(rule.Int64Value(-2))),
+				),
+				rule.LT(
+					rule.Div(rule.Add(rule.Float64Value(1), rule.Float64Value(2)), rule.Float64Value(4), rule.Float64Value(2)),
+					rule.Max(rule.Float64Value(1), rule.Float64Value(2), rule.Min(rule.Float64Value(0), rule.Float64Value(1))),
+				),
+				rule.Contains(rule.Upper(rule.Concat(rule.StringParam("a"), rule.StringValue("-"))), rule.StringValue("B")),
+				rule.And(rule.Prefix(rule.StringParam("a"), rule.StringValue("x")), rule.Suffix(rule.StringParam("a"), rule.StringValue("y"))),
+				rule.Matches(rule.Lower(rule.StringParam("email")), `@heetch\.com$`),
+				rule.GTE(rule.Length(rule.StringParam("name")), rule.Int64Value(3)),
+				rule.After(rule.TimeParam("at"), rule.TimeValue(time.Date(2026, 1, 2, 3, 4, 5, 6, time.UTC))),
+				rule.LT(rule.DayOfWeek(rule.Now(), rule.StringValue("UTC")), rule.HourOfDay(rule.Now(), rule.StringParam("tz"))),
+				rule.GT(rule.DurationParam("wait"), rule.DurationValue(-time.Second)),
+				rule.Contains(rule.Int64ListValue(-1, 2), rule.Int64Param("id")),
+				rule.Intersects(rule.StringListParam("tags"), rule.StringListValue("a", "b\"c")),
+				rule.All(rule.Float64ListParam("r"), "x", rule.Any(rule.Int64ListValue(1), "y", rule.LT(rule.Size(rule.Int64ListParam("ids")), rule.Int64Param("y")))),
+				rule.LT(rule.Distance(rule.PointParam("a"), rule.PointValue(rule.Point{Lat: -33.8688, Lng: 151.2093})), rule.Float64Value(10)),
+				rule.WithinRadius(rule.PointParam("a"), rule.PointParam("b"), rule.Float64Param("r")),
+				rule.In(rule.VersionParam("v"), rule.VersionValue("1.0.0-rc.1+build"), rule.VersionValue("2")),
+				rule.And(rule.Has(rule.Optional(rule.StringParam("p"))), rule.LT(rule.Coalesce(rule.Optional(rule.Float64Param("f")), rule.Float64Value(1)), rule.Float64Value(2))),
+				rule.Eq(rule.Variant("exp", rule.Int64Param("id"), rule.VariantWeight{Name: "a", Weight: 1}, rule.VariantWeight{Name: "b", Weight: 0}), rule.StringValue("b")),
+				rule.And(rule.BoolParam("and"), rule.Not(rule.BoolParam("not")), rule.Has(rule.Optional(rule.StringParam("in"))), rule.Eq(rule.StringParam("true"), rule.StringParam("a b"))),
+			),
+		}
+
+		for _, e := range exprs {
+			s, err := rule.Format(e)
+			require.NoError(t, err)
+
+			e2, err := rule.Parse(s)
+			require.NoError(t, err, s)
+			require.Equal(t, e, e2, s)
+		}
+	})
+
+	t.Run("Empty list", func(t *testing.T) {
+		_, err := rule.Format(rule.StringListValue())
+		require.Error(t, err)
+	})
+
+	t.Run("Invalid param name", func(t *testing.T) {
+		_, err := rule.Format(rule.StringParam("a`b"))
+		require.Error(t, err)
+
+		_, err = rule.Format(rule.StringParam(""))
+		require.Error(t, err)
+	})
+
+	t.Run("Unknown expression", func(t *testing.T) {
+		_, err := rule.Format(new(mockExpr))
+		require.Error(t, err)
+	})
+}
+
+func TestFormatRule(t *testing.T) {
+	r := rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.Float64Value(3))
+
+	s, err := rule.FormatRule(r)
+	require.NoError(t, err)
+	require.Equal(t, `city == "paris" -> 3.0`, s)
+
+	r2, err := rule.ParseRule(s)
+	require.NoError(t, err)
+	require.Equal(t, r, r2)
+
+	r.Priority = -5
+	s, err = rule.FormatRule(r)
+	require.NoError(t, err)
+	require.Equal(t, `city == "paris" -> 3.0 priority -5`, s)
+
+	r2, err = rule.ParseRule(s)
+	require.NoError(t, err)
+	require.Equal(t, r, r2)
+
+	enabled := true
+	from := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	r.ID = "paris"
+	r.Name = "Paris"
+	r.Description = `The "city of light"`
+	r.Enabled = &enabled
+	r.ValidFrom = &from
+	r.ValidUntil = &from
+	s, err = rule.FormatRule(r)
+	require.NoError(t, err)
+	require.Equal(t, `@id "paris"
+@name "Paris"
+@description "The \"city of light\""
+@enabled true
+@valid-from "2026-12-01T00:00:00Z"
+@valid-until "2026-12-01T00:00:00Z"
+city == "paris" -> 3.0 priority -5`, s)
+
+	r2, err = rule.ParseRule(s)
+	require.NoError(t, err)
+	require.Equal(t, r, r2)
+}
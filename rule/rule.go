@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"strconv"
+	"time"
 
 	"github.com/tidwall/gjson"
 )
@@ -18,21 +19,48 @@ var (
 	// ErrParamNotFound is returned when a parameter is not defined.
 	ErrParamNotFound = errors.New("parameter not found")
 
+	// ErrOptionalParamNotFound is returned when an optional parameter is not defined.
+	// Rules using it don't match instead of failing.
+	ErrOptionalParamNotFound = errors.New("optional parameter not found")
+
 	// ErrNoMatch is returned when the rule doesn't match the given params.
 	ErrNoMatch = errors.New("rule doesn't match the given params")
 
 	// ErrRulesetIncoherentType is returned when a ruleset contains rules of different types.
 	ErrRulesetIncoherentType = errors.New("types in ruleset are incoherent")
+
+	// ErrDivisionByZero is returned when an arithmetic expression divides by zero.
+	ErrDivisionByZero = errors.New("division by zero")
+
+	// ErrIntegerOverflow is returned when the result of an arithmetic expression doesn't fit in an int64.
+	ErrIntegerOverflow = errors.New("integer overflow")
 )
 
 // A Rule represents a logical boolean expression that evaluates to a result.
+// The result can be a value or any expression, it is evaluated only when the rule matches.
 type Rule struct {
-	Expr   Expr   `json:"expr"`
-	Result *Value `json:"result"`
+	Expr   Expr `json:"expr" yaml:"expr"`
+	Result Expr `json:"result" yaml:"result"`
+
+	// Priority is used by rulesets evaluated with the priority strategy:
+	// rules with a higher priority are evaluated first.
+	Priority int64 `json:"priority,omitempty" yaml:"priority,omitempty"`
+
+	// ID identifies the rule within its ruleset. It must be unique and should be kept across versions.
+	ID string `json:"id,omitempty" yaml:"id,omitempty"`
+	// Name and Description document the rule.
+	Name        string `json:"name,omitempty" yaml:"name,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	// Enabled can be set to false to disable the rule. Rules are enabled by default.
+	Enabled *bool `json:"enabled,omitempty" yaml:"enabled,omitempty"`
+	// ValidFrom and ValidUntil restrict the evaluation of the rule to a time window.
+	// ValidFrom is included and ValidUntil excluded.
+	ValidFrom  *time.Time `json:"valid-from,omitempty" yaml:"valid-from,omitempty"`
+	ValidUntil *time.Time `json:"valid-until,omitempty" yaml:"valid-until,omitempty"`
 }
 
 // New creates a rule with the given expression and that returns the given result on evaluation.
-func New(expr Expr, result *Value) *Rule {
+func New(expr Expr, result Expr) *Rule {
 	return &Rule{
 		Expr:   expr,
 		Result: result,
@@ -42,8 +70,15 @@ func New(expr Expr, result *Value) *Rule {
 // UnmarshalJSON implements the json.Unmarshaler interface.
 func (r *Rule) UnmarshalJSON(data []byte) error {
 	tree := struct {
-		Expr   json.RawMessage
-		Result *Value
+		Expr        json.RawMessage
+		Result      json.RawMessage
+		Priority    int64
+		ID          string
+		Name        string
+		Description string
+		Enabled     *bool
+		ValidFrom   *time.Time `json:"valid-from"`
+		ValidUntil  *time.Time `json:"valid-until"`
 	}{}
 
 	err := json.Unmarshal(data, &tree)
@@ -51,10 +86,6 @@ func (r *Rule) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	if tree.Result.Type == "" {
-		return errors.New("invalid rule result type")
-	}
-
 	res := gjson.Get(string(tree.Expr), "kind")
 	n, err := unmarshalExpr(res.Str, []byte(tree.Expr))
 	if err != nil {
@@ -62,14 +93,71 @@ func (r *Rule) UnmarshalJSON(data []byte) error {
 	}
 
 	r.Expr = n
-	r.Result = tree.Result
+	r.Priority = tree.Priority
+	r.ID = tree.ID
+	r.Name = tree.Name
+	r.Description = tree.Description
+	r.Enabled = tree.Enabled
+	r.ValidFrom = tree.ValidFrom
+	r.ValidUntil = tree.ValidUntil
+	r.Result, err = unmarshalResult(tree.Result)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.TypeCheck()
 	return err
 }
 
+// unmarshalResult decodes the result of a rule.
+// Results stored before the introduction of computed results are values without a kind.
+func unmarshalResult(data []byte) (Expr, error) {
+	kind := gjson.GetBytes(data, "kind").Str
+	if kind != "" && kind != "value" {
+		return unmarshalExpr(kind, data)
+	}
+
+	var v Value
+	err := json.Unmarshal(data, &v)
+	if err != nil {
+		return nil, err
+	}
+
+	if v.Type == "" {
+		return nil, errors.New("invalid rule result type")
+	}
+
+	v.Kind = "value"
+	return &v, nil
+}
+
+// Active reports whether the rule is enabled and t is within its validity window.
+func (r *Rule) Active(t time.Time) bool {
+	if r.Enabled != nil && !*r.Enabled {
+		return false
+	}
+
+	if r.ValidFrom != nil && t.Before(*r.ValidFrom) {
+		return false
+	}
+
+	return r.ValidUntil == nil || t.Before(*r.ValidUntil)
+}
+
 // Eval evaluates the rule against the given params.
 // If it matches it returns a result, otherwise it returns ErrNoMatch
 // or any encountered error.
+// Rules using a missing optional param don't match.
 func (r *Rule) Eval(params Params) (*Value, error) {
+	value, err := r.eval(params)
+	if err == ErrOptionalParamNotFound {
+		return nil, ErrNoMatch
+	}
+
+	return value, err
+}
+
+func (r *Rule) eval(params Params) (*Value, error) {
 	value, err := r.Expr.Eval(params)
 	if err != nil {
 		return nil, err
@@ -88,20 +176,86 @@ func (r *Rule) Eval(params Params) (*Value, error) {
 		return nil, ErrNoMatch
 	}
 
-	return r.Result, nil
+	return r.Result.Eval(params)
 }
 
-// Params returns a list of all the parameters expected by this rule.
+// Params returns a list of all the parameters expected by this rule,
+// including the ones used to compute its result.
+// The names given to the elements of lists by the Any and All expressions are not included.
 func (r *Rule) Params() []Param {
-	var list []Param
+	list := collectParams(r.Expr, nil, nil)
+	if r.Result != nil {
+		list = collectParams(r.Result, nil, list)
+	}
 
-	walk(r.Expr, func(e Expr) error {
-		if p, ok := e.(*Param); ok {
-			list = append(list, *p)
+	return list
+}
+
+// ExprParams returns a list of all the parameters used by the given expression.
+func ExprParams(e Expr) []Param {
+	return collectParams(e, nil, nil)
+}
+
+// collectParams appends to list the parameters used by e, except the ones whose name is bound
+// to the element of a list by an enclosing quantifier.
+func collectParams(e Expr, bound map[string]bool, list []Param) []Param {
+	switch t := e.(type) {
+	case *Param:
+		if !bound[t.Name] {
+			list = append(list, *t)
 		}
+	case operatorExpr:
+		o := t.node()
+		if o.kind == "any" || o.kind == "all" {
+			if name, err := quantifierName(o.kind, o.operands); err == nil {
+				inner := map[string]bool{name: true}
+				for k := range bound {
+					inner[k] = true
+				}
 
-		return nil
-	})
+				list = collectParams(o.operands[0], bound, list)
+				return collectParams(o.operands[2], inner, list)
+			}
+		}
+
+		for _, op := range o.operands {
+			list = collectParams(op, bound, list)
+		}
+	}
 
 	return list
 }
+
+// ResultType returns the type of the value returned by the rule when it matches.
+func (r *Rule) ResultType() (string, error) {
+	if r.Result == nil {
+		return "", errors.New("missing rule result")
+	}
+
+	return TypeCheck(r.Result)
+}
+
+// TypeCheck ensures the expression of the rule evaluates to a boolean and that both the expression
+// and the result are well typed. It returns the type of the result.
+// Type errors are returned as *TypeError whose path is relative to the rule, like /expr/operands/0.
+func (r *Rule) TypeCheck() (string, error) {
+	if r.Expr == nil {
+		return "", errors.New("missing rule expression")
+	}
+
+	typ, err := TypeCheck(r.Expr)
+	if err != nil {
+		return "", prefixTypeError("/expr", err)
+	}
+
+	if typ != "bool" {
+		return "", typeErrorf("/expr", "the expression of a rule must be a bool, got %s", typ)
+	}
+
+	typ, err = r.ResultType()
+	if err != nil {
+		return "", prefixTypeError("/result", err)
+	}
+
+	return typ, nil
+}
@@ -2,6 +2,7 @@ package rule_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/heetch/regula"
 	"github.com/heetch/regula/rule"
@@ -36,6 +37,40 @@ func TestRuleEval(t *testing.T) {
 		}
 	})
 
+	t.Run("Computed result", func(t *testing.T) {
+		r := rule.New(rule.BoolParam("surge"), rule.Mul(rule.Float64Param("base"), rule.Float64Value(1.5)))
+
+		res, err := r.Eval(regula.Params{"surge": true, "base": 2.0})
+		require.NoError(t, err)
+		require.Equal(t, rule.Float64Value(3), res)
+
+		_, err = r.Eval(regula.Params{"surge": false})
+		require.Equal(t, rule.ErrNoMatch, err)
+
+		_, err = r.Eval(regula.Params{"surge": true})
+		require.Equal(t, rule.ErrParamNotFound, err)
+	})
+
+	t.Run("Optional param", func(t *testing.T) {
+		r := rule.New(
+			rule.Eq(rule.Optional(rule.StringParam("promo")), rule.StringValue("summer")),
+			rule.Add(rule.Int64Param("base"), rule.Optional(rule.Int64Param("bonus"))),
+		)
+
+		res, err := r.Eval(regula.Params{"promo": "summer", "base": int64(1), "bonus": int64(2)})
+		require.NoError(t, err)
+		require.Equal(t, rule.Int64Value(3), res)
+
+		_, err = r.Eval(regula.Params{"base": int64(1), "bonus": int64(2)})
+		require.Equal(t, rule.ErrNoMatch, err)
+
+		_, err = r.Eval(regula.Params{"promo": "summer", "base": int64(1)})
+		require.Equal(t, rule.ErrNoMatch, err)
+
+		_, err = r.Eval(regula.Params{"promo": "summer", "bonus": int64(2)})
+		require.Equal(t, rule.ErrParamNotFound, err)
+	})
+
 	t.Run("Invalid return", func(t *testing.T) {
 		tests := []struct {
 			expr   rule.Expr
@@ -53,6 +88,34 @@ func TestRuleEval(t *testing.T) {
 	})
 }
 
+func TestRuleActive(t *testing.T) {
+	from := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)
+	disabled, enabled := false, true
+
+	tests := []struct {
+		name     string
+		rule     rule.Rule
+		t        time.Time
+		expected bool
+	}{
+		{"Default", rule.Rule{}, from, true},
+		{"Enabled", rule.Rule{Enabled: &enabled}, from, true},
+		{"Disabled", rule.Rule{Enabled: &disabled}, from, false},
+		{"Before window", rule.Rule{ValidFrom: &from, ValidUntil: &until}, from.Add(-time.Second), false},
+		{"Window start", rule.Rule{ValidFrom: &from, ValidUntil: &until}, from, true},
+		{"Window end", rule.Rule{ValidFrom: &from, ValidUntil: &until}, until, false},
+		{"Open window", rule.Rule{ValidFrom: &from}, until, true},
+		{"Disabled in window", rule.Rule{Enabled: &disabled, ValidFrom: &from}, until, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.rule.Active(test.t))
+		})
+	}
+}
+
 func TestRuleParams(t *testing.T) {
 	tc := []struct {
 		rule   *rule.Rule
@@ -71,6 +134,18 @@ func TestRuleParams(t *testing.T) {
 				), rule.StringValue("result")),
 			[]rule.Param{*rule.Int64Param("a"), *rule.BoolParam("b")},
 		},
+		{
+			rule.New(rule.BoolParam("a"), rule.Add(rule.Int64Param("b"), rule.Int64Value(1))),
+			[]rule.Param{*rule.BoolParam("a"), *rule.Int64Param("b")},
+		},
+		{
+			rule.New(
+				rule.And(
+					rule.Any(rule.StringListParam("tags"), "tag", rule.Eq(rule.StringParam("tag"), rule.StringParam("vip"))),
+					rule.Eq(rule.StringParam("tag"), rule.StringValue("a")),
+				), rule.StringValue("result")),
+			[]rule.Param{*rule.StringListParam("tags"), *rule.StringParam("vip"), *rule.StringParam("tag")},
+		},
 	}
 
 	for _, tt := range tc {
@@ -78,3 +153,25 @@ func TestRuleParams(t *testing.T) {
 		require.Equal(t, tt.params, params)
 	}
 }
+
+func TestRuleResultType(t *testing.T) {
+	tests := []struct {
+		result rule.Expr
+		typ    string
+	}{
+		{rule.StringValue("a"), "string"},
+		{rule.Int64Param("a"), "int64"},
+		{rule.Mul(rule.Float64Param("a"), rule.Float64Value(2)), "float64"},
+		{rule.Eq(rule.StringParam("a"), rule.StringValue("b")), "bool"},
+		{rule.FNV(rule.StringParam("a")), "int64"},
+	}
+
+	for _, test := range tests {
+		typ, err := rule.New(rule.True(), test.result).ResultType()
+		require.NoError(t, err)
+		require.Equal(t, test.typ, typ)
+	}
+
+	_, err := rule.New(rule.True(), new(mockExpr)).ResultType()
+	require.Error(t, err)
+}
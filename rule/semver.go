@@ -0,0 +1,235 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A Version is a semantic version, as described by https://semver.org.
+type Version struct {
+	Major int64
+	Minor int64
+	Patch int64
+
+	// Pre holds the dot separated pre-release identifiers, if any.
+	Pre string
+
+	// Build holds the build metadata, if any. It is ignored when comparing versions.
+	Build string
+}
+
+// ParseVersion parses a semantic version, like "5.12.3" or "6.0.0-beta.1+42".
+// The minor and patch numbers can be omitted, in which case they default to zero: "6" is equivalent to "6.0.0".
+func ParseVersion(s string) (Version, error) {
+	var v Version
+
+	rest := s
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		v.Build = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(v.Build, false) {
+			return Version{}, fmt.Errorf("invalid build metadata in version %q", s)
+		}
+	}
+
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		v.Pre = rest[i+1:]
+		rest = rest[:i]
+		if !validIdentifiers(v.Pre, true) {
+			return Version{}, fmt.Errorf("invalid pre-release in version %q", s)
+		}
+	}
+
+	nums := [...]*int64{&v.Major, &v.Minor, &v.Patch}
+	for i := 0; ; i++ {
+		n := rest
+		j := strings.IndexByte(rest, '.')
+		if j >= 0 {
+			n = rest[:j]
+		}
+
+		if i == len(nums) || !isNumeric(n) {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+
+		x, err := strconv.ParseInt(n, 10, 64)
+		if err != nil {
+			return Version{}, fmt.Errorf("invalid version %q", s)
+		}
+		*nums[i] = x
+
+		if j < 0 {
+			return v, nil
+		}
+		rest = rest[j+1:]
+	}
+}
+
+// isNumeric reports whether s is a number without leading zeros.
+func isNumeric(s string) bool {
+	if s == "" || (len(s) > 1 && s[0] == '0') {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validIdentifiers reports whether s is a non empty list of dot separated identifiers made of alphanumerics and hyphens.
+// Numeric pre-release identifiers must not have leading zeros.
+func validIdentifiers(s string, pre bool) bool {
+	if s == "" {
+		return false
+	}
+
+	for s != "" {
+		var id string
+		id, s = nextIdentifier(s)
+		if id == "" {
+			return false
+		}
+
+		digits := true
+		for i := 0; i < len(id); i++ {
+			c := id[i]
+			switch {
+			case c >= '0' && c <= '9':
+			case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c == '-':
+				digits = false
+			default:
+				return false
+			}
+		}
+
+		if pre && digits && !isNumeric(id) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// String returns the version formatted as MAJOR.MINOR.PATCH, followed by the pre-release and the build metadata.
+func (v Version) String() string {
+	s := strconv.FormatInt(v.Major, 10) + "." + strconv.FormatInt(v.Minor, 10) + "." + strconv.FormatInt(v.Patch, 10)
+	if v.Pre != "" {
+		s += "-" + v.Pre
+	}
+	if v.Build != "" {
+		s += "+" + v.Build
+	}
+
+	return s
+}
+
+// Compare returns a negative number if v precedes other, zero if they have the same precedence
+// and a positive number if v follows other, according to the semantic versioning precedence rules.
+func (v Version) Compare(other Version) int {
+	switch {
+	case v.Major != other.Major:
+		return compareInt64(v.Major, other.Major)
+	case v.Minor != other.Minor:
+		return compareInt64(v.Minor, other.Minor)
+	case v.Patch != other.Patch:
+		return compareInt64(v.Patch, other.Patch)
+	}
+
+	// a pre-release version has a lower precedence than the associated normal version
+	switch {
+	case v.Pre == other.Pre:
+		return 0
+	case v.Pre == "":
+		return 1
+	case other.Pre == "":
+		return -1
+	}
+
+	a, b := v.Pre, other.Pre
+	for a != "" && b != "" {
+		var x, y string
+		x, a = nextIdentifier(a)
+		y, b = nextIdentifier(b)
+
+		if c := compareIdentifiers(x, y); c != 0 {
+			return c
+		}
+	}
+
+	// a larger set of pre-release identifiers has a higher precedence
+	switch {
+	case a != "":
+		return 1
+	case b != "":
+		return -1
+	}
+
+	return 0
+}
+
+func nextIdentifier(s string) (id, rest string) {
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		return s[:i], s[i+1:]
+	}
+
+	return s, ""
+}
+
+// compareIdentifiers compares two pre-release identifiers. Numeric identifiers are compared numerically
+// and have a lower precedence than alphanumeric ones, which are compared in ASCII order.
+func compareIdentifiers(a, b string) int {
+	na, nb := isNumeric(a), isNumeric(b)
+	switch {
+	case na && nb:
+		if len(a) != len(b) {
+			return compareInt64(int64(len(a)), int64(len(b)))
+		}
+	case na:
+		return -1
+	case nb:
+		return 1
+	}
+
+	return strings.Compare(a, b)
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+
+	return 0
+}
+
+// VersionParam creates a Param that looks up in the set of params passed during evaluation and returns the value
+// of the variable that corresponds to the given name.
+// The corresponding value must be a string holding a semantic version. If not found it returns an error.
+func VersionParam(name string) *Param {
+	return &Param{
+		Kind: "param",
+		Type: "version",
+		Name: name,
+	}
+}
+
+// VersionValue creates a semantic version value, like "5.12.3".
+// The version is only checked when type checking the expression, see ParseVersion for the accepted formats.
+func VersionValue(value string) *Value {
+	return newValue("version", value)
+}
+
+func parseVersionValues(v1, v2 *Value) (s1, s2 Version, err error) {
+	if s1, err = ParseVersion(v1.Data); err != nil {
+		return
+	}
+	s2, err = ParseVersion(v2.Data)
+	return
+}
@@ -0,0 +1,151 @@
+package rule
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+)
+
+// A Trace records the evaluation of an expression and of its operands.
+// The Value and Error of an operand that wasn't evaluated, because the evaluation of its operator
+// stopped before reaching it, are empty.
+type Trace struct {
+	// Kind is the kind of the expression: "value", "param" or the kind of the operator.
+	Kind string `json:"kind"`
+	// Name is the name of the param, if the expression is a param.
+	Name string `json:"name,omitempty"`
+	// Value is the value returned by the expression.
+	Value *Value `json:"value,omitempty"`
+	// Error is the error returned by the expression, if any.
+	Error    string   `json:"error,omitempty"`
+	Operands []*Trace `json:"operands,omitempty"`
+}
+
+// EvalTrace evaluates e like e.Eval and returns the trace of the evaluation.
+// The predicates of the Any and All expressions are evaluated once per element of the list,
+// their trace is the one of the last evaluation.
+func EvalTrace(e Expr, params Params) (*Value, *Trace, error) {
+	t, err := newTracer(e)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v, err := t.Eval(params)
+	return v, t.trace, err
+}
+
+// tracer records the result of the evaluation of an expression in a trace.
+type tracer struct {
+	Expr
+
+	trace *Trace
+}
+
+// newTracer returns a tracer wrapping a copy of e whose operands are themselves tracers.
+func newTracer(e Expr) (*tracer, error) {
+	t := tracer{
+		Expr:  e,
+		trace: new(Trace),
+	}
+
+	switch n := e.(type) {
+	case *Value:
+		t.trace.Kind = n.Kind
+	case *Param:
+		t.trace.Kind = n.Kind
+		t.trace.Name = n.Name
+	case operatorExpr:
+		// shallow copy of the operator, to replace its operands without modifying e
+		c := reflect.New(reflect.TypeOf(n).Elem())
+		c.Elem().Set(reflect.ValueOf(n).Elem())
+		op := c.Interface().(operatorExpr)
+
+		o := op.node()
+		t.Expr = op
+		t.trace.Kind = o.kind
+
+		operands := make([]Expr, len(o.operands))
+		for i, operand := range o.operands {
+			if (o.kind == "any" || o.kind == "all") && i == 1 {
+				// the name of the element must remain a value
+				operands[i] = operand
+				continue
+			}
+
+			ot, err := newTracer(operand)
+			if err != nil {
+				return nil, err
+			}
+			operands[i] = ot
+			t.trace.Operands = append(t.trace.Operands, ot.trace)
+		}
+		o.operands = operands
+	default:
+		return nil, errors.New("unable to trace expression")
+	}
+
+	return &t, nil
+}
+
+func (t *tracer) Eval(params Params) (*Value, error) {
+	v, err := t.Expr.Eval(params)
+	t.trace.Value = v
+	t.trace.Error = ""
+	if err != nil {
+		t.trace.Error = err.Error()
+	}
+
+	return v, err
+}
+
+// A RuleTrace records the evaluation of a rule.
+type RuleTrace struct {
+	// Index is the index of the rule in its ruleset, set when the rule is evaluated as part of a ruleset.
+	// It identifies the rule as the traces of the rules of a ruleset are in order of evaluation.
+	Index int `json:"index"`
+	// ID is the ID of the rule, if it has one.
+	ID string `json:"id,omitempty"`
+	// Expr is the trace of the expression of the rule.
+	Expr *Trace `json:"expr"`
+	// Matched reports whether the expression evaluated to true.
+	Matched bool `json:"matched"`
+	// Result is the trace of the result, if the rule matched.
+	Result *Trace `json:"result,omitempty"`
+}
+
+// EvalTrace evaluates the rule like Eval and returns the trace of the evaluation.
+// The trace is returned even if the evaluation fails.
+func (r *Rule) EvalTrace(params Params) (*Value, *RuleTrace, error) {
+	value, rt, err := r.evalTrace(params)
+	if err == ErrOptionalParamNotFound {
+		return nil, rt, ErrNoMatch
+	}
+
+	return value, rt, err
+}
+
+func (r *Rule) evalTrace(params Params) (*Value, *RuleTrace, error) {
+	rt := RuleTrace{ID: r.ID}
+
+	value, trace, err := EvalTrace(r.Expr, params)
+	rt.Expr = trace
+	if err != nil {
+		return nil, &rt, err
+	}
+
+	if value.Type != "bool" {
+		return nil, &rt, errors.New("invalid rule returning non boolean value")
+	}
+
+	rt.Matched, err = strconv.ParseBool(value.Data)
+	if err != nil {
+		return nil, &rt, err
+	}
+
+	if !rt.Matched {
+		return nil, &rt, ErrNoMatch
+	}
+
+	value, rt.Result, err = EvalTrace(r.Result, params)
+	return value, &rt, err
+}
@@ -0,0 +1,120 @@
+package rule_test
+
+import (
+	"testing"
+
+	"github.com/heetch/regula"
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvalTrace(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		e := rule.Or(
+			rule.Eq(rule.Add(rule.Int64Param("a"), rule.Int64Value(1)), rule.Int64Value(3)),
+			rule.BoolParam("b"),
+		)
+
+		v, trace, err := rule.EvalTrace(e, regula.Params{"a": int64(2)})
+		require.NoError(t, err)
+		require.Equal(t, rule.BoolValue(true), v)
+		require.Equal(t, &rule.Trace{
+			Kind:  "or",
+			Value: rule.BoolValue(true),
+			Operands: []*rule.Trace{
+				{
+					Kind:  "eq",
+					Value: rule.BoolValue(true),
+					Operands: []*rule.Trace{
+						{
+							Kind:  "add",
+							Value: rule.Int64Value(3),
+							Operands: []*rule.Trace{
+								{Kind: "param", Name: "a", Value: rule.Int64Value(2)},
+								{Kind: "value", Value: rule.Int64Value(1)},
+							},
+						},
+						{Kind: "value", Value: rule.Int64Value(3)},
+					},
+				},
+				// not evaluated
+				{Kind: "param", Name: "b"},
+			},
+		}, trace)
+
+		// the expression is left untouched
+		require.Equal(t, rule.Or(
+			rule.Eq(rule.Add(rule.Int64Param("a"), rule.Int64Value(1)), rule.Int64Value(3)),
+			rule.BoolParam("b"),
+		), e)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, trace, err := rule.EvalTrace(rule.Not(rule.BoolParam("a")), regula.Params{})
+		require.Equal(t, rule.ErrParamNotFound, err)
+		require.Equal(t, &rule.Trace{
+			Kind:  "not",
+			Error: rule.ErrParamNotFound.Error(),
+			Operands: []*rule.Trace{
+				{Kind: "param", Name: "a", Error: rule.ErrParamNotFound.Error()},
+			},
+		}, trace)
+	})
+
+	t.Run("Quantifier", func(t *testing.T) {
+		e := rule.Any(rule.Int64ListParam("ids"), "id", rule.GT(rule.Int64Param("id"), rule.Int64Value(1)))
+
+		v, trace, err := rule.EvalTrace(e, regula.Params{"ids": []int64{1, 2, 3}})
+		require.NoError(t, err)
+		require.Equal(t, rule.BoolValue(true), v)
+		require.Len(t, trace.Operands, 2)
+		require.Equal(t, rule.Int64ListValue(1, 2, 3), trace.Operands[0].Value)
+		// last evaluation of the predicate
+		require.Equal(t, rule.Int64Value(2), trace.Operands[1].Operands[0].Value)
+	})
+
+	t.Run("Matches", func(t *testing.T) {
+		v, _, err := rule.EvalTrace(rule.Matches(rule.StringParam("a"), "^b"), regula.Params{"a": "bc"})
+		require.NoError(t, err)
+		require.Equal(t, rule.BoolValue(true), v)
+	})
+
+	t.Run("Variant", func(t *testing.T) {
+		e := rule.Variant("exp", rule.StringParam("user"),
+			rule.VariantWeight{Name: "a", Weight: 1},
+			rule.VariantWeight{Name: "b", Weight: 1},
+		)
+		params := regula.Params{"user": "123"}
+
+		expected, err := e.Eval(params)
+		require.NoError(t, err)
+
+		v, trace, err := rule.EvalTrace(e, params)
+		require.NoError(t, err)
+		require.Equal(t, expected, v)
+		require.Equal(t, "variant", trace.Kind)
+		require.Equal(t, rule.StringValue("123"), trace.Operands[1].Value)
+	})
+}
+
+func TestRuleEvalTrace(t *testing.T) {
+	r := rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.Float64Value(2))
+
+	v, trace, err := r.EvalTrace(regula.Params{"city": "paris"})
+	require.NoError(t, err)
+	require.Equal(t, rule.Float64Value(2), v)
+	require.True(t, trace.Matched)
+	require.Equal(t, &rule.Trace{Kind: "value", Value: rule.Float64Value(2)}, trace.Result)
+
+	_, trace, err = r.EvalTrace(regula.Params{"city": "lyon"})
+	require.Equal(t, rule.ErrNoMatch, err)
+	require.False(t, trace.Matched)
+	require.Equal(t, rule.BoolValue(false), trace.Expr.Value)
+	require.Nil(t, trace.Result)
+
+	r = rule.New(rule.Optional(rule.BoolParam("vip")), rule.Float64Value(2))
+	_, trace, err = r.EvalTrace(regula.Params{})
+	require.Equal(t, rule.ErrNoMatch, err)
+	require.False(t, trace.Matched)
+	require.Equal(t, rule.ErrOptionalParamNotFound.Error(), trace.Expr.Error)
+}
@@ -0,0 +1,378 @@
+package rule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A TypeError describes an expression whose operands are not of the number or types expected by its operator.
+type TypeError struct {
+	// Path is a JSON pointer locating the offending expression, relative to the checked one.
+	// For example /operands/1 designates the second operand and an empty path the checked expression itself.
+	Path string
+	Msg  string
+}
+
+func (e *TypeError) Error() string {
+	path := e.Path
+	if path == "" {
+		path = "/"
+	}
+
+	return path + ": " + e.Msg
+}
+
+func typeErrorf(path, format string, args ...interface{}) error {
+	return &TypeError{
+		Path: path,
+		Msg:  fmt.Sprintf(format, args...),
+	}
+}
+
+// prefixTypeError prepends prefix to the path of err if it is a TypeError.
+func prefixTypeError(prefix string, err error) error {
+	if te, ok := err.(*TypeError); ok {
+		return &TypeError{
+			Path: prefix + te.Path,
+			Msg:  te.Msg,
+		}
+	}
+
+	return err
+}
+
+// TypeCheck infers the type of the value returned by the evaluation of e, without evaluating it.
+// It ensures every operator of the tree is given the expected number of operands, of the expected types,
+// and that values and parameters are of a known type.
+// If it is not the case, it returns a *TypeError pointing to the offending expression.
+func TypeCheck(e Expr) (string, error) {
+	var c checker
+	return c.check(e, "")
+}
+
+// arities lists the minimum and maximum number of operands of each operator.
+// A maximum of -1 means that the number of operands is unbounded.
+var arities = map[string][2]int{
+	"not":           {1, 1},
+	"and":           {2, -1},
+	"or":            {2, -1},
+	"eq":            {2, -1},
+	"in":            {2, -1},
+	"gt":            {2, -1},
+	"gte":           {2, -1},
+	"lt":            {2, -1},
+	"lte":           {2, -1},
+	"fnv":           {1, 1},
+	"percentile":    {2, 2},
+	"variant":       {4, -1},
+	"add":           {2, -1},
+	"sub":           {2, -1},
+	"mul":           {2, -1},
+	"div":           {2, -1},
+	"mod":           {2, 2},
+	"min":           {2, -1},
+	"max":           {2, -1},
+	"abs":           {1, 1},
+	"contains":      {2, 2},
+	"prefix":        {2, 2},
+	"suffix":        {2, 2},
+	"matches":       {2, 2},
+	"lower":         {1, 1},
+	"upper":         {1, 1},
+	"length":        {1, 1},
+	"concat":        {2, -1},
+	"now":           {0, 0},
+	"before":        {2, 2},
+	"after":         {2, 2},
+	"hour-of-day":   {2, 2},
+	"day-of-week":   {2, 2},
+	"intersects":    {2, 2},
+	"size":          {1, 1},
+	"any":           {3, 3},
+	"all":           {3, 3},
+	"distance":      {2, 2},
+	"within-radius": {3, 3},
+	"in-polygon":    {2, 2},
+	"has":           {1, 1},
+	"coalesce":      {2, -1},
+}
+
+// checker infers the types of an expression tree.
+type checker struct {
+	// bound maps the names given to the elements of lists by the enclosing quantifiers to their type.
+	bound map[string]string
+}
+
+func (c *checker) check(e Expr, path string) (string, error) {
+	switch t := e.(type) {
+	case *Value:
+		return t.Type, checkValue(t, path)
+	case *Param:
+		if !isKnownType(t.Type) {
+			return "", typeErrorf(path, "unknown type %q for param %s", t.Type, t.Name)
+		}
+		if typ, ok := c.bound[t.Name]; ok && typ != t.Type {
+			return "", typeErrorf(path, "param %s designates an element of type %s, got %s", t.Name, typ, t.Type)
+		}
+		return t.Type, nil
+	case operatorExpr:
+		return c.checkOperator(t, path)
+	}
+
+	return "", typeErrorf(path, "unable to determine the type of expression %T", e)
+}
+
+func (c *checker) checkOperator(e operatorExpr, path string) (string, error) {
+	o := e.node()
+
+	ar, ok := arities[o.kind]
+	if !ok {
+		return "", typeErrorf(path, "unknown operator %q", o.kind)
+	}
+
+	if n := len(o.operands); n < ar[0] || (ar[1] >= 0 && n > ar[1]) {
+		return "", typeErrorf(path, "%s expects %s, got %d", o.kind, describeArity(ar), n)
+	}
+
+	if v, ok := e.(validator); ok {
+		if err := v.validate(); err != nil {
+			return "", typeErrorf(path, "%v", err)
+		}
+	}
+
+	if o.kind == "any" || o.kind == "all" {
+		return c.checkQuantifier(o, path)
+	}
+
+	types := make([]string, len(o.operands))
+	for i, op := range o.operands {
+		typ, err := c.check(op, operandPath(path, i))
+		if err != nil {
+			return "", err
+		}
+		types[i] = typ
+	}
+
+	s := signature{kind: o.kind, path: path, types: types}
+
+	switch o.kind {
+	case "not", "and", "or":
+		return "bool", s.all("bool")
+	case "eq", "in":
+		return "bool", s.same()
+	case "gt", "gte", "lt", "lte":
+		if err := s.operand(0, "string", "bool", "int64", "float64", "time", "duration", "version"); err != nil {
+			return "", err
+		}
+		return "bool", s.same()
+	case "fnv":
+		return "int64", nil
+	case "percentile":
+		return "bool", s.operand(1, "int64")
+	case "variant":
+		return "string", nil
+	case "add", "sub", "mul", "div", "mod", "min", "max", "abs":
+		if err := s.operand(0, "int64", "float64"); err != nil {
+			return "", err
+		}
+		return types[0], s.same()
+	case "contains":
+		if types[0] == "string" {
+			return "bool", s.operand(1, "string")
+		}
+		if err := s.list(0); err != nil {
+			return "", err
+		}
+		return "bool", s.operand(1, listElemType(types[0]))
+	case "prefix", "suffix", "matches":
+		return "bool", s.all("string")
+	case "lower", "upper", "concat":
+		return "string", s.all("string")
+	case "length":
+		return "int64", s.all("string")
+	case "now":
+		return "time", nil
+	case "before", "after":
+		return "bool", s.all("time")
+	case "hour-of-day", "day-of-week":
+		if err := s.operand(0, "time"); err != nil {
+			return "", err
+		}
+		return "int64", s.operand(1, "string")
+	case "intersects":
+		if err := s.list(0); err != nil {
+			return "", err
+		}
+		return "bool", s.same()
+	case "distance":
+		return "float64", s.all("point")
+	case "within-radius":
+		if err := s.operand(0, "point"); err != nil {
+			return "", err
+		}
+		if err := s.operand(1, "point"); err != nil {
+			return "", err
+		}
+		return "bool", s.operand(2, "float64")
+	case "in-polygon":
+		if err := s.operand(0, "point"); err != nil {
+			return "", err
+		}
+		return "bool", s.operand(1, "polygon")
+	case "has":
+		return "bool", nil
+	case "coalesce":
+		return types[0], s.same()
+	}
+
+	// size
+	return "int64", s.list(0)
+}
+
+// checkQuantifier checks the Any and All expressions, whose predicate can refer to the elements of the list.
+func (c *checker) checkQuantifier(o *operator, path string) (string, error) {
+	typ, err := c.check(o.operands[0], operandPath(path, 0))
+	if err != nil {
+		return "", err
+	}
+
+	s := signature{kind: o.kind, path: path, types: []string{typ}}
+	if err := s.list(0); err != nil {
+		return "", err
+	}
+
+	name, err := quantifierName(o.kind, o.operands)
+	if err != nil {
+		return "", typeErrorf(operandPath(path, 1), "%v", err)
+	}
+
+	inner := checker{bound: map[string]string{name: listElemType(typ)}}
+	for k, v := range c.bound {
+		if k != name {
+			inner.bound[k] = v
+		}
+	}
+
+	typ, err = inner.check(o.operands[2], operandPath(path, 2))
+	if err != nil {
+		return "", err
+	}
+
+	s.types = append(s.types, "string", typ)
+	return "bool", s.operand(2, "bool")
+}
+
+// signature checks the types of the operands of an operator.
+type signature struct {
+	kind  string
+	path  string
+	types []string
+}
+
+// operand ensures the operand at index i is of one of the given types.
+func (s *signature) operand(i int, types ...string) error {
+	for _, typ := range types {
+		if s.types[i] == typ {
+			return nil
+		}
+	}
+
+	return typeErrorf(operandPath(s.path, i), "%s expects %s, got %s", s.kind, strings.Join(types, " or "), s.types[i])
+}
+
+// all ensures every operand is of the given type.
+func (s *signature) all(typ string) error {
+	for i := range s.types {
+		if err := s.operand(i, typ); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// same ensures every operand is of the type of the first one.
+func (s *signature) same() error {
+	for i := range s.types {
+		if s.types[i] != s.types[0] {
+			return typeErrorf(operandPath(s.path, i), "%s expects operands of the same type, %s, got %s", s.kind, s.types[0], s.types[i])
+		}
+	}
+
+	return nil
+}
+
+// list ensures the operand at index i is a list.
+func (s *signature) list(i int) error {
+	if listElemType(s.types[i]) == "" {
+		return typeErrorf(operandPath(s.path, i), "%s expects a list, got %s", s.kind, s.types[i])
+	}
+
+	return nil
+}
+
+func operandPath(path string, i int) string {
+	return path + "/operands/" + strconv.Itoa(i)
+}
+
+func describeArity(ar [2]int) string {
+	switch {
+	case ar[1] < 0:
+		return fmt.Sprintf("at least %d operands", ar[0])
+	case ar[0] == ar[1] && ar[0] == 1:
+		return "1 operand"
+	case ar[0] == ar[1]:
+		return fmt.Sprintf("%d operands", ar[0])
+	}
+
+	return fmt.Sprintf("between %d and %d operands", ar[0], ar[1])
+}
+
+func isKnownType(typ string) bool {
+	switch typ {
+	case "string", "bool", "int64", "float64", "time", "duration", "version", "point", "object":
+		return true
+	}
+
+	return listElemType(typ) != ""
+}
+
+// checkValue ensures the data of the value can be decoded according to its type.
+func checkValue(v *Value, path string) error {
+	var err error
+
+	switch v.Type {
+	case "string":
+	case "bool":
+		_, err = strconv.ParseBool(v.Data)
+	case "int64":
+		_, err = strconv.ParseInt(v.Data, 10, 64)
+	case "float64":
+		_, err = strconv.ParseFloat(v.Data, 64)
+	case "time":
+		_, err = time.Parse(time.RFC3339Nano, v.Data)
+	case "duration":
+		_, err = time.ParseDuration(v.Data)
+	case "version":
+		_, err = ParseVersion(v.Data)
+	case "point":
+		_, err = decodePoint(v.Data)
+	case "polygon":
+		_, err = decodePolygon(v.Data)
+	case "object":
+		_, err = decodeObject(v.Data)
+	default:
+		if listElemType(v.Type) == "" {
+			return typeErrorf(path, "unknown value type %q", v.Type)
+		}
+		_, err = listItems("", v)
+	}
+
+	if err != nil {
+		return typeErrorf(path, "invalid %s value %q", v.Type, v.Data)
+	}
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package rule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTypeCheck(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		tests := []struct {
+			expr     rule.Expr
+			expected string
+		}{
+			{rule.StringValue("a"), "string"},
+			{rule.Int64Param("a"), "int64"},
+			{rule.Not(rule.BoolParam("a")), "bool"},
+			{rule.Eq(rule.Float64Param("a"), rule.Float64Value(1)), "bool"},
+			{rule.In(rule.StringParam("a"), rule.StringValue("b"), rule.StringValue("c")), "bool"},
+			{rule.GT(rule.TimeParam("a"), rule.Now()), "bool"},
+			{rule.Percentile(rule.Int64Param("id"), rule.Int64Value(50)), "bool"},
+			{rule.FNV(rule.BoolValue(true)), "int64"},
+			{rule.Mul(rule.Float64Param("a"), rule.Float64Value(2)), "float64"},
+			{rule.Abs(rule.Int64Param("a")), "int64"},
+			{rule.Concat(rule.StringParam("a"), rule.Upper(rule.StringValue("b"))), "string"},
+			{rule.Length(rule.StringParam("a")), "int64"},
+			{rule.Contains(rule.StringParam("a"), rule.StringValue("b")), "bool"},
+			{rule.Contains(rule.Int64ListParam("a"), rule.Int64Value(1)), "bool"},
+			{rule.Now(), "time"},
+			{rule.HourOfDay(rule.Now(), rule.StringParam("tz")), "int64"},
+			{rule.Intersects(rule.StringListParam("a"), rule.StringListValue("b")), "bool"},
+			{rule.Size(rule.Float64ListParam("a")), "int64"},
+			{rule.Any(rule.StringListParam("tags"), "tag", rule.Prefix(rule.StringParam("tag"), rule.StringValue("vip"))), "bool"},
+			{rule.All(rule.Int64ListParam("a"), "x", rule.Any(rule.StringListParam("b"), "y", rule.GT(rule.Int64Param("x"), rule.Int64Value(0)))), "bool"},
+			{rule.LT(rule.VersionParam("a"), rule.VersionValue("6")), "bool"},
+			{rule.ObjectValue(map[string]interface{}{"a": 1}), "object"},
+			{rule.Eq(rule.ObjectValue(nil), rule.ObjectValue(nil)), "bool"},
+			{rule.Has(rule.Optional(rule.PointParam("a"))), "bool"},
+			{rule.Coalesce(rule.Optional(rule.Int64Param("a")), rule.Int64Param("b"), rule.Int64Value(1)), "int64"},
+			{rule.Variant("exp", rule.TimeParam("a"), rule.VariantWeight{Name: "a", Weight: 1}), "string"},
+			{rule.Distance(rule.PointParam("a"), rule.PointValue(rule.Point{Lat: 1, Lng: 2})), "float64"},
+			{rule.WithinRadius(rule.PointParam("a"), rule.PointParam("b"), rule.Float64Value(2)), "bool"},
+			{rule.InPolygon(rule.PointParam("a"), rule.PolygonValue(rule.Point{}, rule.Point{Lat: 1}, rule.Point{Lng: 1})), "bool"},
+		}
+
+		for _, test := range tests {
+			typ, err := rule.TypeCheck(test.expr)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, typ)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			expr rule.Expr
+			path string
+		}{
+			{rule.Not(rule.StringValue("x")), "/operands/0"},
+			{rule.GT(rule.Int64Param("a"), rule.StringValue("b")), "/operands/1"},
+			{rule.GT(rule.StringListValue("a"), rule.StringListValue("b")), "/operands/0"},
+			{rule.And(rule.True(), rule.Not(rule.Eq(rule.Int64Param("a"), rule.Float64Value(1)))), "/operands/1/operands/0/operands/1"},
+			{rule.Add(rule.StringValue("a"), rule.StringValue("b")), "/operands/0"},
+			{rule.Percentile(rule.StringParam("a"), rule.StringValue("b")), "/operands/1"},
+			{rule.Contains(rule.Int64Value(1), rule.Int64Value(1)), "/operands/0"},
+			{rule.Contains(rule.StringListParam("a"), rule.Int64Value(1)), "/operands/1"},
+			{rule.Before(rule.Now(), rule.DurationValue(time.Hour)), "/operands/1"},
+			{rule.Intersects(rule.StringListParam("a"), rule.Int64ListParam("b")), "/operands/1"},
+			{rule.Size(rule.StringParam("a")), "/operands/0"},
+			{rule.Any(rule.StringListParam("a"), "x", rule.StringParam("x")), "/operands/2"},
+			{rule.Any(rule.StringListParam("a"), "x", rule.Eq(rule.Int64Param("x"), rule.Int64Value(1))), "/operands/2/operands/0"},
+			{rule.Any(rule.StringListParam("a"), "", rule.True()), ""},
+			{rule.Matches(rule.StringParam("a"), "a("), ""},
+			{rule.HourOfDay(rule.Now(), rule.StringValue("Mars/Olympus")), ""},
+			{rule.Lower(&rule.Value{Kind: "value", Type: "int64", Data: "a"}), "/operands/0"},
+			{rule.Lower(&rule.Value{Kind: "value", Type: "int32", Data: "1"}), "/operands/0"},
+			{rule.Lower(&rule.Param{Kind: "param", Type: "int32", Name: "a"}), "/operands/0"},
+			{rule.Eq(rule.Variant("exp", rule.StringParam("a"), rule.VariantWeight{Name: "a", Weight: -1}), rule.StringValue("a")), "/operands/0"},
+			{rule.Variant("exp", &rule.Param{Kind: "param", Type: "int32", Name: "a"}, rule.VariantWeight{Name: "a", Weight: 1}), "/operands/1"},
+			{rule.Coalesce(rule.Int64Param("a"), rule.Float64Value(1)), "/operands/1"},
+			{rule.GT(rule.VersionParam("a"), rule.StringValue("6.0.0")), "/operands/1"},
+			{rule.GT(rule.VersionParam("a"), rule.VersionValue("6.0.0.1")), "/operands/1"},
+			{rule.Eq(rule.ObjectValue(nil), &rule.Value{Kind: "value", Type: "object", Data: "{"}), "/operands/1"},
+			{rule.GT(rule.ObjectValue(nil), rule.ObjectValue(nil)), "/operands/0"},
+			{rule.Distance(rule.PointParam("a"), rule.StringParam("b")), "/operands/1"},
+			{rule.WithinRadius(rule.PointParam("a"), rule.PointParam("b"), rule.Int64Value(2)), "/operands/2"},
+			{rule.InPolygon(rule.PointParam("a"), rule.PointParam("b")), "/operands/1"},
+			{rule.InPolygon(rule.PointParam("a"), rule.PolygonValue(rule.Point{}, rule.Point{Lat: 1})), "/operands/1"},
+			{rule.Distance(rule.PointParam("a"), &rule.Value{Kind: "value", Type: "point", Data: "[100,0]"}), "/operands/1"},
+			{rule.Eq(&rule.Param{Kind: "param", Type: "polygon", Name: "a"}, rule.PolygonValue()), "/operands/0"},
+			{new(mockExpr), ""},
+		}
+
+		for _, test := range tests {
+			_, err := rule.TypeCheck(test.expr)
+			require.Error(t, err)
+			te, ok := err.(*rule.TypeError)
+			require.True(t, ok, err.Error())
+			require.Equal(t, test.path, te.Path, err.Error())
+		}
+	})
+
+	t.Run("Unmarshal", func(t *testing.T) {
+		var r rule.Rule
+
+		err := r.UnmarshalJSON([]byte(`{"expr": {"kind": "not", "operands": []}, "result": {"kind": "value", "type": "int64", "data": "1"}}`))
+		require.EqualError(t, err, "/expr: not expects 1 operand, got 0")
+	})
+
+	t.Run("Error message", func(t *testing.T) {
+		_, err := rule.TypeCheck(rule.And(rule.True(), rule.Not(rule.StringValue("x"))))
+		require.EqualError(t, err, "/operands/1/operands/0: not expects bool, got string")
+	})
+}
+
+func TestRuleTypeCheck(t *testing.T) {
+	typ, err := rule.New(rule.BoolParam("a"), rule.Add(rule.Int64Param("b"), rule.Int64Value(1))).TypeCheck()
+	require.NoError(t, err)
+	require.Equal(t, "int64", typ)
+
+	_, err = rule.New(rule.Int64Param("a"), rule.Int64Value(1)).TypeCheck()
+	require.Equal(t, &rule.TypeError{Path: "/expr", Msg: "the expression of a rule must be a bool, got int64"}, err)
+
+	_, err = rule.New(rule.True(), rule.Abs(rule.StringValue("a"))).TypeCheck()
+	require.Equal(t, &rule.TypeError{Path: "/result/operands/0", Msg: "abs expects int64 or float64, got string"}, err)
+}
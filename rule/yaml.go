@@ -0,0 +1,369 @@
+package rule
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// The YAML representation of expressions is more compact than their JSON representation:
+//
+//	- string, bool, int64 and float64 values are written as scalars, e.g. paris, true, 10 or 1.5
+//	- values of the other types, and float64 values without decimals, are written as a map associating their type
+//	  with their data, e.g. {duration: 1h}, {float64: 3} or {string-list: '["a","b"]'}
+//	- params are written as a map holding their name, their type if it's not string,
+//	  and whether they are optional, e.g. {param: age, type: int64, optional: true}
+//	- operators are written as a map associating their kind with the list of their operands,
+//	  e.g. {eq: [{param: city}, paris]}. The list can be omitted for operators with a single operand.
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (o *operator) MarshalYAML() (interface{}, error) {
+	return yaml.MapSlice{{Key: o.kind, Value: o.operands}}, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (o *operator) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var node interface{}
+	if err := unmarshal(&node); err != nil {
+		return err
+	}
+
+	e, err := exprFromYAML(node)
+	if err != nil {
+		return err
+	}
+
+	op, ok := e.(operatorExpr)
+	if !ok {
+		return errors.New("expected an operator")
+	}
+
+	*o = *op.node()
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (n *exprVariant) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	err := n.operator.UnmarshalYAML(unmarshal)
+	if err != nil {
+		return err
+	}
+
+	n.compile()
+	return n.err
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (n *exprMatches) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	err := n.operator.UnmarshalYAML(unmarshal)
+	if err != nil {
+		return err
+	}
+
+	n.compile()
+	return n.err
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (p *Param) MarshalYAML() (interface{}, error) {
+	m := yaml.MapSlice{{Key: "param", Value: p.Name}}
+	if p.Type != "string" {
+		m = append(m, yaml.MapItem{Key: "type", Value: p.Type})
+	}
+	if p.Optional {
+		m = append(m, yaml.MapItem{Key: "optional", Value: true})
+	}
+
+	return m, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (p *Param) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var node interface{}
+	if err := unmarshal(&node); err != nil {
+		return err
+	}
+
+	e, err := exprFromYAML(node)
+	if err != nil {
+		return err
+	}
+
+	param, ok := e.(*Param)
+	if !ok {
+		return errors.New("expected a param")
+	}
+
+	*p = *param
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (v *Value) MarshalYAML() (interface{}, error) {
+	switch v.Type {
+	case "string":
+		return v.Data, nil
+	case "bool":
+		b, err := strconv.ParseBool(v.Data)
+		if err != nil {
+			return nil, err
+		}
+		return b, nil
+	case "int64":
+		n, err := strconv.ParseInt(v.Data, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return n, nil
+	case "float64":
+		f, err := strconv.ParseFloat(v.Data, 64)
+		if err != nil {
+			return nil, err
+		}
+		// floats without decimals would be decoded as int64 values
+		if f != math.Trunc(f) {
+			return f, nil
+		}
+		return yaml.MapSlice{{Key: v.Type, Value: f}}, nil
+	}
+
+	return yaml.MapSlice{{Key: v.Type, Value: v.Data}}, nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (v *Value) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var node interface{}
+	if err := unmarshal(&node); err != nil {
+		return err
+	}
+
+	e, err := exprFromYAML(node)
+	if err != nil {
+		return err
+	}
+
+	value, ok := e.(*Value)
+	if !ok {
+		return errors.New("expected a value")
+	}
+
+	*v = *value
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (r *Rule) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	tree := struct {
+		Expr        interface{} `yaml:"expr"`
+		Result      interface{} `yaml:"result"`
+		Priority    int64       `yaml:"priority"`
+		ID          string      `yaml:"id"`
+		Name        string      `yaml:"name"`
+		Description string      `yaml:"description"`
+		Enabled     *bool       `yaml:"enabled"`
+		ValidFrom   *time.Time  `yaml:"valid-from"`
+		ValidUntil  *time.Time  `yaml:"valid-until"`
+	}{}
+
+	err := unmarshal(&tree)
+	if err != nil {
+		return err
+	}
+
+	r.Expr, err = exprFromYAML(tree.Expr)
+	if err != nil {
+		return err
+	}
+	r.Result, err = exprFromYAML(tree.Result)
+	if err != nil {
+		return err
+	}
+
+	r.Priority = tree.Priority
+	r.ID = tree.ID
+	r.Name = tree.Name
+	r.Description = tree.Description
+	r.Enabled = tree.Enabled
+	r.ValidFrom = tree.ValidFrom
+	r.ValidUntil = tree.ValidUntil
+
+	_, err = r.TypeCheck()
+	return err
+}
+
+func isValueType(typ string) bool {
+	switch typ {
+	case "string", "bool", "int64", "float64", "time", "duration", "version", "point", "polygon", "object",
+		"string-list", "int64-list", "float64-list":
+		return true
+	}
+
+	return false
+}
+
+// exprFromYAML builds an expression from its YAML representation, decoded by the yaml package.
+func exprFromYAML(node interface{}) (Expr, error) {
+	switch t := node.(type) {
+	case string:
+		return StringValue(t), nil
+	case bool:
+		return BoolValue(t), nil
+	case int:
+		return Int64Value(int64(t)), nil
+	case int64:
+		return Int64Value(t), nil
+	case float64:
+		return Float64Value(t), nil
+	case map[interface{}]interface{}:
+		return mapFromYAML(t)
+	case nil:
+		return nil, errors.New("missing expression")
+	}
+
+	return nil, fmt.Errorf("unexpected expression %v", node)
+}
+
+func mapFromYAML(m map[interface{}]interface{}) (Expr, error) {
+	if _, ok := m["param"]; ok {
+		return paramFromYAML(m)
+	}
+
+	if len(m) != 1 {
+		return nil, errors.New("an expression must be a map with a single key")
+	}
+
+	var kind string
+	var arg interface{}
+	for k, v := range m {
+		kind, arg = fmt.Sprint(k), v
+	}
+
+	if isValueType(kind) {
+		return valueFromYAML(kind, arg)
+	}
+
+	args, ok := arg.([]interface{})
+	if !ok {
+		args = []interface{}{arg}
+	}
+
+	op := struct {
+		Kind     string `json:"kind"`
+		Operands []Expr `json:"operands"`
+	}{Kind: kind}
+
+	for _, a := range args {
+		e, err := exprFromYAML(a)
+		if err != nil {
+			return nil, err
+		}
+		op.Operands = append(op.Operands, e)
+	}
+
+	// operators are built from their JSON representation, which validates them
+	data, err := json.Marshal(&op)
+	if err != nil {
+		return nil, err
+	}
+
+	return unmarshalExpr(kind, data)
+}
+
+func paramFromYAML(m map[interface{}]interface{}) (Expr, error) {
+	p := Param{Kind: "param", Type: "string"}
+
+	for k, v := range m {
+		var ok bool
+		switch k {
+		case "param":
+			p.Name, ok = v.(string)
+		case "type":
+			p.Type, ok = v.(string)
+		case "optional":
+			p.Optional, ok = v.(bool)
+		default:
+			return nil, fmt.Errorf("unexpected param field %v", k)
+		}
+
+		if !ok {
+			return nil, fmt.Errorf("invalid param field %v", k)
+		}
+	}
+
+	return &p, nil
+}
+
+// valueFromYAML builds a value of the given type. Its data can be a scalar, or a sequence or a map that
+// is converted into its JSON representation, for lists and objects.
+func valueFromYAML(typ string, data interface{}) (Expr, error) {
+	var s string
+	switch t := data.(type) {
+	case string:
+		s = t
+	case bool:
+		s = strconv.FormatBool(t)
+	case int:
+		s = strconv.Itoa(t)
+	case int64:
+		s = strconv.FormatInt(t, 10)
+	case float64:
+		s = strconv.FormatFloat(t, 'g', -1, 64)
+	case []interface{}, map[interface{}]interface{}:
+		raw, err := json.Marshal(jsonFromYAML(t))
+		if err != nil {
+			return nil, err
+		}
+		s = string(raw)
+	default:
+		return nil, fmt.Errorf("invalid %s value %v", typ, data)
+	}
+
+	switch typ {
+	case "bool":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool value %s", s)
+		}
+		return BoolValue(b), nil
+	case "int64":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int64 value %s", s)
+		}
+		return Int64Value(n), nil
+	case "float64":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float64 value %s", s)
+		}
+		return Float64Value(f), nil
+	}
+
+	return newValue(typ, s), nil
+}
+
+// jsonFromYAML converts the maps decoded by the yaml package, which keys can be of any type,
+// into maps that can be encoded in JSON.
+func jsonFromYAML(node interface{}) interface{} {
+	switch t := node.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			m[fmt.Sprint(k)] = jsonFromYAML(v)
+		}
+		return m
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, v := range t {
+			l[i] = jsonFromYAML(v)
+		}
+		return l
+	}
+
+	return node
+}
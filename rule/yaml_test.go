@@ -0,0 +1,103 @@
+package rule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
+)
+
+func TestExprYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		expr rule.Expr
+	}{
+		{"string", `paris`, rule.StringValue("paris")},
+		{"quoted string", `"true"`, rule.StringValue("true")},
+		{"bool", `true`, rule.BoolValue(true)},
+		{"int64", `10`, rule.Int64Value(10)},
+		{"float64", `1.5`, rule.Float64Value(1.5)},
+		{"float64 without decimals", `{float64: 3}`, rule.Float64Value(3)},
+		{"duration", `{duration: 1h0m0s}`, rule.DurationValue(time.Hour)},
+		{"list", `{string-list: '["a","b"]'}`, rule.StringListValue("a", "b")},
+		{"list as sequence", `{int64-list: [1, 2]}`, rule.Int64ListValue(1, 2)},
+		{"param", `{param: city}`, rule.StringParam("city")},
+		{"typed param", `{param: age, type: int64}`, rule.Int64Param("age")},
+		{"optional param", `{param: promo, optional: true}`, rule.Optional(rule.StringParam("promo"))},
+		{"operator", `{eq: [{param: city}, paris]}`, rule.Eq(rule.StringParam("city"), rule.StringValue("paris"))},
+		{"single operand", `{not: {param: surge, type: bool}}`, rule.Not(rule.BoolParam("surge"))},
+		{"nested", `{and: [{gte: [{param: age, type: int64}, 18]}, {in: [{param: city}, paris, lyon]}]}`, rule.And(
+			rule.GTE(rule.Int64Param("age"), rule.Int64Value(18)),
+			rule.In(rule.StringParam("city"), rule.StringValue("paris"), rule.StringValue("lyon")),
+		)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var r rule.Rule
+			err := yaml.Unmarshal([]byte("expr: true\nresult: "+test.src), &r)
+			require.NoError(t, err)
+			require.Equal(t, test.expr, r.Result)
+
+			raw, err := yaml.Marshal(&r)
+			require.NoError(t, err)
+
+			var r2 rule.Rule
+			err = yaml.Unmarshal(raw, &r2)
+			require.NoError(t, err)
+			require.Equal(t, test.expr, r2.Result)
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			src  string
+		}{
+			{"missing expr", `result: 1`},
+			{"unknown kind", `{expr: {kiwi: [1]}, result: 1}`},
+			{"many keys", `{expr: {eq: [1, 1], not: true}, result: 1}`},
+			{"sequence", `{expr: [true], result: 1}`},
+			{"invalid value", `{expr: true, result: {int64: a}}`},
+			{"invalid param", `{expr: {param: a, kind: b}, result: 1}`},
+			{"invalid pattern", `{expr: {matches: [{param: a}, "a("]}, result: 1}`},
+			{"type error", `{expr: {eq: [{param: a}, 1]}, result: 1}`},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				var r rule.Rule
+				err := yaml.Unmarshal([]byte(test.src), &r)
+				require.Error(t, err)
+			})
+		}
+	})
+}
+
+func TestRuleYAML(t *testing.T) {
+	from := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	r := rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.Float64Value(2.5))
+	r.ID = "paris"
+	r.Priority = 10
+	r.ValidFrom = &from
+
+	raw, err := yaml.Marshal(r)
+	require.NoError(t, err)
+	require.Equal(t, `expr:
+  eq:
+  - param: city
+  - paris
+result: 2.5
+priority: 10
+id: paris
+valid-from: 2019-01-01T00:00:00Z
+`, string(raw))
+
+	var r2 rule.Rule
+	err = yaml.Unmarshal(raw, &r2)
+	require.NoError(t, err)
+	require.Equal(t, r, &r2)
+}
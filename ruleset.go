@@ -3,16 +3,54 @@ package regula
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/heetch/regula/rule"
 )
 
 // A Ruleset is list of rules that must return the same type.
 type Ruleset struct {
-	Rules []*rule.Rule `json:"rules"`
-	Type  string       `json:"type"`
+	Rules []*rule.Rule `json:"rules" yaml:"rules"`
+	Type  string       `json:"type" yaml:"type"`
+
+	// ParamSpecs declares the params expected by the ruleset along with their constraints.
+	// When set, the rules can only use the declared params and the params passed during evaluation must satisfy them.
+	ParamSpecs []ParamSpec `json:"params,omitempty" yaml:"params,omitempty"`
+
+	// Schema associates the name of each field of the objects returned by an object ruleset with its type.
+	// It is required by object rulesets and forbidden otherwise.
+	Schema map[string]string `json:"schema,omitempty" yaml:"schema,omitempty"`
+
+	// MissingParams is the policy applied when a rule uses a param that is not defined.
+	// It defaults to MissingParamsError.
+	MissingParams string `json:"missing-params,omitempty" yaml:"missing-params,omitempty"`
+
+	// Strategy selects how the rules are evaluated and how their results are combined.
+	// It defaults to StrategyFirstMatch.
+	Strategy string `json:"strategy,omitempty" yaml:"strategy,omitempty"`
+
+	// Default is the result returned when no rule matches, if any.
+	// It must be of the result type of the ruleset, see ResultType.
+	Default *rule.Value `json:"default,omitempty" yaml:"default,omitempty"`
+
+	// Tests holds examples of evaluation of the ruleset, checked before a new version is stored, see RunTests.
+	Tests []TestCase `json:"tests,omitempty" yaml:"tests,omitempty"`
+
+	// checks holds the param specs ready to be checked during evaluation, prepared once by validate.
+	checks []paramCheck
 }
 
+// Policies applied by a ruleset when a rule uses a param that is not defined.
+// Optional params never cause an error: the rules using them don't match.
+const (
+	// MissingParamsError stops the evaluation of the ruleset and returns rule.ErrParamNotFound.
+	MissingParamsError = "error"
+	// MissingParamsNoMatch considers that the rule doesn't match and evaluates the next one.
+	MissingParamsNoMatch = "no-match"
+)
+
 // NewStringRuleset creates a ruleset which rules all return a string otherwise
 // ErrRulesetIncoherentType is returned.
 func NewStringRuleset(rules ...*rule.Rule) (*Ruleset, error) {
@@ -51,17 +89,95 @@ func newRuleset(typ string, rules ...*rule.Rule) (*Ruleset, error) {
 	return &rs, nil
 }
 
-// Eval evaluates every rule of the ruleset until one matches.
-// It returns rule.ErrNoMatch if no rule matches the given context.
+// Eval evaluates the rules of the ruleset according to its strategy, by default until one matches.
+// Disabled rules and rules outside of their validity window are ignored.
+// If no rule matches the given context, it returns the default result of the ruleset or rule.ErrNoMatch if there is none.
 func (r *Ruleset) Eval(params rule.Params) (*rule.Value, error) {
-	for _, rl := range r.Rules {
-		res, err := rl.Eval(params)
-		if err != rule.ErrNoMatch {
-			return res, err
+	res, _, err := r.EvalRule(params)
+	return res, err
+}
+
+// EvalRule evaluates the ruleset like Eval and also returns the rule that matched,
+// or the first one with strategies combining several rules. The rule is nil if the default result is returned.
+func (r *Ruleset) EvalRule(params rule.Params) (*rule.Value, *rule.Rule, error) {
+	if err := r.checkParams(params); err != nil {
+		return nil, nil, err
+	}
+
+	res, i, err := r.evalRules(r.order(), func(i int) (*rule.Value, error) {
+		return r.Rules[i].Eval(params)
+	})
+	if i == -1 {
+		return res, nil, err
+	}
+
+	return res, r.Rules[i], err
+}
+
+// checkParams ensures the given params satisfy the constraints of the param specs of the ruleset.
+// It returns a *ParamError if it is not the case.
+func (r *Ruleset) checkParams(params rule.Params) error {
+	if len(r.ParamSpecs) == 0 {
+		return nil
+	}
+
+	checks := r.checks
+	if checks == nil {
+		// the ruleset wasn't validated, e.g. it was created without using a constructor
+		var err error
+		checks, err = r.paramChecks()
+		if err != nil {
+			return err
 		}
 	}
 
-	return nil, rule.ErrNoMatch
+	return checkParams(checks, params)
+}
+
+// skip reports whether the error returned by a rule must be ignored according to the missing params policy.
+func (r *Ruleset) skip(err error) bool {
+	return err == rule.ErrParamNotFound && r.MissingParams == MissingParamsNoMatch
+}
+
+// A RulesetTrace explains the evaluation of a ruleset.
+type RulesetTrace struct {
+	// Rule is the index of the rule that matched, -1 if none did.
+	// With strategies combining the results of several rules, it is the first rule that matched.
+	Rule int `json:"rule"`
+	// Matches holds the indexes of all the rules that matched, with strategies combining their results.
+	Matches []int `json:"matches,omitempty"`
+	// Rules holds the traces of the evaluated rules, in order of evaluation, which isn't the order of the rules
+	// with the priority strategy. Inactive rules aren't evaluated. The index of each rule is recorded in its trace.
+	// The rules preceding the matching one show why they didn't match.
+	Rules []*rule.RuleTrace `json:"rules"`
+	// Default reports whether no rule matched and the default result of the ruleset was returned.
+	Default bool `json:"default,omitempty"`
+}
+
+// EvalTrace evaluates the ruleset like Eval and returns the trace of the evaluation.
+// The trace is returned even if the evaluation fails.
+func (r *Ruleset) EvalTrace(params rule.Params) (*rule.Value, *RulesetTrace, error) {
+	trace := RulesetTrace{
+		Rule: -1,
+	}
+
+	if err := r.checkParams(params); err != nil {
+		return nil, &trace, err
+	}
+
+	res, i, err := r.evalRules(r.order(), func(i int) (*rule.Value, error) {
+		res, rt, err := r.Rules[i].EvalTrace(params)
+		rt.Index = i
+		trace.Rules = append(trace.Rules, rt)
+		if err == nil && r.combines() {
+			trace.Matches = append(trace.Matches, i)
+		}
+		return res, err
+	})
+	trace.Rule = i
+	trace.Default = err == nil && i == -1
+
+	return res, &trace, err
 }
 
 // UnmarshalJSON implements the json.Unmarshaler interface.
@@ -71,13 +187,316 @@ func (r *Ruleset) UnmarshalJSON(data []byte) error {
 		return err
 	}
 
-	if r.Type != "string" && r.Type != "bool" && r.Type != "int64" && r.Type != "float64" {
+	if !isSupportedType(r.Type) {
+		return errors.New("unsupported ruleset type")
+	}
+
+	return r.validate()
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+// The YAML representation of the expressions is more compact than the JSON one, e.g. {eq: [{param: city}, paris]}.
+func (r *Ruleset) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type ruleset Ruleset
+	if err := unmarshal((*ruleset)(r)); err != nil {
+		return err
+	}
+
+	if !isSupportedType(r.Type) {
 		return errors.New("unsupported ruleset type")
 	}
 
 	return r.validate()
 }
 
+// ParseRuleset parses a ruleset written in the rule syntax, one rule per line.
+// The type of the ruleset can be declared before the rules using the type keyword,
+// otherwise it is deduced from the result of the first rule.
+// The fields of object rulesets are declared using the schema keyword, e.g. schema radius:float64 drivers:int64.
+// The params expected by the ruleset are declared using the param keyword, one per line, followed by their constraints,
+// e.g. param city:string enum "paris" "lyon" or param age:int64? min 18 max 99 description "Age of the rider".
+// Test cases are declared using the test keyword, one per line, followed by an optional name, the params
+// and the expected result or no-match, e.g. test "paris riders" city="paris" age=30 -> 3.0.
+// The evaluation strategy can be declared using the strategy keyword, the missing params policy
+// using the missing-params keyword, and the result returned when no rule matches using the default keyword.
+//
+//	type float64
+//	strategy priority
+//	missing-params no-match
+//	default 1.0
+//
+//	city == "paris" and driver-status in ("gold", "silver") -> 3.0
+//	true -> 1.0
+func ParseRuleset(src string) (*Ruleset, error) {
+	lines := strings.Split(src, "\n")
+
+	var typ, strategy, policy string
+	var schema map[string]string
+	var def *rule.Value
+	var specs []ParamSpec
+	var tests []TestCase
+header:
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if idx := strings.IndexByte(line, '#'); idx != -1 {
+			line = strings.TrimSpace(line[:idx])
+		}
+		if line == "" {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			break
+		}
+
+		switch fields[0] {
+		case "default":
+			def = parseDefault(lines[i])
+			if def == nil {
+				break header
+			}
+		case "param":
+			raw := lines[i][strings.Index(lines[i], "param")+len("param"):]
+			spec, ok, err := parseParamSpec(raw)
+			if !ok {
+				break header
+			}
+			if err != nil {
+				return nil, headerError(lines[i], i, "invalid param declaration: "+err.Error())
+			}
+			specs = append(specs, *spec)
+		case "test":
+			raw := lines[i][strings.Index(lines[i], "test")+len("test"):]
+			tc, ok, err := parseTestCase(raw)
+			if !ok {
+				break header
+			}
+			if err != nil {
+				return nil, headerError(lines[i], i, "invalid test case: "+err.Error())
+			}
+			tests = append(tests, *tc)
+		case "type":
+			if len(fields) != 2 {
+				break header
+			}
+			typ = fields[1]
+			if !isSupportedType(typ) {
+				return nil, headerError(lines[i], i, "unsupported ruleset type "+typ)
+			}
+		case "schema":
+			s, ok, err := parseSchema(fields[1:])
+			if !ok {
+				break header
+			}
+			if err != nil {
+				return nil, headerError(lines[i], i, "invalid schema: "+err.Error())
+			}
+			schema = s
+		case "strategy":
+			if len(fields) != 2 {
+				break header
+			}
+			strategy = fields[1]
+			if !isSupportedStrategy(strategy) {
+				return nil, headerError(lines[i], i, "unsupported strategy "+strategy)
+			}
+		case "missing-params":
+			if len(fields) != 2 {
+				break header
+			}
+			policy = fields[1]
+			if !isSupportedPolicy(policy) {
+				return nil, headerError(lines[i], i, "unsupported missing params policy "+policy)
+			}
+		default:
+			break header
+		}
+
+		// blank the header to preserve the position of the rules.
+		lines[i] = ""
+	}
+
+	rules, err := rule.ParseRules(strings.Join(lines, "\n"))
+	if err != nil {
+		return nil, err
+	}
+
+	if typ == "" {
+		if len(rules) == 0 {
+			return nil, errors.New("missing ruleset type")
+		}
+		typ, err = rules[0].ResultType()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rs := Ruleset{
+		Rules:         rules,
+		Type:          typ,
+		ParamSpecs:    specs,
+		Schema:        schema,
+		MissingParams: policy,
+		Strategy:      strategy,
+		Default:       def,
+		Tests:         tests,
+	}
+
+	err = rs.validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rs, nil
+}
+
+// parseDefault parses the value of the default header found on the given line.
+// It returns nil if the line doesn't hold a value, for instance if it is a rule using a param named default,
+// in which case the line is parsed, and errors are reported, along with the rules.
+func parseDefault(line string) *rule.Value {
+	line = line[strings.Index(line, "default")+len("default"):]
+
+	e, err := rule.Parse(line)
+	if err != nil {
+		return nil
+	}
+
+	v, _ := e.(*rule.Value)
+	return v
+}
+
+// headerError returns a parse error pointing to the value of the header found on the given line.
+func headerError(line string, i int, msg string) error {
+	fields := strings.Fields(line)
+	col := strings.Index(line, fields[0]) + len(fields[0])
+	col += strings.Index(line[col:], fields[1])
+
+	return &rule.ParseError{
+		Line:   i + 1,
+		Column: col + 1,
+		Msg:    msg,
+	}
+}
+
+// a headerToken is a field of a header line. Quoted fields are unquoted,
+// raw holds the field as written.
+type headerToken struct {
+	text   string
+	raw    string
+	quoted bool
+}
+
+// splitHeader splits the line into whitespace separated fields. Fields can be double quoted strings
+// or contain some, like name="a b". A # outside of a string starts a comment.
+func splitHeader(line string) ([]headerToken, error) {
+	var tokens []headerToken
+
+	for {
+		line = strings.TrimLeft(line, " \t\r")
+		if line == "" || line[0] == '#' {
+			return tokens, nil
+		}
+
+		end := 0
+		for end < len(line) && !strings.ContainsRune(" \t\r#", rune(line[end])) {
+			if line[end] != '"' {
+				end++
+				continue
+			}
+
+			n, err := quotedLen(line[end:])
+			if err != nil {
+				return nil, err
+			}
+			end += n
+		}
+
+		tok := headerToken{text: line[:end], raw: line[:end]}
+		if n, _ := quotedLen(tok.raw); n == len(tok.raw) {
+			s, err := strconv.Unquote(tok.raw)
+			if err != nil {
+				return nil, err
+			}
+			tok.text, tok.quoted = s, true
+		}
+
+		tokens = append(tokens, tok)
+		line = line[end:]
+	}
+}
+
+// quotedLen returns the length of the double quoted string s starts with, quotes included.
+func quotedLen(s string) (int, error) {
+	if s == "" || s[0] != '"' {
+		return 0, errors.New("expected string")
+	}
+
+	end := 1
+	for end < len(s) && s[end] != '"' {
+		if s[end] == '\\' {
+			end++
+		}
+		end++
+	}
+	if end >= len(s) {
+		return 0, errors.New("unterminated string")
+	}
+
+	return end + 1, nil
+}
+
+// FormatRuleset returns the representation of the ruleset in the rule syntax.
+// The output can be parsed back using ParseRuleset.
+func FormatRuleset(rs *Ruleset) (string, error) {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "type %s\n", rs.Type)
+	if len(rs.Schema) > 0 {
+		fmt.Fprintf(&b, "schema %s\n", formatSchema(rs.Schema))
+	}
+	if rs.Strategy != "" {
+		fmt.Fprintf(&b, "strategy %s\n", rs.Strategy)
+	}
+	if rs.MissingParams != "" {
+		fmt.Fprintf(&b, "missing-params %s\n", rs.MissingParams)
+	}
+	if rs.Default != nil {
+		s, err := rule.Format(rs.Default)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "default %s\n", s)
+	}
+	for i := range rs.ParamSpecs {
+		b.WriteString(formatParamSpec(&rs.ParamSpecs[i]))
+		b.WriteByte('\n')
+	}
+	for i := range rs.Tests {
+		s, err := formatTestCase(&rs.Tests[i])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+	if len(rs.Rules) > 0 {
+		b.WriteByte('\n')
+	}
+
+	for _, rl := range rs.Rules {
+		s, err := rule.FormatRule(rl)
+		if err != nil {
+			return "", err
+		}
+
+		b.WriteString(s)
+		b.WriteByte('\n')
+	}
+
+	return b.String(), nil
+}
+
 // Params returns a list of all the parameters used in all the underlying rules.
 func (r *Ruleset) Params() []rule.Param {
 	bm := make(map[string]bool)
@@ -96,23 +515,106 @@ func (r *Ruleset) Params() []rule.Param {
 	return params
 }
 
+func isSupportedType(typ string) bool {
+	return typ == "string" || typ == "bool" || typ == "int64" || typ == "float64" || typ == "object"
+}
+
+func isSupportedPolicy(policy string) bool {
+	return policy == "" || policy == MissingParamsError || policy == MissingParamsNoMatch
+}
+
 func (r *Ruleset) validate() error {
-	paramTypes := make(map[string]string)
+	if !isSupportedPolicy(r.MissingParams) {
+		return fmt.Errorf("unsupported missing params policy %q", r.MissingParams)
+	}
 
-	for _, rl := range r.Rules {
-		if rl.Result.Type != r.Type {
+	if err := r.validateStrategy(); err != nil {
+		return err
+	}
+
+	if err := r.validateSchema(); err != nil {
+		return err
+	}
+
+	if r.Default != nil {
+		typ, err := rule.TypeCheck(r.Default)
+		if err != nil {
+			if te, ok := err.(*rule.TypeError); ok {
+				return &rule.TypeError{Path: "/default" + te.Path, Msg: te.Msg}
+			}
+			return err
+		}
+
+		if typ != r.ResultType() {
+			return ErrRulesetIncoherentType
+		}
+
+		if r.Type == "object" {
+			if err := r.checkObject(r.Default, "/default"); err != nil {
+				return err
+			}
+		}
+	}
+
+	checks, err := r.paramChecks()
+	if err != nil {
+		return err
+	}
+	r.checks = nil
+	if len(checks) > 0 {
+		r.checks = checks
+	}
+
+	if err := r.validateTests(); err != nil {
+		return err
+	}
+
+	params := make(map[string]rule.Param)
+	ids := make(map[string]bool)
+
+	for i, rl := range r.Rules {
+		if rl.ID != "" {
+			if ids[rl.ID] {
+				return fmt.Errorf("/rules/%d: duplicate rule id %q", i, rl.ID)
+			}
+			ids[rl.ID] = true
+		}
+
+		if rl.ValidFrom != nil && rl.ValidUntil != nil && !rl.ValidFrom.Before(*rl.ValidUntil) {
+			return fmt.Errorf("/rules/%d: the validity window ends before it starts", i)
+		}
+
+		typ, err := rl.TypeCheck()
+		if err != nil {
+			if te, ok := err.(*rule.TypeError); ok {
+				return &rule.TypeError{Path: fmt.Sprintf("/rules/%d%s", i, te.Path), Msg: te.Msg}
+			}
+			return err
+		}
+
+		if typ != r.Type {
 			return ErrRulesetIncoherentType
 		}
 
+		if r.Type == "object" {
+			if err := r.checkObject(rl.Result, fmt.Sprintf("/rules/%d/result", i)); err != nil {
+				return err
+			}
+		}
+
+		if err := r.CheckDeclaredParams(rl); err != nil {
+			return fmt.Errorf("/rules/%d: %v", i, err)
+		}
+
 		ps := rl.Params()
 		for _, p := range ps {
-			tp, ok := paramTypes[p.Name]
+			prev, ok := params[p.Name]
 			if ok {
-				if p.Type != tp {
+				if p.Type != prev.Type || p.Optional != prev.Optional {
 					return ErrRulesetIncoherentType
 				}
 			} else {
-				paramTypes[p.Name] = p.Type
+				params[p.Name] = p
 			}
 		}
 	}
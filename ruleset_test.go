@@ -2,10 +2,13 @@ package regula
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/heetch/regula/rule"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v2"
 )
 
 func TestRulesetEval(t *testing.T) {
@@ -41,6 +44,44 @@ func TestRulesetEval(t *testing.T) {
 		require.Equal(t, ErrRulesetIncoherentType, err)
 	})
 
+	t.Run("Computed result", func(t *testing.T) {
+		r, err := NewFloat64Ruleset(
+			rule.New(rule.BoolParam("surge"), rule.Mul(rule.Float64Param("base"), rule.Float64Value(1.5))),
+			rule.New(rule.True(), rule.Float64Param("base")),
+		)
+		require.NoError(t, err)
+
+		res, err := r.Eval(Params{"surge": true, "base": 2.0})
+		require.NoError(t, err)
+		require.Equal(t, rule.Float64Value(3), res)
+
+		res, err = r.Eval(Params{"surge": false, "base": 2.0})
+		require.NoError(t, err)
+		require.Equal(t, rule.Float64Value(2), res)
+	})
+
+	t.Run("Computed result type mismatch", func(t *testing.T) {
+		_, err := NewFloat64Ruleset(
+			rule.New(rule.True(), rule.Add(rule.Int64Param("base"), rule.Int64Value(1))),
+		)
+		require.Equal(t, ErrRulesetIncoherentType, err)
+	})
+
+	t.Run("Invalid pattern", func(t *testing.T) {
+		_, err := NewBoolRuleset(
+			rule.New(rule.Matches(rule.StringParam("email"), "a("), rule.BoolValue(true)),
+		)
+		require.Error(t, err)
+	})
+
+	t.Run("Type error", func(t *testing.T) {
+		_, err := NewBoolRuleset(
+			rule.New(rule.True(), rule.BoolValue(true)),
+			rule.New(rule.Not(rule.StringParam("a")), rule.BoolValue(true)),
+		)
+		require.Equal(t, &rule.TypeError{Path: "/rules/1/expr/operands/0", Msg: "not expects bool, got string"}, err)
+	})
+
 	t.Run("No match", func(t *testing.T) {
 		r, err := NewStringRuleset(
 			rule.New(rule.Eq(rule.StringValue("foo"), rule.StringValue("bar")), rule.StringValue("first")),
@@ -64,6 +105,249 @@ func TestRulesetEval(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, "default", res.Data)
 	})
+
+	t.Run("Default result", func(t *testing.T) {
+		r, err := NewStringRuleset(
+			rule.New(rule.Eq(rule.StringValue("foo"), rule.StringValue("bar")), rule.StringValue("first")),
+		)
+		require.NoError(t, err)
+		r.Default = rule.StringValue("default")
+
+		res, rl, err := r.EvalRule(nil)
+		require.NoError(t, err)
+		require.Equal(t, "default", res.Data)
+		// no rule matched
+		require.Nil(t, rl)
+	})
+
+	t.Run("Default result type mismatch", func(t *testing.T) {
+		r := Ruleset{Type: "string", Default: rule.Int64Value(1)}
+		require.Equal(t, ErrRulesetIncoherentType, r.validate())
+
+		r = Ruleset{Type: "int64", Default: &rule.Value{Type: "int64", Data: "one"}}
+		require.Equal(t, &rule.TypeError{Path: "/default", Msg: `invalid int64 value "one"`}, r.validate())
+	})
+
+	t.Run("Missing params", func(t *testing.T) {
+		r, err := NewStringRuleset(
+			rule.New(rule.BoolParam("surge"), rule.StringValue("first")),
+			rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.StringValue("second")),
+			rule.New(rule.True(), rule.StringValue("default")),
+		)
+		require.NoError(t, err)
+
+		_, err = r.Eval(Params{"city": "paris"})
+		require.Equal(t, rule.ErrParamNotFound, err)
+
+		r.MissingParams = MissingParamsNoMatch
+		res, err := r.Eval(Params{"city": "paris"})
+		require.NoError(t, err)
+		require.Equal(t, "second", res.Data)
+
+		res, err = r.Eval(Params{})
+		require.NoError(t, err)
+		require.Equal(t, "default", res.Data)
+
+		raw, err := json.Marshal(r)
+		require.NoError(t, err)
+		require.Contains(t, string(raw), `"missing-params":"no-match"`)
+	})
+
+	t.Run("Optional params", func(t *testing.T) {
+		r, err := NewStringRuleset(
+			rule.New(rule.Optional(rule.BoolParam("surge")), rule.StringValue("first")),
+			rule.New(rule.True(), rule.StringValue("default")),
+		)
+		require.NoError(t, err)
+
+		res, err := r.Eval(Params{})
+		require.NoError(t, err)
+		require.Equal(t, "default", res.Data)
+	})
+
+	t.Run("Incoherent optional params", func(t *testing.T) {
+		_, err := NewStringRuleset(
+			rule.New(rule.Optional(rule.BoolParam("surge")), rule.StringValue("first")),
+			rule.New(rule.BoolParam("surge"), rule.StringValue("second")),
+		)
+		require.Equal(t, ErrRulesetIncoherentType, err)
+	})
+
+	t.Run("Unsupported missing params policy", func(t *testing.T) {
+		r := Ruleset{Type: "string", MissingParams: "ignore"}
+		require.EqualError(t, r.validate(), `unsupported missing params policy "ignore"`)
+	})
+}
+
+func TestRulesetMetadata(t *testing.T) {
+	defer func(fn func() time.Time) { rule.NowFunc = fn }(rule.NowFunc)
+	now := time.Date(2026, 12, 15, 0, 0, 0, 0, time.UTC)
+	rule.NowFunc = func() time.Time { return now }
+
+	disabled := false
+	from, until := now.Add(-time.Hour), now.Add(time.Hour)
+
+	rs, err := NewStringRuleset(
+		&rule.Rule{Expr: rule.True(), Result: rule.StringValue("disabled"), ID: "a", Enabled: &disabled},
+		&rule.Rule{Expr: rule.True(), Result: rule.StringValue("expired"), ID: "b", ValidUntil: &from},
+		&rule.Rule{Expr: rule.True(), Result: rule.StringValue("scheduled"), ID: "c", ValidFrom: &until},
+		&rule.Rule{Expr: rule.True(), Result: rule.StringValue("current"), ID: "d", ValidFrom: &from, ValidUntil: &until},
+		rule.New(rule.True(), rule.StringValue("default")),
+	)
+	require.NoError(t, err)
+
+	res, rl, err := rs.EvalRule(Params{})
+	require.NoError(t, err)
+	require.Equal(t, "current", res.Data)
+	require.Equal(t, rs.Rules[3], rl)
+
+	p, err := rs.Compile()
+	require.NoError(t, err)
+	res, rl, err = p.EvalRule(Params{})
+	require.NoError(t, err)
+	require.Equal(t, "current", res.Data)
+	require.Equal(t, rs.Rules[3], rl)
+
+	_, trace, err := rs.EvalTrace(Params{})
+	require.NoError(t, err)
+	require.Equal(t, 3, trace.Rule)
+	require.Len(t, trace.Rules, 1)
+	require.Equal(t, "d", trace.Rules[0].ID)
+
+	now = until
+	res, rl, err = rs.EvalRule(Params{})
+	require.NoError(t, err)
+	require.Equal(t, "scheduled", res.Data)
+	require.Equal(t, "c", rl.ID)
+
+	t.Run("Default", func(t *testing.T) {
+		rs := Ruleset{Type: "string", Rules: rs.Rules[:1], Default: rule.StringValue("none")}
+
+		res, rl, err := rs.EvalRule(Params{})
+		require.NoError(t, err)
+		require.Equal(t, "none", res.Data)
+		require.Nil(t, rl)
+	})
+
+	t.Run("Duplicate ids", func(t *testing.T) {
+		_, err := NewStringRuleset(
+			&rule.Rule{Expr: rule.True(), Result: rule.StringValue("a"), ID: "a"},
+			&rule.Rule{Expr: rule.True(), Result: rule.StringValue("b"), ID: "a"},
+		)
+		require.EqualError(t, err, `/rules/1: duplicate rule id "a"`)
+	})
+
+	t.Run("Invalid window", func(t *testing.T) {
+		_, err := NewStringRuleset(
+			&rule.Rule{Expr: rule.True(), Result: rule.StringValue("a"), ValidFrom: &until, ValidUntil: &from},
+		)
+		require.EqualError(t, err, "/rules/0: the validity window ends before it starts")
+	})
+
+	t.Run("Parse and format", func(t *testing.T) {
+		src := `type string
+
+@id "a"
+@enabled false
+true -> "disabled"
+@id "d"
+@name "Current"
+@valid-from "2026-12-14T23:00:00Z"
+@valid-until "2026-12-15T01:00:00Z"
+true -> "current"
+`
+		rs, err := ParseRuleset(src)
+		require.NoError(t, err)
+		require.Len(t, rs.Rules, 2)
+		require.Equal(t, "Current", rs.Rules[1].Name)
+
+		s, err := FormatRuleset(rs)
+		require.NoError(t, err)
+		require.Equal(t, src, s)
+	})
+}
+
+func TestRulesetEvalTrace(t *testing.T) {
+	r, err := NewFloat64Ruleset(
+		rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("lyon")), rule.Float64Value(3)),
+		rule.New(rule.And(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.BoolParam("surge")), rule.Float64Value(2)),
+		rule.New(rule.True(), rule.Float64Param("base")),
+	)
+	require.NoError(t, err)
+
+	t.Run("Match", func(t *testing.T) {
+		res, trace, err := r.EvalTrace(Params{"city": "paris", "surge": false, "base": 1.5})
+		require.NoError(t, err)
+		require.Equal(t, rule.Float64Value(1.5), res)
+		require.Equal(t, &RulesetTrace{
+			Rule: 2,
+			Rules: []*rule.RuleTrace{
+				{
+					Expr: &rule.Trace{
+						Kind:  "eq",
+						Value: rule.BoolValue(false),
+						Operands: []*rule.Trace{
+							{Kind: "param", Name: "city", Value: rule.StringValue("paris")},
+							{Kind: "value", Value: rule.StringValue("lyon")},
+						},
+					},
+				},
+				{
+					Index: 1,
+					Expr: &rule.Trace{
+						Kind:  "and",
+						Value: rule.BoolValue(false),
+						Operands: []*rule.Trace{
+							{
+								Kind:  "eq",
+								Value: rule.BoolValue(true),
+								Operands: []*rule.Trace{
+									{Kind: "param", Name: "city", Value: rule.StringValue("paris")},
+									{Kind: "value", Value: rule.StringValue("paris")},
+								},
+							},
+							{Kind: "param", Name: "surge", Value: rule.BoolValue(false)},
+						},
+					},
+				},
+				{
+					Index:   2,
+					Expr:    &rule.Trace{Kind: "value", Value: rule.BoolValue(true)},
+					Matched: true,
+					Result:  &rule.Trace{Kind: "param", Name: "base", Value: rule.Float64Value(1.5)},
+				},
+			},
+		}, trace)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		_, trace, err := r.EvalTrace(Params{"city": "paris"})
+		require.Equal(t, rule.ErrParamNotFound, err)
+		require.Equal(t, -1, trace.Rule)
+		require.Len(t, trace.Rules, 2)
+		require.Equal(t, rule.ErrParamNotFound.Error(), trace.Rules[1].Expr.Operands[1].Error)
+	})
+
+	t.Run("Default result", func(t *testing.T) {
+		r := *r
+		r.Rules = r.Rules[:2]
+		r.Default = rule.Float64Value(1)
+		res, trace, err := r.EvalTrace(Params{"city": "nice"})
+		require.NoError(t, err)
+		require.Equal(t, rule.Float64Value(1), res)
+		require.Equal(t, -1, trace.Rule)
+		require.True(t, trace.Default)
+		require.Len(t, trace.Rules, 2)
+	})
+
+	t.Run("Missing params no match", func(t *testing.T) {
+		r := *r
+		r.MissingParams = MissingParamsNoMatch
+		_, trace, err := r.EvalTrace(Params{"city": "paris"})
+		require.Equal(t, rule.ErrNoMatch, err)
+		require.Equal(t, -1, trace.Rule)
+		require.Len(t, trace.Rules, 3)
+	})
 }
 
 func TestRulesetEncDec(t *testing.T) {
@@ -73,6 +357,7 @@ func TestRulesetEncDec(t *testing.T) {
 		rule.New(rule.True(), rule.StringValue("default")),
 	)
 	require.NoError(t, err)
+	r1.Default = rule.StringValue("none")
 
 	raw, err := json.Marshal(r1)
 	require.NoError(t, err)
@@ -84,16 +369,204 @@ func TestRulesetEncDec(t *testing.T) {
 	require.Equal(t, r1, &r2)
 }
 
+func TestRulesetYAML(t *testing.T) {
+	src := `type: float64
+params:
+- {name: city, type: string, enum: [paris, lyon]}
+- {name: age, type: int64, min: 18}
+default: 1.0
+rules:
+- expr: {and: [{eq: [{param: city}, paris]}, {gte: [{param: age, type: int64}, 21]}]}
+  result: 3.5
+- expr: {in: [{param: city}, paris, lyon]}
+  result: {float64: 2}
+  name: french cities
+tests:
+- params: {city: lyon, age: 30}
+  expected: {float64: 2}
+`
+	var r1 Ruleset
+	err := yaml.Unmarshal([]byte(src), &r1)
+	require.NoError(t, err)
+
+	r2, err := ParseRuleset(`
+		type float64
+		default 1.0
+		param city:string enum "paris" "lyon"
+		param age:int64 min 18
+		test age="30" city="lyon" -> 2.0
+
+		city == "paris" and age:int64 >= 21 -> 3.5
+		city in ("paris", "lyon") -> 2.0
+	`)
+	require.NoError(t, err)
+	r2.Rules[1].Name = "french cities"
+	require.Equal(t, r2, &r1)
+
+	raw, err := yaml.Marshal(&r1)
+	require.NoError(t, err)
+
+	var r3 Ruleset
+	err = yaml.Unmarshal(raw, &r3)
+	require.NoError(t, err)
+	require.Equal(t, r1, r3)
+
+	err = yaml.Unmarshal([]byte("type: string\nrules:\n- {expr: true, result: 1}\n"), &r3)
+	require.Equal(t, ErrRulesetIncoherentType, err)
+}
+
 func TestRulesetParams(t *testing.T) {
 	r1, err := NewStringRuleset(
-		rule.New(rule.Eq(rule.StringParam("foo"), rule.Int64Param("bar")), rule.StringValue("first")),
-		rule.New(rule.Eq(rule.StringParam("foo"), rule.Float64Param("baz")), rule.StringValue("second")),
-		rule.New(rule.True(), rule.StringValue("default")),
+		rule.New(rule.And(rule.Eq(rule.StringParam("foo"), rule.StringValue("a")), rule.GT(rule.Int64Param("bar"), rule.Int64Value(0))), rule.StringValue("first")),
+		rule.New(rule.And(rule.Eq(rule.StringParam("foo"), rule.StringValue("b")), rule.GT(rule.Float64Param("baz"), rule.Float64Value(0))), rule.StringValue("second")),
+		rule.New(rule.True(), rule.StringParam("qux")),
 	)
 	require.NoError(t, err)
 	require.Equal(t, []rule.Param{
 		*rule.StringParam("foo"),
 		*rule.Int64Param("bar"),
 		*rule.Float64Param("baz"),
+		*rule.StringParam("qux"),
 	}, r1.Params())
 }
+
+func TestParseRuleset(t *testing.T) {
+	t.Run("OK", func(t *testing.T) {
+		rs, err := ParseRuleset(`
+			# marketplace radius
+			type float64
+
+			city == "paris" and driver-status in ("gold", "silver") -> 3.0
+			true -> 1.0
+		`)
+		require.NoError(t, err)
+
+		exp, err := NewFloat64Ruleset(
+			rule.New(
+				rule.And(
+					rule.Eq(rule.StringParam("city"), rule.StringValue("paris")),
+					rule.In(rule.StringParam("driver-status"), rule.StringValue("gold"), rule.StringValue("silver")),
+				),
+				rule.Float64Value(3),
+			),
+			rule.New(rule.True(), rule.Float64Value(1)),
+		)
+		require.NoError(t, err)
+		require.Equal(t, exp, rs)
+	})
+
+	t.Run("Inferred type", func(t *testing.T) {
+		rs, err := ParseRuleset(`true -> "default"`)
+		require.NoError(t, err)
+		require.Equal(t, "string", rs.Type)
+	})
+
+	t.Run("Unsupported type", func(t *testing.T) {
+		_, err := ParseRuleset("\n  type int32\n")
+		require.Equal(t, &rule.ParseError{Line: 2, Column: 8, Msg: "unsupported ruleset type int32"}, err)
+	})
+
+	t.Run("Missing params policy", func(t *testing.T) {
+		rs, err := ParseRuleset("type string\nmissing-params no-match\n\npromo? == \"a\" -> \"a\"")
+		require.NoError(t, err)
+		require.Equal(t, MissingParamsNoMatch, rs.MissingParams)
+		require.Equal(t, []rule.Param{*rule.Optional(rule.StringParam("promo"))}, rs.Params())
+	})
+
+	t.Run("Default result", func(t *testing.T) {
+		rs, err := ParseRuleset("type string\ndefault \"a # b\" # comment\n\ncity == \"paris\" -> \"c\"")
+		require.NoError(t, err)
+		require.Equal(t, rule.StringValue("a # b"), rs.Default)
+		require.Len(t, rs.Rules, 1)
+
+		// a rule using a param named default
+		rs, err = ParseRuleset("type string\ndefault == \"a\" -> \"c\"")
+		require.NoError(t, err)
+		require.Nil(t, rs.Default)
+		require.Len(t, rs.Rules, 1)
+
+		_, err = ParseRuleset("type string\ndefault 1\ntrue -> \"a\"")
+		require.Equal(t, ErrRulesetIncoherentType, err)
+	})
+
+	t.Run("Unsupported missing params policy", func(t *testing.T) {
+		_, err := ParseRuleset("type string\nmissing-params  ignore\n")
+		require.Equal(t, &rule.ParseError{Line: 2, Column: 17, Msg: "unsupported missing params policy ignore"}, err)
+	})
+
+	t.Run("Incoherent types", func(t *testing.T) {
+		_, err := ParseRuleset("type string\ntrue -> 1")
+		require.Equal(t, ErrRulesetIncoherentType, err)
+	})
+
+	t.Run("Syntax error", func(t *testing.T) {
+		_, err := ParseRuleset("type string\n\ncity == -> \"a\"")
+		require.EqualError(t, err, "3:9: unexpected '->'")
+	})
+
+	t.Run("Missing type", func(t *testing.T) {
+		_, err := ParseRuleset("# nothing")
+		require.Error(t, err)
+	})
+}
+
+func TestFormatRuleset(t *testing.T) {
+	rs, err := NewInt64Ruleset(
+		rule.New(rule.Eq(rule.StringParam("foo"), rule.StringValue("bar")), rule.Int64Value(1)),
+		rule.New(rule.GT(rule.Int64Param("score"), rule.Int64Value(10)), rule.Int64Value(2)),
+		rule.New(rule.True(), rule.Int64Value(3)),
+	)
+	require.NoError(t, err)
+
+	s, err := FormatRuleset(rs)
+	require.NoError(t, err)
+	require.Equal(t, `type int64
+
+foo == "bar" -> 1
+score:int64 > 10 -> 2
+true -> 3
+`, s)
+
+	rs2, err := ParseRuleset(s)
+	require.NoError(t, err)
+	require.Equal(t, rs, rs2)
+
+	t.Run("Default result", func(t *testing.T) {
+		rs := *rs
+		rs.Default = rule.Int64Value(-1)
+		s, err := FormatRuleset(&rs)
+		require.NoError(t, err)
+		require.Equal(t, "type int64\ndefault -1\n\n", s[:strings.Index(s, "foo")])
+
+		rs2, err := ParseRuleset(s)
+		require.NoError(t, err)
+		require.Equal(t, &rs, rs2)
+	})
+
+	t.Run("Missing params policy", func(t *testing.T) {
+		rs.MissingParams = MissingParamsNoMatch
+		s, err := FormatRuleset(rs)
+		require.NoError(t, err)
+		require.Equal(t, "type int64\nmissing-params no-match\n\n", s[:strings.Index(s, "foo")])
+
+		rs2, err := ParseRuleset(s)
+		require.NoError(t, err)
+		require.Equal(t, rs, rs2)
+	})
+
+	t.Run("Keyword param names", func(t *testing.T) {
+		rs, err := NewInt64Ruleset(
+			rule.New(rule.And(rule.BoolParam("not"), rule.Eq(rule.StringParam("in"), rule.StringValue("a"))), rule.Int64Param("and")),
+			rule.New(rule.True(), rule.Int64Value(3)),
+		)
+		require.NoError(t, err)
+
+		s, err := FormatRuleset(rs)
+		require.NoError(t, err)
+		require.Equal(t, "type int64\n\n`not`:bool and `in` == \"a\" -> `and`:int64\ntrue -> 3\n", s)
+
+		rs2, err := ParseRuleset(s)
+		require.NoError(t, err)
+		require.Equal(t, rs, rs2)
+	})
+}
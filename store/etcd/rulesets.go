@@ -9,6 +9,8 @@ import (
 	"path"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/coreos/etcd/clientv3"
 	"github.com/coreos/etcd/clientv3/concurrency"
@@ -26,6 +28,21 @@ type RulesetService struct {
 	Client    *clientv3.Client
 	Logger    zerolog.Logger
 	Namespace string
+
+	// RejectFindings lists the kinds of findings reported by regula.Analyze that make Put fail
+	// with a validation error. By default, rulesets are not analyzed.
+	RejectFindings []string
+
+	// programs caches the compiled latest version of the rulesets, by path.
+	// Older versions are compiled on each evaluation, so that the cache doesn't grow with the number of versions.
+	mu       sync.Mutex
+	programs map[string]*cachedProgram
+}
+
+// cachedProgram is a compiled ruleset version.
+type cachedProgram struct {
+	version string
+	eval    evaluator
 }
 
 // List returns all the rulesets entries under the given prefix.
@@ -155,6 +172,16 @@ func (s *RulesetService) Put(ctx context.Context, path string, ruleset *regula.R
 		return nil, err
 	}
 
+	err = s.analyze(ruleset)
+	if err != nil {
+		return nil, err
+	}
+
+	err = runTests(ruleset)
+	if err != nil {
+		return nil, err
+	}
+
 	var entry store.RulesetEntry
 
 	txfn := func(stm concurrency.STM) error {
@@ -181,6 +208,7 @@ func (s *RulesetService) Put(ctx context.Context, path string, ruleset *regula.R
 
 		// make sure signature didn't change
 		rawSig := stm.Get(s.signaturesPath(path))
+		updated := rawSig == ""
 		if rawSig != "" {
 			var curSig signature
 			err := json.Unmarshal([]byte(rawSig), &curSig)
@@ -193,10 +221,14 @@ func (s *RulesetService) Put(ctx context.Context, path string, ruleset *regula.R
 			if err != nil {
 				return err
 			}
+
+			// new optional params become part of the signature
+			updated = curSig.extend(sig)
+			sig = &curSig
 		}
 
 		// if no signature found, create one
-		if rawSig == "" {
+		if updated {
 			v, err := json.Marshal(&sig)
 			if err != nil {
 				return errors.Wrap(err, "failed to encode updated signature")
@@ -243,23 +275,106 @@ func (s *RulesetService) Put(ctx context.Context, path string, ruleset *regula.R
 	return &entry, err
 }
 
+// analyze returns a validation error for the first finding of the analysis of the ruleset
+// which kind must be rejected.
+func (s *RulesetService) analyze(rs *regula.Ruleset) error {
+	if len(s.RejectFindings) == 0 {
+		return nil
+	}
+
+	for _, f := range regula.Analyze(rs) {
+		for _, kind := range s.RejectFindings {
+			if f.Kind != kind {
+				continue
+			}
+
+			if f.Rule == -1 {
+				return &store.ValidationError{
+					Field:  "ruleset",
+					Value:  f.Kind,
+					Reason: f.Message,
+				}
+			}
+
+			return &store.ValidationError{
+				Field:  "rule",
+				Value:  strconv.Itoa(f.Rule),
+				Reason: fmt.Sprintf("%s: %s", f.Kind, f.Message),
+			}
+		}
+	}
+
+	return nil
+}
+
+// runTests runs the test cases of the ruleset and returns a validation error listing those which failed.
+func runTests(rs *regula.Ruleset) error {
+	failures := rs.RunTests()
+	if len(failures) == 0 {
+		return nil
+	}
+
+	cases := make([]string, len(failures))
+	reasons := make([]string, len(failures))
+	for i, f := range failures {
+		cases[i] = strconv.Itoa(f.Case)
+		reasons[i] = f.String()
+	}
+
+	return &store.ValidationError{
+		Field:  "tests",
+		Value:  strings.Join(cases, ","),
+		Reason: strings.Join(reasons, "; "),
+	}
+}
+
 type signature struct {
-	ReturnType string
-	ParamTypes map[string]string
+	ReturnType     string
+	ParamTypes     map[string]string
+	OptionalParams map[string]bool   `json:",omitempty"`
+	MissingParams  string            `json:",omitempty"`
+	Schema         map[string]string `json:",omitempty"`
 }
 
+// newSignature returns the signature of the ruleset. Declared params are part of it
+// even if no rule uses them.
 func newSignature(rs *regula.Ruleset) *signature {
 	pt := make(map[string]string)
+	op := make(map[string]bool)
+	for _, p := range rs.ParamSpecs {
+		pt[p.Name] = p.Type
+		if p.Optional {
+			op[p.Name] = true
+		}
+	}
 	for _, p := range rs.Params() {
 		pt[p.Name] = p.Type
+		if p.Optional {
+			op[p.Name] = true
+		}
 	}
 
 	return &signature{
-		ParamTypes: pt,
-		ReturnType: rs.Type,
+		ParamTypes:     pt,
+		OptionalParams: op,
+		ReturnType:     rs.ResultType(),
+		MissingParams:  rs.MissingParams,
+		Schema:         rs.Schema,
 	}
 }
 
+// missingParams returns the missing params policy of the signature.
+// Signatures created before the introduction of the policy use the default one.
+func (s *signature) missingParams() string {
+	if s.MissingParams == "" {
+		return regula.MissingParamsError
+	}
+
+	return s.MissingParams
+}
+
+// matchWith ensures the other signature is compatible with s: it can use new params only if they are optional,
+// and it can make required params optional, but not the other way around.
 func (s *signature) matchWith(other *signature) error {
 	if s.ReturnType != other.ReturnType {
 		return &store.ValidationError{
@@ -269,9 +384,29 @@ func (s *signature) matchWith(other *signature) error {
 		}
 	}
 
+	if s.missingParams() != other.missingParams() {
+		return &store.ValidationError{
+			Field:  "missing params policy",
+			Value:  other.missingParams(),
+			Reason: fmt.Sprintf("signature mismatch: missing params policy must be %s", s.missingParams()),
+		}
+	}
+
+	if !sameSchema(s.Schema, other.Schema) {
+		return &store.ValidationError{
+			Field:  "schema",
+			Value:  fmt.Sprint(other.Schema),
+			Reason: fmt.Sprintf("signature mismatch: schema must be %v", s.Schema),
+		}
+	}
+
 	for name, tp := range other.ParamTypes {
 		stp, ok := s.ParamTypes[name]
 		if !ok {
+			if other.OptionalParams[name] {
+				continue
+			}
+
 			return &store.ValidationError{
 				Field:  "param",
 				Value:  name,
@@ -286,11 +421,53 @@ func (s *signature) matchWith(other *signature) error {
 				Reason: fmt.Sprintf("signature mismatch: param must be of type %s", stp),
 			}
 		}
+
+		if s.OptionalParams[name] && !other.OptionalParams[name] {
+			return &store.ValidationError{
+				Field:  "param",
+				Value:  name,
+				Reason: "signature mismatch: param must be optional",
+			}
+		}
 	}
 
 	return nil
 }
 
+func sameSchema(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for name, typ := range a {
+		if b[name] != typ {
+			return false
+		}
+	}
+
+	return true
+}
+
+// extend adds to s the optional params of other it doesn't know about and reports whether s changed.
+func (s *signature) extend(other *signature) bool {
+	var changed bool
+
+	for name, tp := range other.ParamTypes {
+		if _, ok := s.ParamTypes[name]; ok || !other.OptionalParams[name] {
+			continue
+		}
+
+		if s.OptionalParams == nil {
+			s.OptionalParams = make(map[string]bool)
+		}
+		s.ParamTypes[name] = tp
+		s.OptionalParams[name] = true
+		changed = true
+	}
+
+	return changed
+}
+
 func validateRuleset(path string, rs *regula.Ruleset) (*signature, error) {
 	err := validateRulesetName(path)
 	if err != nil {
@@ -299,12 +476,47 @@ func validateRuleset(path string, rs *regula.Ruleset) (*signature, error) {
 
 	sig := newSignature(rs)
 
-	for _, r := range rs.Rules {
+	declared := make([]rule.Param, len(rs.ParamSpecs))
+	for i, p := range rs.ParamSpecs {
+		declared[i] = rule.Param{Kind: "param", Type: p.Type, Name: p.Name}
+	}
+	err = validateParamNames(declared)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range rs.Rules {
+		typ, err := r.TypeCheck()
+		if err != nil {
+			return nil, &store.ValidationError{
+				Field:  "rule",
+				Value:  strconv.Itoa(i),
+				Reason: err.Error(),
+			}
+		}
+
+		if typ != rs.Type {
+			return nil, &store.ValidationError{
+				Field:  "result type",
+				Value:  typ,
+				Reason: fmt.Sprintf("rule result must be of type %s", rs.Type),
+			}
+		}
+
 		params := r.Params()
 		err = validateParamNames(params)
 		if err != nil {
 			return nil, err
 		}
+
+		err = rs.CheckDeclaredParams(r)
+		if err != nil {
+			return nil, &store.ValidationError{
+				Field:  "rule",
+				Value:  strconv.Itoa(i),
+				Reason: err.Error(),
+			}
+		}
 	}
 
 	return sig, nil
@@ -427,20 +639,34 @@ func (s *RulesetService) Eval(ctx context.Context, path string, params rule.Para
 		return nil, err
 	}
 
-	v, err := re.Ruleset.Eval(params)
+	return s.eval(re, params)
+}
+
+// EvalVersion evaluates a ruleset given a path and a set of parameters. It implements the regula.Evaluator interface.
+func (s *RulesetService) EvalVersion(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error) {
+	re, err := s.OneByVersion(ctx, path, version)
 	if err != nil {
+		if err == store.ErrNotFound {
+			return nil, regula.ErrRulesetNotFound
+		}
+
 		return nil, err
 	}
 
-	return &regula.EvalResult{
-		Value:   v,
-		Version: re.Version,
-	}, nil
+	return s.eval(re, params)
 }
 
-// EvalVersion evaluates a ruleset given a path and a set of parameters. It implements the regula.Evaluator interface.
-func (s *RulesetService) EvalVersion(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error) {
-	re, err := s.OneByVersion(ctx, path, version)
+// EvalTrace evaluates a ruleset given a path, an optional version and a set of parameters, and returns the trace of the evaluation.
+// It implements the regula.TraceEvaluator interface.
+func (s *RulesetService) EvalTrace(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error) {
+	var re *store.RulesetEntry
+	var err error
+
+	if version != "" {
+		re, err = s.OneByVersion(ctx, path, version)
+	} else {
+		re, err = s.Latest(ctx, path)
+	}
 	if err != nil {
 		if err == store.ErrNotFound {
 			return nil, regula.ErrRulesetNotFound
@@ -449,15 +675,76 @@ func (s *RulesetService) EvalVersion(ctx context.Context, path, version string,
 		return nil, err
 	}
 
-	v, err := re.Ruleset.Eval(params)
+	v, trace, err := re.Ruleset.EvalTrace(params)
+	res := regula.EvalResult{
+		Version: re.Version,
+		Trace:   trace,
+	}
+	if err != nil {
+		// the trace explains why the evaluation failed
+		return &res, err
+	}
+
+	res.Value = v
+	res.Default = trace.Default
+	if trace.Rule != -1 {
+		res.RuleID = re.Ruleset.Rules[trace.Rule].ID
+	}
+
+	return &res, nil
+}
+
+// eval evaluates the given ruleset entry and returns the result.
+func (s *RulesetService) eval(re *store.RulesetEntry, params rule.Params) (*regula.EvalResult, error) {
+	v, rl, err := s.program(re).EvalRule(params)
 	if err != nil {
 		return nil, err
 	}
 
-	return &regula.EvalResult{
+	res := regula.EvalResult{
 		Value:   v,
 		Version: re.Version,
-	}, nil
+		Default: rl == nil,
+	}
+	if rl != nil {
+		res.RuleID = rl.ID
+	}
+
+	return &res, nil
+}
+
+// program returns the compiled version of the given ruleset entry.
+// Rulesets that can't be compiled are evaluated as is.
+func (s *RulesetService) program(re *store.RulesetEntry) evaluator {
+	s.mu.Lock()
+	p, ok := s.programs[re.Path]
+	s.mu.Unlock()
+	if ok && p.version == re.Version {
+		return p.eval
+	}
+
+	compiled, err := re.Ruleset.Compile()
+	if err != nil {
+		return re.Ruleset
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// versions are ordered by creation time
+	if p, ok := s.programs[re.Path]; !ok || p.version < re.Version {
+		if s.programs == nil {
+			s.programs = make(map[string]*cachedProgram)
+		}
+		s.programs[re.Path] = &cachedProgram{version: re.Version, eval: compiled}
+	}
+
+	return compiled
+}
+
+// evaluator is implemented by rulesets and their compiled version.
+type evaluator interface {
+	EvalRule(params rule.Params) (*rule.Value, *rule.Rule, error)
 }
 
 func (s *RulesetService) rulesetsPath(p, v string) string {
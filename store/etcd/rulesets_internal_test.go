@@ -93,4 +93,66 @@ func TestValidation(t *testing.T) {
 			}
 		}
 	})
+
+	t.Run("NOK - result type", func(t *testing.T) {
+		rs := regula.Ruleset{
+			Type: "string",
+			Rules: []*rule.Rule{
+				rule.New(rule.True(), rule.StringValue("a")),
+				rule.New(rule.True(), rule.Add(rule.Int64Param("b"), rule.Int64Value(1))),
+			},
+		}
+
+		_, err := validateRuleset("path/to/ruleset", &rs)
+		require.True(t, store.IsValidationError(err))
+	})
+
+	t.Run("NOK - invalid pattern", func(t *testing.T) {
+		rs := regula.Ruleset{
+			Type: "bool",
+			Rules: []*rule.Rule{
+				rule.New(rule.Matches(rule.StringParam("email"), "a("), rule.BoolValue(true)),
+			},
+		}
+
+		_, err := validateRuleset("path/to/ruleset", &rs)
+		require.True(t, store.IsValidationError(err))
+	})
+
+	t.Run("NOK - type error", func(t *testing.T) {
+		rs := regula.Ruleset{
+			Type: "bool",
+			Rules: []*rule.Rule{
+				rule.New(rule.GT(rule.Int64Param("a"), rule.StringValue("b")), rule.BoolValue(true)),
+			},
+		}
+
+		_, err := validateRuleset("path/to/ruleset", &rs)
+		require.Equal(t, &store.ValidationError{
+			Field:  "rule",
+			Value:  "0",
+			Reason: "/expr/operands/1: gt expects operands of the same type, int64, got string",
+		}, err)
+	})
+}
+
+func TestProgramCache(t *testing.T) {
+	rs, err := regula.ParseRuleset(`true -> "a"`)
+	require.NoError(t, err)
+
+	var s RulesetService
+	v1 := &store.RulesetEntry{Path: "a", Version: "1", Ruleset: rs}
+	v2 := &store.RulesetEntry{Path: "a", Version: "2", Ruleset: rs}
+
+	p := s.program(v2)
+	require.True(t, p == s.program(v2))
+
+	// older versions are evaluated without replacing the latest one
+	s.program(v1)
+	require.Len(t, s.programs, 1)
+	require.Equal(t, "2", s.programs["a"].version)
+
+	s.program(&store.RulesetEntry{Path: "a", Version: "3", Ruleset: rs})
+	require.Len(t, s.programs, 1)
+	require.Equal(t, "3", s.programs["a"].version)
 }
@@ -351,9 +351,9 @@ func TestPut(t *testing.T) {
 		rs1, err := regula.NewBoolRuleset(
 			rule.New(
 				rule.Eq(
-					rule.StringParam("a"),
-					rule.BoolParam("b"),
-					rule.Int64Param("c"),
+					rule.FNV(rule.StringParam("a")),
+					rule.FNV(rule.BoolParam("b")),
+					rule.FNV(rule.Int64Param("c")),
 				),
 				rule.BoolValue(true),
 			),
@@ -367,9 +367,9 @@ func TestPut(t *testing.T) {
 		rs2, err := regula.NewStringRuleset(
 			rule.New(
 				rule.Eq(
-					rule.StringParam("a"),
-					rule.BoolParam("b"),
-					rule.Int64Param("c"),
+					rule.FNV(rule.StringParam("a")),
+					rule.FNV(rule.BoolParam("b")),
+					rule.FNV(rule.Int64Param("c")),
 				),
 				rule.StringValue("true"),
 			),
@@ -383,10 +383,10 @@ func TestPut(t *testing.T) {
 		rs3, err := regula.NewBoolRuleset(
 			rule.New(
 				rule.Eq(
-					rule.StringParam("a"),
-					rule.BoolParam("b"),
-					rule.Int64Param("c"),
-					rule.BoolParam("d"),
+					rule.FNV(rule.StringParam("a")),
+					rule.FNV(rule.BoolParam("b")),
+					rule.FNV(rule.Int64Param("c")),
+					rule.FNV(rule.BoolParam("d")),
 				),
 				rule.BoolValue(true),
 			),
@@ -400,10 +400,10 @@ func TestPut(t *testing.T) {
 		rs4, err := regula.NewBoolRuleset(
 			rule.New(
 				rule.Eq(
-					rule.StringParam("a"),
-					rule.StringParam("b"),
-					rule.Int64Param("c"),
-					rule.BoolParam("d"),
+					rule.FNV(rule.StringParam("a")),
+					rule.FNV(rule.StringParam("b")),
+					rule.FNV(rule.Int64Param("c")),
+					rule.FNV(rule.BoolParam("d")),
 				),
 				rule.BoolValue(true),
 			),
@@ -417,19 +417,19 @@ func TestPut(t *testing.T) {
 		rs5, err := regula.NewBoolRuleset(
 			rule.New(
 				rule.Eq(
-					rule.StringParam("a"),
-					rule.StringParam("b"),
-					rule.Int64Param("c"),
-					rule.BoolParam("d"),
+					rule.FNV(rule.StringParam("a")),
+					rule.FNV(rule.StringParam("b")),
+					rule.FNV(rule.Int64Param("c")),
+					rule.FNV(rule.BoolParam("d")),
 				),
 				rule.BoolValue(true),
 			),
 			rule.New(
 				rule.Eq(
-					rule.StringParam("a"),
-					rule.StringParam("b"),
-					rule.Int64Param("c"),
-					rule.BoolParam("d"),
+					rule.FNV(rule.StringParam("a")),
+					rule.FNV(rule.StringParam("b")),
+					rule.FNV(rule.Int64Param("c")),
+					rule.FNV(rule.BoolParam("d")),
 				),
 				rule.BoolValue(true),
 			),
@@ -442,15 +442,15 @@ func TestPut(t *testing.T) {
 		rs6, _ := regula.NewBoolRuleset(
 			rule.New(
 				rule.Eq(
-					rule.StringParam("a"),
-					rule.BoolParam("b"),
+					rule.FNV(rule.StringParam("a")),
+					rule.FNV(rule.BoolParam("b")),
 				),
 				rule.BoolValue(true),
 			),
 			rule.New(
 				rule.Eq(
-					rule.StringParam("a"),
-					rule.BoolParam("b"),
+					rule.FNV(rule.StringParam("a")),
+					rule.FNV(rule.BoolParam("b")),
 				),
 				rule.BoolValue(true),
 			),
@@ -459,6 +459,198 @@ func TestPut(t *testing.T) {
 		_, err = s.Put(context.Background(), path, rs6)
 		require.NoError(t, err)
 	})
+
+	t.Run("Optional params signatures", func(t *testing.T) {
+		path := "c"
+		rs1, err := regula.NewBoolRuleset(
+			rule.New(rule.BoolParam("a"), rule.BoolValue(true)),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs1)
+		require.NoError(t, err)
+
+		// adding a new optional param
+		rs2, err := regula.NewBoolRuleset(
+			rule.New(rule.BoolParam("a"), rule.BoolValue(true)),
+			rule.New(rule.GT(rule.Optional(rule.Int64Param("b")), rule.Int64Value(1)), rule.BoolValue(true)),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs2)
+		require.NoError(t, err)
+
+		// making the new optional param required
+		rs3, err := regula.NewBoolRuleset(
+			rule.New(rule.BoolParam("a"), rule.BoolValue(true)),
+			rule.New(rule.GT(rule.Int64Param("b"), rule.Int64Value(1)), rule.BoolValue(true)),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs3)
+		require.True(t, store.IsValidationError(err))
+
+		// changing the type of the new optional param
+		rs4, err := regula.NewBoolRuleset(
+			rule.New(rule.BoolParam("a"), rule.BoolValue(true)),
+			rule.New(rule.Optional(rule.BoolParam("b")), rule.BoolValue(true)),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs4)
+		require.True(t, store.IsValidationError(err))
+
+		// making a required param optional
+		rs5, err := regula.NewBoolRuleset(
+			rule.New(rule.Optional(rule.BoolParam("a")), rule.BoolValue(true)),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs5)
+		require.NoError(t, err)
+
+		// changing the missing params policy
+		rs6, err := regula.NewBoolRuleset(
+			rule.New(rule.BoolParam("a"), rule.BoolValue(true)),
+		)
+		require.NoError(t, err)
+		rs6.MissingParams = regula.MissingParamsNoMatch
+
+		_, err = s.Put(context.Background(), path, rs6)
+		require.True(t, store.IsValidationError(err))
+	})
+
+	t.Run("Strategy signatures", func(t *testing.T) {
+		path := "d"
+		rs1, err := regula.NewInt64Ruleset(
+			rule.New(rule.BoolParam("a"), rule.Int64Value(1)),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs1)
+		require.NoError(t, err)
+
+		// aggregating the results doesn't change the return type
+		rs1.Strategy = regula.StrategySum
+		_, err = s.Put(context.Background(), path, rs1)
+		require.NoError(t, err)
+
+		// returning a list does
+		rs1.Strategy = regula.StrategyAllMatches
+		_, err = s.Put(context.Background(), path, rs1)
+		require.True(t, store.IsValidationError(err))
+	})
+
+	t.Run("Schema signatures", func(t *testing.T) {
+		path := "e"
+		rs1, err := regula.NewObjectRuleset(map[string]string{"radius": "float64"},
+			rule.New(rule.True(), rule.ObjectValue(map[string]interface{}{"radius": 3.5})),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs1)
+		require.NoError(t, err)
+
+		rs2, err := regula.NewObjectRuleset(map[string]string{"radius": "float64"},
+			rule.New(rule.True(), rule.ObjectValue(map[string]interface{}{"radius": 5})),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs2)
+		require.NoError(t, err)
+
+		// adding a field
+		rs3, err := regula.NewObjectRuleset(map[string]string{"radius": "float64", "drivers": "int64"},
+			rule.New(rule.True(), rule.ObjectValue(map[string]interface{}{"radius": 5, "drivers": 3})),
+		)
+		require.NoError(t, err)
+
+		_, err = s.Put(context.Background(), path, rs3)
+		require.True(t, store.IsValidationError(err))
+	})
+
+	t.Run("Analysis", func(t *testing.T) {
+		rs, err := regula.NewBoolRuleset(
+			rule.New(rule.True(), rule.BoolValue(true)),
+			rule.New(rule.BoolParam("a"), rule.BoolValue(false)),
+		)
+		require.NoError(t, err)
+
+		s.RejectFindings = []string{regula.FindingShadowed}
+		defer func() { s.RejectFindings = nil }()
+
+		_, err = s.Put(context.Background(), "analysis", rs)
+		require.NoError(t, err)
+
+		s.RejectFindings = append(s.RejectFindings, regula.FindingUnreachable)
+		_, err = s.Put(context.Background(), "analysis", rs)
+		require.Equal(t, &store.ValidationError{
+			Field:  "rule",
+			Value:  "1",
+			Reason: "unreachable: the rule is never evaluated, rule 0 always matches",
+		}, err)
+	})
+
+	t.Run("Test cases", func(t *testing.T) {
+		rs, err := regula.NewInt64Ruleset(
+			rule.New(rule.Eq(rule.StringParam("city"), rule.StringValue("paris")), rule.Int64Value(1)),
+		)
+		require.NoError(t, err)
+		rs.Tests = []regula.TestCase{
+			{Name: "paris", Params: map[string]string{"city": "paris"}, Expected: rule.Int64Value(1)},
+			{Params: map[string]string{"city": "lyon"}},
+		}
+
+		_, err = s.Put(context.Background(), "tests", rs)
+		require.NoError(t, err)
+
+		rs.Rules[0].Result = rule.Int64Value(2)
+		rs.Rules = append(rs.Rules, rule.New(rule.True(), rule.Int64Value(3)))
+		_, err = s.Put(context.Background(), "tests", rs)
+		require.Equal(t, &store.ValidationError{
+			Field:  "tests",
+			Value:  "0,1",
+			Reason: `test "paris": expected 1, got 2; test #1: expected no match, got 3`,
+		}, err)
+	})
+
+	t.Run("Declared params signatures", func(t *testing.T) {
+		path := "f"
+		rs1 := &regula.Ruleset{
+			Type:       "bool",
+			ParamSpecs: []regula.ParamSpec{{Name: "a", Type: "bool"}, {Name: "b", Type: "int64"}},
+			Rules:      []*rule.Rule{rule.New(rule.BoolParam("a"), rule.BoolValue(true))},
+		}
+
+		_, err := s.Put(context.Background(), path, rs1)
+		require.NoError(t, err)
+
+		// the unused param is part of the signature
+		rs2 := &regula.Ruleset{
+			Type:       "bool",
+			ParamSpecs: []regula.ParamSpec{{Name: "a", Type: "bool"}, {Name: "b", Type: "string"}},
+			Rules:      []*rule.Rule{rule.New(rule.BoolParam("a"), rule.BoolValue(true))},
+		}
+
+		_, err = s.Put(context.Background(), path, rs2)
+		require.True(t, store.IsValidationError(err))
+
+		// rules can only use declared params
+		rs1.Rules = append(rs1.Rules, rule.New(rule.BoolParam("c"), rule.BoolValue(false)))
+		_, err = s.Put(context.Background(), path, rs1)
+		require.Equal(t, &store.ValidationError{Field: "rule", Value: "1", Reason: "undeclared param c"}, err)
+
+		// declared params must be used with the same optionality
+		rs1.Rules = []*rule.Rule{rule.New(rule.Has(rule.Optional(rule.BoolParam("a"))), rule.BoolValue(true))}
+		_, err = s.Put(context.Background(), path, rs1)
+		require.Equal(t, &store.ValidationError{Field: "rule", Value: "0", Reason: "param a must not be optional"}, err)
+
+		// declared params names are validated
+		rs1.ParamSpecs = []regula.ParamSpec{{Name: "version", Type: "bool"}}
+		rs1.Rules = nil
+		_, err = s.Put(context.Background(), "g", rs1)
+		require.True(t, store.IsValidationError(err))
+	})
 }
 
 func TestWatch(t *testing.T) {
@@ -519,6 +711,7 @@ func TestEval(t *testing.T) {
 			rule.BoolValue(true),
 		),
 	)
+	rs.Rules[0].ID = "is-123"
 
 	entry := createRuleset(t, s, "a", rs)
 
@@ -529,6 +722,24 @@ func TestEval(t *testing.T) {
 		require.NoError(t, err)
 		require.Equal(t, entry.Version, res.Version)
 		require.Equal(t, rule.BoolValue(true), res.Value)
+		require.False(t, res.Default)
+		require.Equal(t, "is-123", res.RuleID)
+	})
+
+	t.Run("Default", func(t *testing.T) {
+		rs, _ := regula.NewBoolRuleset(
+			rule.New(rule.Eq(rule.StringParam("id"), rule.StringValue("123")), rule.BoolValue(true)),
+		)
+		rs.Default = rule.BoolValue(false)
+		entry := createRuleset(t, s, "b", rs)
+
+		res, err := s.Eval(context.Background(), "b", regula.Params{
+			"id": "456",
+		})
+		require.NoError(t, err)
+		require.Equal(t, entry.Version, res.Version)
+		require.Equal(t, rule.BoolValue(false), res.Value)
+		require.True(t, res.Default)
 	})
 
 	t.Run("NotFound", func(t *testing.T) {
@@ -580,3 +791,53 @@ func TestEvalVersion(t *testing.T) {
 		require.Equal(t, regula.ErrRulesetNotFound, err)
 	})
 }
+
+func TestEvalTrace(t *testing.T) {
+	t.Parallel()
+
+	s, cleanup := newEtcdRulesetService(t)
+	defer cleanup()
+
+	rs, _ := regula.NewBoolRuleset(
+		rule.New(
+			rule.Eq(
+				rule.StringParam("id"),
+				rule.StringValue("123"),
+			),
+			rule.BoolValue(true),
+		),
+	)
+
+	entry := createRuleset(t, s, "a", rs)
+
+	t.Run("OK", func(t *testing.T) {
+		for _, version := range []string{"", entry.Version} {
+			res, err := s.EvalTrace(context.Background(), "a", version, regula.Params{
+				"id": "123",
+			})
+			require.NoError(t, err)
+			require.Equal(t, entry.Version, res.Version)
+			require.Equal(t, rule.BoolValue(true), res.Value)
+			require.Equal(t, 0, res.Trace.Rule)
+			require.True(t, res.Trace.Rules[0].Matched)
+		}
+	})
+
+	t.Run("NoMatch", func(t *testing.T) {
+		res, err := s.EvalTrace(context.Background(), "a", "", regula.Params{
+			"id": "456",
+		})
+		require.Equal(t, rule.ErrNoMatch, err)
+		require.Equal(t, entry.Version, res.Version)
+		require.Nil(t, res.Value)
+		require.Equal(t, -1, res.Trace.Rule)
+		require.False(t, res.Trace.Rules[0].Matched)
+	})
+
+	t.Run("NotFound", func(t *testing.T) {
+		_, err := s.EvalTrace(context.Background(), "a", "someversion", regula.Params{
+			"id": "123",
+		})
+		require.Equal(t, regula.ErrRulesetNotFound, err)
+	})
+}
@@ -49,6 +49,9 @@ type RulesetService interface {
 	Eval(ctx context.Context, path string, params rule.Params) (*regula.EvalResult, error)
 	// EvalVersion evaluates a ruleset given a path and a set of parameters. It implements the regula.Evaluator interface.
 	EvalVersion(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error)
+	// EvalTrace evaluates a ruleset given a path, an optional version and a set of parameters, and returns the trace of the evaluation.
+	// It implements the regula.TraceEvaluator interface.
+	EvalTrace(ctx context.Context, path, version string, params rule.Params) (*regula.EvalResult, error)
 }
 
 // RulesetEntry holds a ruleset and its metadata.
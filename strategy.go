@@ -0,0 +1,227 @@
+package regula
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/heetch/regula/rule"
+)
+
+// Strategies used to evaluate a ruleset.
+const (
+	// StrategyFirstMatch returns the result of the first rule that matches, in order of declaration.
+	StrategyFirstMatch = "first-match"
+	// StrategyPriority returns the result of the first rule that matches, in order of priority.
+	// Rules with the same priority are evaluated in order of declaration.
+	StrategyPriority = "priority"
+	// StrategyAllMatches returns the list of the results of all the rules that match.
+	// It is supported by string, int64 and float64 rulesets.
+	StrategyAllMatches = "all-matches"
+	// StrategySum returns the sum of the results of all the rules that match.
+	// It is supported by int64 and float64 rulesets.
+	StrategySum = "sum"
+	// StrategyMin returns the smallest result of all the rules that match.
+	// It is supported by int64 and float64 rulesets.
+	StrategyMin = "min"
+	// StrategyMax returns the largest result of all the rules that match.
+	// It is supported by int64 and float64 rulesets.
+	StrategyMax = "max"
+)
+
+func isSupportedStrategy(strategy string) bool {
+	switch strategy {
+	case "", StrategyFirstMatch, StrategyPriority, StrategyAllMatches, StrategySum, StrategyMin, StrategyMax:
+		return true
+	}
+
+	return false
+}
+
+// validateStrategy ensures the strategy of the ruleset is supported by its type.
+func (r *Ruleset) validateStrategy() error {
+	if !isSupportedStrategy(r.Strategy) {
+		return fmt.Errorf("unsupported strategy %q", r.Strategy)
+	}
+
+	var ok bool
+	switch r.Strategy {
+	case StrategyAllMatches:
+		ok = r.Type == "string" || r.Type == "int64" || r.Type == "float64"
+	case StrategySum, StrategyMin, StrategyMax:
+		ok = r.Type == "int64" || r.Type == "float64"
+	default:
+		ok = true
+	}
+
+	if !ok {
+		return fmt.Errorf("the %s strategy doesn't support %s rulesets", r.Strategy, r.Type)
+	}
+
+	return nil
+}
+
+// ResultType returns the type of the values returned by the evaluation of the ruleset.
+// It is the type of the ruleset, or a list of it for the all-matches strategy.
+func (r *Ruleset) ResultType() string {
+	if r.Strategy == StrategyAllMatches {
+		return r.Type + "-list"
+	}
+
+	return r.Type
+}
+
+// combines reports whether the strategy evaluates every rule and combines their results.
+func (r *Ruleset) combines() bool {
+	switch r.Strategy {
+	case StrategyAllMatches, StrategySum, StrategyMin, StrategyMax:
+		return true
+	}
+
+	return false
+}
+
+// order returns the indexes of the rules of the ruleset, in the order they must be evaluated.
+func (r *Ruleset) order() []int {
+	order := make([]int, len(r.Rules))
+	for i := range order {
+		order[i] = i
+	}
+
+	if r.Strategy == StrategyPriority {
+		sort.SliceStable(order, func(i, j int) bool {
+			return r.Rules[order[i]].Priority > r.Rules[order[j]].Priority
+		})
+	}
+
+	return order
+}
+
+// evalRules evaluates the active rules in the given order using the eval function,
+// and returns the result selected by the strategy of the ruleset along with the index of the rule that matched,
+// or of the first one with strategies combining several rules. The index is -1 if no rule matched.
+func (r *Ruleset) evalRules(order []int, eval func(i int) (*rule.Value, error)) (*rule.Value, int, error) {
+	var results []*rule.Value
+	first := -1
+	now := rule.NowFunc()
+
+	for _, i := range order {
+		if !r.Rules[i].Active(now) {
+			continue
+		}
+
+		res, err := eval(i)
+		if err == rule.ErrNoMatch || r.skip(err) {
+			continue
+		}
+		if err != nil {
+			return nil, -1, err
+		}
+		if !r.combines() {
+			return res, i, nil
+		}
+
+		if first == -1 {
+			first = i
+		}
+		results = append(results, res)
+	}
+
+	if len(results) > 0 {
+		res, err := combine(r.Strategy, r.Type, results)
+		if err != nil {
+			return nil, -1, err
+		}
+		return res, first, nil
+	}
+
+	if r.Default != nil {
+		return r.Default, -1, nil
+	}
+
+	return nil, -1, rule.ErrNoMatch
+}
+
+// combine merges the results of the matching rules according to the given strategy.
+func combine(strategy, typ string, results []*rule.Value) (*rule.Value, error) {
+	if typ == "string" {
+		l := make([]string, len(results))
+		for i, v := range results {
+			l[i] = v.Data
+		}
+
+		return rule.StringListValue(l...), nil
+	}
+
+	if typ == "int64" {
+		l := make([]int64, len(results))
+		for i, v := range results {
+			n, err := strconv.ParseInt(v.Data, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = n
+		}
+
+		return combineInt64(strategy, l)
+	}
+
+	l := make([]float64, len(results))
+	for i, v := range results {
+		f, err := strconv.ParseFloat(v.Data, 64)
+		if err != nil {
+			return nil, err
+		}
+		l[i] = f
+	}
+
+	return combineFloat64(strategy, l), nil
+}
+
+func combineInt64(strategy string, l []int64) (*rule.Value, error) {
+	if strategy == StrategyAllMatches {
+		return rule.Int64ListValue(l...), nil
+	}
+
+	res := l[0]
+	for _, n := range l[1:] {
+		switch strategy {
+		case StrategySum:
+			if (n > 0 && res > math.MaxInt64-n) || (n < 0 && res < math.MinInt64-n) {
+				return nil, rule.ErrIntegerOverflow
+			}
+			res += n
+		case StrategyMin:
+			if n < res {
+				res = n
+			}
+		case StrategyMax:
+			if n > res {
+				res = n
+			}
+		}
+	}
+
+	return rule.Int64Value(res), nil
+}
+
+func combineFloat64(strategy string, l []float64) *rule.Value {
+	if strategy == StrategyAllMatches {
+		return rule.Float64ListValue(l...)
+	}
+
+	res := l[0]
+	for _, f := range l[1:] {
+		switch strategy {
+		case StrategySum:
+			res += f
+		case StrategyMin:
+			res = math.Min(res, f)
+		case StrategyMax:
+			res = math.Max(res, f)
+		}
+	}
+
+	return rule.Float64Value(res)
+}
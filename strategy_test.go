@@ -0,0 +1,181 @@
+package regula
+
+import (
+	"math"
+	"testing"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStrategies(t *testing.T) {
+	surcharges := func(t *testing.T, typ, strategy string, results ...rule.Expr) *Ruleset {
+		t.Helper()
+
+		rs := Ruleset{Type: typ, Strategy: strategy}
+		for i, res := range results {
+			rs.Rules = append(rs.Rules, rule.New(rule.GTE(rule.Int64Param("n"), rule.Int64Value(int64(i))), res))
+		}
+		require.NoError(t, rs.validate())
+
+		return &rs
+	}
+
+	tests := []struct {
+		name     string
+		rs       *Ruleset
+		params   Params
+		expected *rule.Value
+	}{
+		{"First match", surcharges(t, "int64", "", rule.Int64Value(1), rule.Int64Value(2)), Params{"n": int64(1)}, rule.Int64Value(1)},
+		{"Explicit first match", surcharges(t, "int64", StrategyFirstMatch, rule.Int64Value(1), rule.Int64Value(2)), Params{"n": int64(1)}, rule.Int64Value(1)},
+		{"All matches string", surcharges(t, "string", StrategyAllMatches, rule.StringValue("a"), rule.StringValue("b"), rule.StringValue("c")), Params{"n": int64(1)}, rule.StringListValue("a", "b")},
+		{"All matches int64", surcharges(t, "int64", StrategyAllMatches, rule.Int64Value(1), rule.Int64Value(2)), Params{"n": int64(5)}, rule.Int64ListValue(1, 2)},
+		{"All matches float64", surcharges(t, "float64", StrategyAllMatches, rule.Float64Value(1.5), rule.Float64Value(2)), Params{"n": int64(0)}, rule.Float64ListValue(1.5)},
+		{"Sum int64", surcharges(t, "int64", StrategySum, rule.Int64Value(1), rule.Int64Value(2), rule.Int64Value(4)), Params{"n": int64(2)}, rule.Int64Value(7)},
+		{"Sum float64", surcharges(t, "float64", StrategySum, rule.Float64Value(1.5), rule.Float64Param("base")), Params{"n": int64(2), "base": 2.0}, rule.Float64Value(3.5)},
+		{"Min int64", surcharges(t, "int64", StrategyMin, rule.Int64Value(3), rule.Int64Value(-2), rule.Int64Value(4)), Params{"n": int64(2)}, rule.Int64Value(-2)},
+		{"Min float64", surcharges(t, "float64", StrategyMin, rule.Float64Value(3), rule.Float64Value(1.5)), Params{"n": int64(2)}, rule.Float64Value(1.5)},
+		{"Max int64", surcharges(t, "int64", StrategyMax, rule.Int64Value(3), rule.Int64Value(-2), rule.Int64Value(4)), Params{"n": int64(1)}, rule.Int64Value(3)},
+		{"Max float64", surcharges(t, "float64", StrategyMax, rule.Float64Value(3), rule.Float64Value(4.5)), Params{"n": int64(2)}, rule.Float64Value(4.5)},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			v, err := test.rs.Eval(test.params)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v)
+
+			v, _, err = test.rs.EvalTrace(test.params)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v)
+
+			p, err := test.rs.Compile()
+			require.NoError(t, err)
+			v, err = p.Eval(test.params)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, v)
+		})
+	}
+
+	t.Run("Priority", func(t *testing.T) {
+		rs := surcharges(t, "int64", StrategyPriority, rule.Int64Value(1), rule.Int64Value(2), rule.Int64Value(3))
+		rs.Rules[1].Priority = 10
+		rs.Rules[2].Priority = 10
+
+		p, err := rs.Compile()
+		require.NoError(t, err)
+
+		for n, exp := range []int64{1, 2, 2} {
+			params := Params{"n": int64(n)}
+
+			v, err := rs.Eval(params)
+			require.NoError(t, err)
+			require.Equal(t, rule.Int64Value(exp), v)
+
+			v, err = p.Eval(params)
+			require.NoError(t, err)
+			require.Equal(t, rule.Int64Value(exp), v)
+		}
+
+		_, trace, err := rs.EvalTrace(Params{"n": int64(1)})
+		require.NoError(t, err)
+		require.Equal(t, 1, trace.Rule)
+		require.Len(t, trace.Rules, 1)
+		// the trace of the rule evaluated first identifies it
+		require.Equal(t, 1, trace.Rules[0].Index)
+	})
+
+	t.Run("Trace", func(t *testing.T) {
+		rs := surcharges(t, "int64", StrategySum, rule.Int64Value(1), rule.Int64Value(2), rule.Int64Value(4))
+
+		_, trace, err := rs.EvalTrace(Params{"n": int64(1)})
+		require.NoError(t, err)
+		require.Equal(t, 0, trace.Rule)
+		require.Equal(t, []int{0, 1}, trace.Matches)
+		require.Len(t, trace.Rules, 3)
+	})
+
+	t.Run("No match", func(t *testing.T) {
+		rs := surcharges(t, "string", StrategyAllMatches, rule.StringValue("a"))
+
+		_, err := rs.Eval(Params{"n": int64(-1)})
+		require.Equal(t, rule.ErrNoMatch, err)
+
+		rs.Default = rule.StringListValue()
+		v, err := rs.Eval(Params{"n": int64(-1)})
+		require.NoError(t, err)
+		require.Equal(t, rule.StringListValue(), v)
+	})
+
+	t.Run("Overflow", func(t *testing.T) {
+		rs := surcharges(t, "int64", StrategySum, rule.Int64Value(math.MaxInt64), rule.Int64Value(1))
+
+		_, err := rs.Eval(Params{"n": int64(1)})
+		require.Equal(t, rule.ErrIntegerOverflow, err)
+	})
+
+	t.Run("Error", func(t *testing.T) {
+		rs := surcharges(t, "float64", StrategySum, rule.Float64Value(1), rule.Float64Param("base"))
+
+		_, err := rs.Eval(Params{"n": int64(1)})
+		require.Equal(t, rule.ErrParamNotFound, err)
+	})
+}
+
+func TestStrategyValidation(t *testing.T) {
+	tests := []struct {
+		typ, strategy string
+		def           *rule.Value
+		err           string
+	}{
+		{"string", "random", nil, `unsupported strategy "random"`},
+		{"bool", StrategyAllMatches, nil, "the all-matches strategy doesn't support bool rulesets"},
+		{"string", StrategySum, nil, "the sum strategy doesn't support string rulesets"},
+		{"bool", StrategyMin, nil, "the min strategy doesn't support bool rulesets"},
+		{"string", StrategyAllMatches, rule.StringValue("a"), ErrRulesetIncoherentType.Error()},
+		{"string", StrategyAllMatches, rule.StringListValue("a"), ""},
+		{"bool", StrategyPriority, rule.BoolValue(true), ""},
+	}
+
+	for _, test := range tests {
+		rs := Ruleset{
+			Type:     test.typ,
+			Strategy: test.strategy,
+			Default:  test.def,
+			Rules:    []*rule.Rule{rule.New(rule.True(), &rule.Value{Kind: "value", Type: test.typ, Data: "1"})},
+		}
+
+		err := rs.validate()
+		if test.err == "" {
+			require.NoError(t, err)
+		} else {
+			require.EqualError(t, err, test.err)
+		}
+	}
+}
+
+func TestParseRulesetStrategy(t *testing.T) {
+	rs, err := ParseRuleset(`
+		type float64
+		strategy priority
+
+		city == "paris" -> 3.0
+		city == "paris" and surge:bool -> 5.0 priority 1
+	`)
+	require.NoError(t, err)
+	require.Equal(t, StrategyPriority, rs.Strategy)
+	require.Equal(t, int64(1), rs.Rules[1].Priority)
+
+	s, err := FormatRuleset(rs)
+	require.NoError(t, err)
+	require.Equal(t, `type float64
+strategy priority
+
+city == "paris" -> 3.0
+city == "paris" and surge:bool -> 5.0 priority 1
+`, s)
+
+	_, err = ParseRuleset("type float64\nstrategy first\n")
+	require.Equal(t, &rule.ParseError{Line: 2, Column: 10, Msg: "unsupported strategy first"}, err)
+}
@@ -0,0 +1,175 @@
+package regula
+
+import (
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/heetch/regula/rule"
+)
+
+// StringParams is a rule.Params implementation holding the parameters encoded as strings,
+// like in the query string of the eval endpoint of the HTTP API.
+// Values are decoded when they are extracted, using the format of Params.EncodeValue.
+type StringParams map[string]string
+
+// GetString extracts a string parameter which corresponds to the given key.
+func (p StringParams) GetString(key string) (string, error) {
+	s, ok := p[key]
+	if !ok {
+		return "", rule.ErrParamNotFound
+	}
+
+	return s, nil
+}
+
+// GetBool extracts a bool parameter which corresponds to the given key.
+func (p StringParams) GetBool(key string) (bool, error) {
+	v, ok := p[key]
+	if !ok {
+		return false, rule.ErrParamNotFound
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, rule.ErrParamTypeMismatch
+	}
+
+	return b, nil
+}
+
+// GetInt64 extracts an int64 parameter which corresponds to the given key.
+func (p StringParams) GetInt64(key string) (int64, error) {
+	v, ok := p[key]
+	if !ok {
+		return 0, rule.ErrParamNotFound
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, rule.ErrParamTypeMismatch
+	}
+
+	return i, nil
+}
+
+// GetFloat64 extracts a float64 parameter which corresponds to the given key.
+func (p StringParams) GetFloat64(key string) (float64, error) {
+	v, ok := p[key]
+	if !ok {
+		return 0, rule.ErrParamNotFound
+	}
+
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, rule.ErrParamTypeMismatch
+	}
+
+	return f, err
+}
+
+// GetTime extracts a time parameter which corresponds to the given key.
+// The time must be formatted using RFC 3339.
+func (p StringParams) GetTime(key string) (time.Time, error) {
+	v, ok := p[key]
+	if !ok {
+		return time.Time{}, rule.ErrParamNotFound
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, v)
+	if err != nil {
+		return time.Time{}, rule.ErrParamTypeMismatch
+	}
+
+	return t, nil
+}
+
+// GetDuration extracts a duration parameter which corresponds to the given key.
+// The duration must be formatted as accepted by time.ParseDuration, like "1h30m".
+func (p StringParams) GetDuration(key string) (time.Duration, error) {
+	v, ok := p[key]
+	if !ok {
+		return 0, rule.ErrParamNotFound
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0, rule.ErrParamTypeMismatch
+	}
+
+	return d, nil
+}
+
+// GetStringList extracts a list of strings parameter which corresponds to the given key.
+// The list must be formatted as a JSON array.
+func (p StringParams) GetStringList(key string) ([]string, error) {
+	var l []string
+	err := p.decodeList(key, &l)
+	return l, err
+}
+
+// GetInt64List extracts a list of int64 parameter which corresponds to the given key.
+// The list must be formatted as a JSON array.
+func (p StringParams) GetInt64List(key string) ([]int64, error) {
+	var l []int64
+	err := p.decodeList(key, &l)
+	return l, err
+}
+
+// GetFloat64List extracts a list of float64 parameter which corresponds to the given key.
+// The list must be formatted as a JSON array.
+func (p StringParams) GetFloat64List(key string) ([]float64, error) {
+	var l []float64
+	err := p.decodeList(key, &l)
+	return l, err
+}
+
+// GetPoint extracts a point parameter which corresponds to the given key.
+// The point must be formatted as a JSON array holding its latitude and longitude.
+func (p StringParams) GetPoint(key string) (rule.Point, error) {
+	var c []float64
+	err := p.decodeList(key, &c)
+	if err != nil {
+		return rule.Point{}, err
+	}
+
+	if len(c) != 2 {
+		return rule.Point{}, rule.ErrParamTypeMismatch
+	}
+
+	return rule.Point{Lat: c[0], Lng: c[1]}, nil
+}
+
+func (p StringParams) decodeList(key string, l interface{}) error {
+	v, ok := p[key]
+	if !ok {
+		return rule.ErrParamNotFound
+	}
+
+	err := json.Unmarshal([]byte(v), l)
+	if err != nil {
+		return rule.ErrParamTypeMismatch
+	}
+
+	return nil
+}
+
+// Keys returns the list of all the keys.
+func (p StringParams) Keys() []string {
+	keys := make([]string, 0, len(p))
+	for k := range p {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
+
+// EncodeValue returns the string representation of a value.
+func (p StringParams) EncodeValue(key string) (string, error) {
+	v, ok := p[key]
+	if !ok {
+		return "", rule.ErrParamNotFound
+	}
+
+	return v, nil
+}
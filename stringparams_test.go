@@ -0,0 +1,257 @@
+package regula
+
+import (
+	"testing"
+	"time"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringParamsGetString(t *testing.T) {
+	p := StringParams{
+		"string": "string",
+		"bool":   "true",
+	}
+
+	t.Run("GetString - OK", func(t *testing.T) {
+		v, err := p.GetString("string")
+		require.NoError(t, err)
+		require.Equal(t, "string", v)
+	})
+
+	t.Run("GetString - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetString("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+}
+
+func TestStringParamsGetBool(t *testing.T) {
+	p := StringParams{
+		"bool":   "true",
+		"string": "foo",
+	}
+
+	t.Run("GetBool - OK", func(t *testing.T) {
+		v, err := p.GetBool("bool")
+		require.NoError(t, err)
+		require.Equal(t, true, v)
+	})
+
+	t.Run("GetBool - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetBool("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetBool - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetBool("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestStringParamsGetInt64(t *testing.T) {
+	p := StringParams{
+		"int64":  "42",
+		"string": "foo",
+	}
+
+	t.Run("GetInt64 - OK", func(t *testing.T) {
+		v, err := p.GetInt64("int64")
+		require.NoError(t, err)
+		require.Equal(t, int64(42), v)
+	})
+
+	t.Run("GetInt64 - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetInt64("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetInt64 - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetInt64("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestStringParamsGetFloat64(t *testing.T) {
+	p := StringParams{
+		"float64": "42.42",
+		"string":  "foo",
+	}
+
+	t.Run("GetFloat64 - OK", func(t *testing.T) {
+		v, err := p.GetFloat64("float64")
+		require.NoError(t, err)
+		require.Equal(t, 42.42, v)
+	})
+
+	t.Run("GetFloat64 - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetFloat64("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetFloat64 - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetFloat64("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestStringParamsGetTime(t *testing.T) {
+	p := StringParams{
+		"time":   "2026-10-17T12:30:00Z",
+		"string": "foo",
+	}
+
+	t.Run("GetTime - OK", func(t *testing.T) {
+		v, err := p.GetTime("time")
+		require.NoError(t, err)
+		require.Equal(t, time.Date(2026, 10, 17, 12, 30, 0, 0, time.UTC), v)
+	})
+
+	t.Run("GetTime - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetTime("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetTime - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetTime("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestStringParamsGetDuration(t *testing.T) {
+	p := StringParams{
+		"duration": "1h30m",
+		"string":   "foo",
+	}
+
+	t.Run("GetDuration - OK", func(t *testing.T) {
+		v, err := p.GetDuration("duration")
+		require.NoError(t, err)
+		require.Equal(t, 90*time.Minute, v)
+	})
+
+	t.Run("GetDuration - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetDuration("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetDuration - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetDuration("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestStringParamsGetStringList(t *testing.T) {
+	p := StringParams{
+		"list":   `["a","b"]`,
+		"string": "foo",
+	}
+
+	t.Run("GetStringList - OK", func(t *testing.T) {
+		v, err := p.GetStringList("list")
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, v)
+	})
+
+	t.Run("GetStringList - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetStringList("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetStringList - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetStringList("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestStringParamsGetInt64List(t *testing.T) {
+	p := StringParams{
+		"list":   `[1, 2]`,
+		"string": "foo",
+	}
+
+	t.Run("GetInt64List - OK", func(t *testing.T) {
+		v, err := p.GetInt64List("list")
+		require.NoError(t, err)
+		require.Equal(t, []int64{1, 2}, v)
+	})
+
+	t.Run("GetInt64List - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetInt64List("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetInt64List - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetInt64List("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestStringParamsGetFloat64List(t *testing.T) {
+	p := StringParams{
+		"list":   `[1.5]`,
+		"string": "foo",
+	}
+
+	t.Run("GetFloat64List - OK", func(t *testing.T) {
+		v, err := p.GetFloat64List("list")
+		require.NoError(t, err)
+		require.Equal(t, []float64{1.5}, v)
+	})
+
+	t.Run("GetFloat64List - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetFloat64List("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetFloat64List - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetFloat64List("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
+
+func TestStringParamsGetPoint(t *testing.T) {
+	p := StringParams{
+		"point":  `[48.8566,2.3522]`,
+		"list":   `[1.5]`,
+		"string": "foo",
+	}
+
+	t.Run("GetPoint - OK", func(t *testing.T) {
+		v, err := p.GetPoint("point")
+		require.NoError(t, err)
+		require.Equal(t, rule.Point{Lat: 48.8566, Lng: 2.3522}, v)
+	})
+
+	t.Run("GetPoint - NOK - ErrParamNotFound", func(t *testing.T) {
+		_, err := p.GetPoint("badkey")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamNotFound)
+	})
+
+	t.Run("GetPoint - NOK - ErrParamTypeMismatch", func(t *testing.T) {
+		_, err := p.GetPoint("string")
+		require.Error(t, err)
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+
+		_, err = p.GetPoint("list")
+		require.Equal(t, err, rule.ErrParamTypeMismatch)
+	})
+}
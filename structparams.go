@@ -0,0 +1,473 @@
+package regula
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/heetch/regula/rule"
+)
+
+// StructParams returns a rule.Params implementation extracting the parameters from the fields
+// of the given struct, or pointer to struct, which are tagged with the name of a param:
+//
+//	type Trip struct {
+//		City     string   `param:"city"`
+//		Distance int      `param:"distance"`
+//		Promo    *string  `param:"promo"`
+//		Zones    []string `param:"zones"`
+//	}
+//
+//	s, res, err := engine.GetString(ctx, "path/to/ruleset", regula.StructParams(&trip))
+//
+// Fields can hold strings, bools, integers and floats of any width, which are returned as int64 and float64,
+// time.Time, time.Duration, rule.Point values, or slices of strings, integers and floats.
+// Pointer fields hold optional params, which are not found when nil. The fields of embedded structs
+// are promoted, as with encoding/json: fields of the outer struct hide the ones with the same name in embedded structs,
+// and fields with the same name at the same depth are ambiguous and ignored.
+// Fields without tag, tagged with "-" or unexported are ignored, as well as the fields of unexported embedded structs.
+//
+// The fields of each type are computed once and cached. StructParams panics if v isn't a struct or a pointer to a struct,
+// or if one of its tagged fields has an unsupported type.
+func StructParams(v interface{}) rule.Params {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			panic("regula: StructParams called with a nil pointer")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("regula: StructParams called with a %s, a struct is expected", rv.Type()))
+	}
+
+	return &structParams{v: rv, fields: structFieldsOf(rv.Type())}
+}
+
+type structParams struct {
+	v      reflect.Value
+	fields *structFields
+}
+
+// field returns the field holding the param with the given name, which must be of the given type.
+func (p *structParams) field(key, typ string) (reflect.Value, *structField, error) {
+	f, ok := p.fields.byName[key]
+	if !ok {
+		return reflect.Value{}, nil, rule.ErrParamNotFound
+	}
+
+	v, ok := fieldByIndex(p.v, f.index)
+	if !ok {
+		return reflect.Value{}, nil, rule.ErrParamNotFound
+	}
+
+	if typ != "" && f.typ != typ {
+		return reflect.Value{}, nil, rule.ErrParamTypeMismatch
+	}
+
+	return v, f, nil
+}
+
+// GetString extracts a string parameter corresponding to the given key.
+func (p *structParams) GetString(key string) (string, error) {
+	v, _, err := p.field(key, "string")
+	if err != nil {
+		return "", err
+	}
+
+	return v.String(), nil
+}
+
+// GetBool extracts a bool parameter corresponding to the given key.
+func (p *structParams) GetBool(key string) (bool, error) {
+	v, _, err := p.field(key, "bool")
+	if err != nil {
+		return false, err
+	}
+
+	return v.Bool(), nil
+}
+
+// GetInt64 extracts an int64 parameter corresponding to the given key.
+// It returns rule.ErrParamTypeMismatch if an unsigned integer overflows an int64.
+func (p *structParams) GetInt64(key string) (int64, error) {
+	v, _, err := p.field(key, "int64")
+	if err != nil {
+		return 0, err
+	}
+
+	return toInt64(v)
+}
+
+// GetFloat64 extracts a float64 parameter corresponding to the given key.
+func (p *structParams) GetFloat64(key string) (float64, error) {
+	v, _, err := p.field(key, "float64")
+	if err != nil {
+		return 0, err
+	}
+
+	return v.Float(), nil
+}
+
+// GetTime extracts a time parameter corresponding to the given key.
+func (p *structParams) GetTime(key string) (time.Time, error) {
+	v, _, err := p.field(key, "time")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return v.Interface().(time.Time), nil
+}
+
+// GetDuration extracts a duration parameter corresponding to the given key.
+func (p *structParams) GetDuration(key string) (time.Duration, error) {
+	v, _, err := p.field(key, "duration")
+	if err != nil {
+		return 0, err
+	}
+
+	return time.Duration(v.Int()), nil
+}
+
+// GetStringList extracts a list of strings parameter corresponding to the given key.
+func (p *structParams) GetStringList(key string) ([]string, error) {
+	v, f, err := p.field(key, "string-list")
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := f.convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.([]string), nil
+}
+
+// GetInt64List extracts a list of int64 parameter corresponding to the given key.
+func (p *structParams) GetInt64List(key string) ([]int64, error) {
+	v, f, err := p.field(key, "int64-list")
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := f.convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.([]int64), nil
+}
+
+// GetFloat64List extracts a list of float64 parameter corresponding to the given key.
+func (p *structParams) GetFloat64List(key string) ([]float64, error) {
+	v, f, err := p.field(key, "float64-list")
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := f.convert(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return l.([]float64), nil
+}
+
+// GetPoint extracts a point parameter corresponding to the given key.
+func (p *structParams) GetPoint(key string) (rule.Point, error) {
+	v, _, err := p.field(key, "point")
+	if err != nil {
+		return rule.Point{}, err
+	}
+
+	return v.Interface().(rule.Point), nil
+}
+
+// Keys returns the names of the params, in the order of the fields, except optional params which are nil.
+func (p *structParams) Keys() []string {
+	keys := make([]string, 0, len(p.fields.names))
+	for _, name := range p.fields.names {
+		if _, ok := fieldByIndex(p.v, p.fields.byName[name].index); ok {
+			keys = append(keys, name)
+		}
+	}
+
+	return keys
+}
+
+// EncodeValue returns the string representation of the selected value, using the format of Params.EncodeValue.
+func (p *structParams) EncodeValue(key string) (string, error) {
+	v, f, err := p.field(key, "")
+	if err != nil {
+		return "", err
+	}
+
+	c, err := f.convert(v)
+	if err != nil {
+		return "", err
+	}
+
+	return encodeValue(c)
+}
+
+// fieldByIndex returns the field of v with the given index, dereferencing pointers.
+// It returns false if the field or one of the embedded structs holding it is a nil pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for _, i := range index {
+		v = v.Field(i)
+		for v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return v, false
+			}
+			v = v.Elem()
+		}
+	}
+
+	return v, true
+}
+
+// structFields holds the tagged fields of a struct type.
+type structFields struct {
+	byName map[string]*structField
+	// names holds the names of the params in the order of the fields.
+	names []string
+}
+
+type structField struct {
+	// name is the name of the param held by the field.
+	name string
+	// index is the sequence of indexes of the field, through embedded structs.
+	index []int
+	// typ is the type of the param held by the field.
+	typ string
+	// convert returns the value of the field converted to the type expected by Params.
+	convert func(reflect.Value) (interface{}, error)
+	// depth is the number of embedded structs holding the field.
+	depth int
+}
+
+// fieldsCache associates struct types with their *structFields.
+var fieldsCache sync.Map
+
+func structFieldsOf(t reflect.Type) *structFields {
+	if f, ok := fieldsCache.Load(t); ok {
+		return f.(*structFields)
+	}
+
+	var all []*structField
+	var names []string
+	collectFields(t, nil, map[reflect.Type]bool{}, &all, &names)
+
+	// like encoding/json, the shallowest field of each name wins, and if several are at the same depth
+	// the name is ambiguous and all of them are dropped.
+	candidates := make(map[string][]*structField)
+	for _, f := range all {
+		if l := candidates[f.name]; len(l) == 0 || l[0].depth == f.depth {
+			candidates[f.name] = append(l, f)
+		} else if f.depth < l[0].depth {
+			candidates[f.name] = []*structField{f}
+		}
+	}
+
+	fields := structFields{byName: make(map[string]*structField)}
+	for _, name := range names {
+		if l := candidates[name]; len(l) == 1 {
+			fields.byName[name] = l[0]
+			fields.names = append(fields.names, name)
+		}
+	}
+
+	f, _ := fieldsCache.LoadOrStore(t, &fields)
+	return f.(*structFields)
+}
+
+// collectFields adds the tagged fields of t, and of the structs it embeds, to fields, and the names
+// of their params to names, in order of first appearance. visited holds the embedded structs
+// holding t, to stop on types embedding themselves.
+func collectFields(t reflect.Type, index []int, visited map[reflect.Type]bool, fields *[]*structField, names *[]string) {
+	if visited[t] {
+		return
+	}
+	visited[t] = true
+	defer delete(visited, t)
+
+	var embedded []int
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag := sf.Tag.Get("param")
+
+		if sf.Anonymous && tag == "" {
+			if sf.PkgPath != "" {
+				// the fields of unexported embedded structs can't be read.
+				continue
+			}
+			et := sf.Type
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				embedded = append(embedded, i)
+			}
+			continue
+		}
+
+		if tag == "" || tag == "-" || sf.PkgPath != "" {
+			continue
+		}
+
+		typ, convert := converter(sf.Type)
+		if convert == nil {
+			panic(fmt.Sprintf("regula: field %s of %s has unsupported type %s", sf.Name, t, sf.Type))
+		}
+
+		f := structField{
+			name:    tag,
+			index:   append(append([]int(nil), index...), i),
+			typ:     typ,
+			convert: convert,
+			depth:   len(index),
+		}
+
+		*fields = append(*fields, &f)
+		if !contains(*names, tag) {
+			*names = append(*names, tag)
+		}
+	}
+
+	for _, i := range embedded {
+		et := t.Field(i).Type
+		if et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+		collectFields(et, append(append([]int(nil), index...), i), visited, fields, names)
+	}
+}
+
+func contains(l []string, s string) bool {
+	for _, e := range l {
+		if e == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	pointType    = reflect.TypeOf(rule.Point{})
+)
+
+// converter returns the type of the param held by values of type t and a function converting them
+// to the type expected by Params, or a nil function if t isn't supported. Pointers are dereferenced before conversion.
+func converter(t reflect.Type) (string, func(reflect.Value) (interface{}, error)) {
+	if t.Kind() == reflect.Ptr {
+		return converter(t.Elem())
+	}
+
+	switch t {
+	case timeType:
+		return "time", func(v reflect.Value) (interface{}, error) {
+			return v.Interface().(time.Time), nil
+		}
+	case durationType:
+		return "duration", func(v reflect.Value) (interface{}, error) {
+			return time.Duration(v.Int()), nil
+		}
+	case pointType:
+		return "point", func(v reflect.Value) (interface{}, error) {
+			return v.Interface().(rule.Point), nil
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return "string", func(v reflect.Value) (interface{}, error) {
+			return v.String(), nil
+		}
+	case reflect.Bool:
+		return "bool", func(v reflect.Value) (interface{}, error) {
+			return v.Bool(), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "int64", func(v reflect.Value) (interface{}, error) {
+			n, err := toInt64(v)
+			if err != nil {
+				return nil, err
+			}
+			return n, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return "float64", func(v reflect.Value) (interface{}, error) {
+			return v.Float(), nil
+		}
+	case reflect.Slice:
+		return listConverter(t.Elem())
+	}
+
+	return "", nil
+}
+
+// listConverter returns the type of the param held by slices of elem and a function converting them
+// to []string, []int64 or []float64, or a nil function if elem isn't supported.
+func listConverter(elem reflect.Type) (string, func(reflect.Value) (interface{}, error)) {
+	switch elem.Kind() {
+	case reflect.String:
+		return "string-list", func(v reflect.Value) (interface{}, error) {
+			l := make([]string, v.Len())
+			for i := range l {
+				l[i] = v.Index(i).String()
+			}
+			return l, nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		// []uint8 is left out as it usually holds bytes rather than a list of numbers.
+		if elem == durationType {
+			return "", nil
+		}
+		return "int64-list", func(v reflect.Value) (interface{}, error) {
+			l := make([]int64, v.Len())
+			for i := range l {
+				n, err := toInt64(v.Index(i))
+				if err != nil {
+					return nil, err
+				}
+				l[i] = n
+			}
+			return l, nil
+		}
+	case reflect.Float32, reflect.Float64:
+		return "float64-list", func(v reflect.Value) (interface{}, error) {
+			l := make([]float64, v.Len())
+			for i := range l {
+				l[i] = v.Index(i).Float()
+			}
+			return l, nil
+		}
+	}
+
+	return "", nil
+}
+
+// toInt64 returns the value of the signed or unsigned integer v.
+// It returns ErrParamTypeMismatch if an unsigned value overflows an int64.
+func toInt64(v reflect.Value) (int64, error) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int(), nil
+	}
+
+	n := v.Uint()
+	if n > math.MaxInt64 {
+		return 0, rule.ErrParamTypeMismatch
+	}
+
+	return int64(n), nil
+}
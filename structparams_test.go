@@ -0,0 +1,216 @@
+package regula
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+type Location struct {
+	City  string     `param:"city"`
+	Point rule.Point `param:"point"`
+}
+
+type Trip struct {
+	Location
+	*Driver
+
+	City      string        `param:"city"`
+	Distance  int           `param:"distance"`
+	Passenger uint8         `param:"passengers"`
+	Price     float32       `param:"price"`
+	Surge     bool          `param:"surge"`
+	At        time.Time     `param:"at"`
+	Duration  time.Duration `param:"duration"`
+	Promo     *string       `param:"promo"`
+	Zones     []string      `param:"zones"`
+	Stops     []int32       `param:"stops"`
+	Fees      []float32     `param:"fees"`
+	Ignored   string
+	Skipped   string `param:"-"`
+	Large     uint64 `param:"large"`
+}
+
+type Driver struct {
+	ID   string `param:"driver-id"`
+	Name string `param:"driver-name"`
+}
+
+func TestStructParams(t *testing.T) {
+	at := time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	trip := Trip{
+		Location:  Location{City: "paris", Point: rule.Point{Lat: 48.8, Lng: 2.3}},
+		City:      "lyon",
+		Distance:  12,
+		Passenger: 2,
+		Price:     1.5,
+		Surge:     true,
+		At:        at,
+		Duration:  time.Minute,
+		Zones:     []string{"a", "b"},
+		Stops:     []int32{1, 2},
+		Fees:      []float32{0.5},
+		Large:     math.MaxUint64,
+	}
+	p := StructParams(&trip)
+
+	t.Run("OK", func(t *testing.T) {
+		// Trip.City hides Location.City
+		s, err := p.GetString("city")
+		require.NoError(t, err)
+		require.Equal(t, "lyon", s)
+
+		i, err := p.GetInt64("distance")
+		require.NoError(t, err)
+		require.Equal(t, int64(12), i)
+
+		i, err = p.GetInt64("passengers")
+		require.NoError(t, err)
+		require.Equal(t, int64(2), i)
+
+		f, err := p.GetFloat64("price")
+		require.NoError(t, err)
+		require.Equal(t, 1.5, f)
+
+		b, err := p.GetBool("surge")
+		require.NoError(t, err)
+		require.True(t, b)
+
+		tm, err := p.GetTime("at")
+		require.NoError(t, err)
+		require.Equal(t, at, tm)
+
+		d, err := p.GetDuration("duration")
+		require.NoError(t, err)
+		require.Equal(t, time.Minute, d)
+
+		pt, err := p.GetPoint("point")
+		require.NoError(t, err)
+		require.Equal(t, rule.Point{Lat: 48.8, Lng: 2.3}, pt)
+
+		sl, err := p.GetStringList("zones")
+		require.NoError(t, err)
+		require.Equal(t, []string{"a", "b"}, sl)
+
+		il, err := p.GetInt64List("stops")
+		require.NoError(t, err)
+		require.Equal(t, []int64{1, 2}, il)
+
+		fl, err := p.GetFloat64List("fees")
+		require.NoError(t, err)
+		require.Equal(t, []float64{0.5}, fl)
+
+		e, err := p.EncodeValue("distance")
+		require.NoError(t, err)
+		require.Equal(t, "12", e)
+	})
+
+	t.Run("Allocations", func(t *testing.T) {
+		allocs := testing.AllocsPerRun(10, func() {
+			p.GetString("city")
+			p.GetInt64("passengers")
+			p.GetFloat64("price")
+		})
+		require.Zero(t, allocs)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		_, err := p.GetString("promo")
+		require.Equal(t, rule.ErrParamNotFound, err)
+
+		_, err = p.GetString("driver-id")
+		require.Equal(t, rule.ErrParamNotFound, err)
+
+		_, err = p.GetString("Ignored")
+		require.Equal(t, rule.ErrParamNotFound, err)
+
+		_, err = p.GetString("-")
+		require.Equal(t, rule.ErrParamNotFound, err)
+
+		_, err = p.GetInt64("city")
+		require.Equal(t, rule.ErrParamTypeMismatch, err)
+
+		_, err = p.GetInt64("duration")
+		require.Equal(t, rule.ErrParamTypeMismatch, err)
+
+		_, err = p.GetInt64("large")
+		require.Equal(t, rule.ErrParamTypeMismatch, err)
+	})
+
+	t.Run("Keys", func(t *testing.T) {
+		require.Equal(t, []string{
+			"city", "distance", "passengers", "price", "surge", "at", "duration", "zones", "stops", "fees", "large", "point",
+		}, p.Keys())
+
+		promo := "summer"
+		trip2 := trip
+		trip2.Promo = &promo
+		trip2.Driver = &Driver{ID: "d1", Name: "bob"}
+		p2 := StructParams(trip2)
+
+		s, err := p2.GetString("promo")
+		require.NoError(t, err)
+		require.Equal(t, "summer", s)
+
+		s, err = p2.GetString("driver-id")
+		require.NoError(t, err)
+		require.Equal(t, "d1", s)
+
+		require.Contains(t, p2.Keys(), "promo")
+		require.Contains(t, p2.Keys(), "driver-id")
+	})
+
+	t.Run("Conflicts", func(t *testing.T) {
+		type Place struct {
+			City string `param:"city"`
+			Zone string `param:"zone"`
+		}
+		type conflict struct {
+			Location
+			Place
+		}
+
+		// like encoding/json, ambiguous fields are ignored
+		p := StructParams(conflict{Location: Location{City: "paris"}, Place: Place{City: "lyon", Zone: "a"}})
+		_, err := p.GetString("city")
+		require.Equal(t, rule.ErrParamNotFound, err)
+		require.Equal(t, []string{"point", "zone"}, p.Keys())
+
+		// unless a shallower field hides them
+		type hidden struct {
+			Location
+			Place
+			City string `param:"city"`
+		}
+		s, err := StructParams(hidden{City: "nice"}).GetString("city")
+		require.NoError(t, err)
+		require.Equal(t, "nice", s)
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		require.Panics(t, func() { StructParams("paris") })
+		require.Panics(t, func() { StructParams((*Trip)(nil)) })
+		require.Panics(t, func() {
+			StructParams(struct {
+				C chan int `param:"c"`
+			}{})
+		})
+	})
+}
+
+func TestStructParamsEval(t *testing.T) {
+	rs, err := ParseRuleset(`
+		type string
+
+		city == "paris" and distance:int64 > 10 -> "far"
+		true -> "near"
+	`)
+	require.NoError(t, err)
+
+	res, err := rs.Eval(StructParams(Trip{City: "paris", Distance: 12}))
+	require.NoError(t, err)
+	require.Equal(t, rule.StringValue("far"), res)
+}
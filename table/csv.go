@@ -0,0 +1,33 @@
+package table
+
+import (
+	"encoding/csv"
+	"io"
+
+	"github.com/heetch/regula"
+)
+
+// ReadCSV reads a decision table in the CSV format and converts it into a ruleset.
+func ReadCSV(r io.Reader) (*regula.Ruleset, error) {
+	cr := csv.NewReader(r)
+	// the number of cells is checked by ToRuleset, which reports the faulty row
+	cr.FieldsPerRecord = -1
+
+	rows, err := cr.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	return ToRuleset(rows)
+}
+
+// WriteCSV converts the ruleset into a decision table and writes it in the CSV format.
+// See FromRuleset for the rulesets that can be converted.
+func WriteCSV(w io.Writer, rs *regula.Ruleset) error {
+	rows, err := FromRuleset(rs)
+	if err != nil {
+		return err
+	}
+
+	return csv.NewWriter(w).WriteAll(rows)
+}
@@ -0,0 +1,125 @@
+package table
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/heetch/regula"
+)
+
+// ReadMarkdown reads a decision table in the Markdown format and converts it into a ruleset, e.g.
+//
+//	| city  | age:int64 | result:float64 |
+//	| ----- | --------- | -------------- |
+//	| paris | 18..      | 3.0            |
+//
+// Blank lines are ignored, every other line must be a row of the table. The delimiter row, following the header,
+// is not counted in the row numbers reported by CellError. Pipes within cells must be escaped with a backslash.
+func ReadMarkdown(r io.Reader) (*regula.Ruleset, error) {
+	var rows [][]string
+
+	s := bufio.NewScanner(r)
+	line := 0
+	for s.Scan() {
+		line++
+
+		text := strings.TrimSpace(s.Text())
+		if text == "" {
+			continue
+		}
+		if !strings.HasPrefix(text, "|") {
+			return nil, fmt.Errorf("line %d: not a table row", line)
+		}
+
+		row := splitRow(text)
+		if len(rows) == 1 && isDelimiterRow(row) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+
+	return ToRuleset(rows)
+}
+
+// splitRow returns the cells of a row, delimited by unescaped pipes.
+func splitRow(text string) []string {
+	text = strings.TrimPrefix(text, "|")
+	if strings.HasSuffix(text, "|") && !strings.HasSuffix(text, `\|`) {
+		text = text[:len(text)-1]
+	}
+
+	var cells []string
+	var b strings.Builder
+	for i := 0; i < len(text); i++ {
+		switch {
+		case text[i] == '\\' && i+1 < len(text) && text[i+1] == '|':
+			b.WriteByte('|')
+			i++
+		case text[i] == '|':
+			cells = append(cells, strings.TrimSpace(b.String()))
+			b.Reset()
+		default:
+			b.WriteByte(text[i])
+		}
+	}
+
+	return append(cells, strings.TrimSpace(b.String()))
+}
+
+// isDelimiterRow reports whether the cells are those of the row separating the header from the body,
+// like --- or :---:.
+func isDelimiterRow(cells []string) bool {
+	for _, c := range cells {
+		c = strings.TrimSuffix(strings.TrimPrefix(c, ":"), ":")
+		if c == "" || strings.Trim(c, "-") != "" {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WriteMarkdown converts the ruleset into a decision table and writes it in the Markdown format,
+// with aligned columns. See FromRuleset for the rulesets that can be converted.
+func WriteMarkdown(w io.Writer, rs *regula.Ruleset) error {
+	rows, err := FromRuleset(rs)
+	if err != nil {
+		return err
+	}
+
+	widths := make([]int, len(rows[0]))
+	for i := range widths {
+		widths[i] = 3
+	}
+	for _, row := range rows {
+		for i, c := range row {
+			row[i] = strings.Replace(c, "|", `\|`, -1)
+			if n := utf8.RuneCountInString(row[i]); n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	delimiter := make([]string, len(widths))
+	for i, n := range widths {
+		delimiter[i] = strings.Repeat("-", n)
+	}
+	rows = append(rows[:1], append([][]string{delimiter}, rows[1:]...)...)
+
+	bw := bufio.NewWriter(w)
+	for _, row := range rows {
+		bw.WriteString("|")
+		for i, c := range row {
+			bw.WriteString(" " + c + strings.Repeat(" ", widths[i]-utf8.RuneCountInString(c)) + " |")
+		}
+		bw.WriteString("\n")
+	}
+
+	return bw.Flush()
+}
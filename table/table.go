@@ -0,0 +1,529 @@
+/*
+Package table converts decision tables into rulesets and rulesets into decision tables.
+
+A decision table has one column per param, a last column holding the results and one row per rule,
+evaluated in order:
+
+	city          age:int64  result:float64
+	paris         18..65     3.0
+	lyon, nice    *          2.0
+	*             *          1.0
+
+The first row is the header. The header of a param column is the name of the param, optionally followed
+by its type, string by default. The header of the last column holds the result type of the ruleset, its name
+being ignored. Params and results can be of type string, bool, int64 or float64.
+
+Each cell of a param column holds a condition on the param:
+
+  - * or an empty cell matches any value
+  - a value, like paris, matches that value
+  - a comma separated list, like lyon, nice, matches any of the values
+  - a range, like 18..65, matches the values between its bounds, which are included. One of the bounds can be
+    omitted, e.g. 18.. or ..65. Ranges are only supported by int64 and float64 params.
+
+String values can be quoted using the Go syntax to hold commas, quotes or surrounding spaces, e.g. "a, b".
+
+A row is converted into a rule which expression is the conjunction of the conditions of its cells, using the
+Eq, In, GTE, LTE and And operators, and true if all the cells are wildcards.
+*/
+package table
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/heetch/regula"
+	"github.com/heetch/regula/rule"
+)
+
+// ErrMissingHeader is returned when converting a table without any row.
+var ErrMissingHeader = errors.New("missing header")
+
+// A CellError is returned when a cell of a decision table is invalid.
+type CellError struct {
+	// Row and Column locate the cell, starting at 1 with the header and the first column.
+	// Column is 0 if the error concerns the whole row.
+	Row, Column int
+	Msg         string
+}
+
+func (e *CellError) Error() string {
+	if e.Column == 0 {
+		return fmt.Sprintf("row %d: %s", e.Row, e.Msg)
+	}
+
+	return fmt.Sprintf("row %d, column %d: %s", e.Row, e.Column, e.Msg)
+}
+
+// A RuleError describes why a rule of a ruleset can't be represented in a decision table.
+type RuleError struct {
+	// Rule is the index of the rule in the ruleset.
+	Rule   int
+	Reason string
+}
+
+func (e RuleError) Error() string {
+	return fmt.Sprintf("/rules/%d: %s", e.Rule, e.Reason)
+}
+
+// RuleErrors is returned by FromRuleset when some rules can't be represented in a decision table.
+// It lists all of them.
+type RuleErrors []RuleError
+
+func (e RuleErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i := range e {
+		msgs[i] = e[i].Error()
+	}
+
+	return "rules can't be tabulated: " + strings.Join(msgs, "; ")
+}
+
+// ToRuleset converts the rows of a decision table, the first one being the header, into a ruleset.
+func ToRuleset(rows [][]string) (*regula.Ruleset, error) {
+	if len(rows) == 0 {
+		return nil, ErrMissingHeader
+	}
+
+	header := rows[0]
+	if len(header) == 0 {
+		return nil, &CellError{Row: 1, Msg: "the table must have a result column"}
+	}
+
+	params := make([]*rule.Param, len(header)-1)
+	seen := make(map[string]bool)
+	for i := range params {
+		p, err := parseHeader(header[i])
+		if err == nil && seen[p.Name] {
+			err = fmt.Errorf("duplicate param %s", p.Name)
+		}
+		if err != nil {
+			return nil, &CellError{Row: 1, Column: i + 1, Msg: err.Error()}
+		}
+
+		seen[p.Name] = true
+		params[i] = p
+	}
+
+	result, err := parseHeader(header[len(header)-1])
+	if err != nil {
+		return nil, &CellError{Row: 1, Column: len(header), Msg: err.Error()}
+	}
+
+	rules := make([]*rule.Rule, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		if len(row) != len(header) {
+			return nil, &CellError{Row: i + 2, Msg: fmt.Sprintf("expected %d cells, got %d", len(header), len(row))}
+		}
+
+		var conds []rule.Expr
+		for j, p := range params {
+			c, err := parseCondition(p, row[j])
+			if err != nil {
+				return nil, &CellError{Row: i + 2, Column: j + 1, Msg: err.Error()}
+			}
+			conds = append(conds, c...)
+		}
+
+		res, err := parseValue(result.Type, strings.TrimSpace(row[len(row)-1]))
+		if err != nil {
+			return nil, &CellError{Row: i + 2, Column: len(row), Msg: err.Error()}
+		}
+
+		var expr rule.Expr
+		switch len(conds) {
+		case 0:
+			expr = rule.True()
+		case 1:
+			expr = conds[0]
+		default:
+			expr = rule.And(conds[0], conds[1], conds[2:]...)
+		}
+
+		rules = append(rules, rule.New(expr, res))
+	}
+
+	switch result.Type {
+	case "bool":
+		return regula.NewBoolRuleset(rules...)
+	case "int64":
+		return regula.NewInt64Ruleset(rules...)
+	case "float64":
+		return regula.NewFloat64Ruleset(rules...)
+	}
+
+	return regula.NewStringRuleset(rules...)
+}
+
+func isSupportedType(typ string) bool {
+	switch typ {
+	case "string", "bool", "int64", "float64":
+		return true
+	}
+
+	return false
+}
+
+// parseHeader parses the header of a column, of the form name[:type].
+func parseHeader(cell string) (*rule.Param, error) {
+	cell = strings.TrimSpace(cell)
+
+	name, typ := cell, "string"
+	if idx := strings.IndexByte(cell, ':'); idx != -1 {
+		name, typ = strings.TrimSpace(cell[:idx]), strings.TrimSpace(cell[idx+1:])
+	}
+
+	if name == "" {
+		return nil, errors.New("missing name")
+	}
+	if !isSupportedType(typ) {
+		return nil, fmt.Errorf("unsupported type %s", typ)
+	}
+
+	return &rule.Param{Kind: "param", Type: typ, Name: name}, nil
+}
+
+// parseCondition returns the conditions on the param expressed by the cell, none if it is a wildcard.
+func parseCondition(p *rule.Param, cell string) ([]rule.Expr, error) {
+	cell = strings.TrimSpace(cell)
+	if cell == "" || cell == "*" {
+		return nil, nil
+	}
+
+	if idx := strings.Index(cell, ".."); idx != -1 && (p.Type == "int64" || p.Type == "float64") {
+		var conds []rule.Expr
+
+		if lo := strings.TrimSpace(cell[:idx]); lo != "" {
+			v, err := parseValue(p.Type, lo)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, rule.GTE(p, v))
+		}
+
+		if hi := strings.TrimSpace(cell[idx+2:]); hi != "" {
+			v, err := parseValue(p.Type, hi)
+			if err != nil {
+				return nil, err
+			}
+			conds = append(conds, rule.LTE(p, v))
+		}
+
+		if len(conds) == 0 {
+			return nil, errors.New("a range must have at least one bound")
+		}
+
+		return conds, nil
+	}
+
+	items, err := splitList(cell)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]rule.Expr, len(items))
+	for i, item := range items {
+		values[i], err = parseValue(p.Type, item)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(values) == 1 {
+		return []rule.Expr{rule.Eq(p, values[0])}, nil
+	}
+
+	return []rule.Expr{rule.In(p, values[0], values[1:]...)}, nil
+}
+
+// splitList splits a comma separated list of values, ignoring the commas within quoted strings.
+func splitList(cell string) ([]string, error) {
+	var items []string
+	var quoted, escaped bool
+
+	start := 0
+	for i, c := range cell {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case !quoted && c == ',':
+			items = append(items, strings.TrimSpace(cell[start:i]))
+			start = i + 1
+		}
+	}
+	if quoted {
+		return nil, fmt.Errorf("unterminated string in %s", cell)
+	}
+	items = append(items, strings.TrimSpace(cell[start:]))
+
+	for _, item := range items {
+		if item == "" {
+			return nil, fmt.Errorf("empty value in list %s", cell)
+		}
+	}
+
+	return items, nil
+}
+
+// parseValue parses a value of the given type. Strings may be quoted.
+func parseValue(typ, s string) (*rule.Value, error) {
+	switch typ {
+	case "string":
+		if !strings.HasPrefix(s, `"`) {
+			return rule.StringValue(s), nil
+		}
+
+		v, err := strconv.Unquote(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s", s)
+		}
+		return rule.StringValue(v), nil
+	case "bool":
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool value %q", s)
+		}
+		return rule.BoolValue(b), nil
+	case "int64":
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int64 value %q", s)
+		}
+		return rule.Int64Value(n), nil
+	case "float64":
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid float64 value %q", s)
+		}
+		return rule.Float64Value(f), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", typ)
+}
+
+// FromRuleset converts a ruleset into the rows of a decision table, the first one being the header.
+// The params are ordered by first use.
+//
+// Only first-match rulesets without default result and with the default missing params policy can be tabulated,
+// and each rule must be enabled, without validity window, return a value and have an expression made of conditions
+// that can be represented by a cell, combined with the and operator. Otherwise, RuleErrors lists the rules that can't
+// be tabulated. The declared params, test cases and the identifiers and documentation of the rules are not represented.
+func FromRuleset(rs *regula.Ruleset) ([][]string, error) {
+	switch {
+	case rs.Strategy != "" && rs.Strategy != regula.StrategyFirstMatch:
+		return nil, fmt.Errorf("the %s strategy can't be tabulated", rs.Strategy)
+	case rs.MissingParams != "" && rs.MissingParams != regula.MissingParamsError:
+		return nil, fmt.Errorf("the %s missing params policy can't be tabulated", rs.MissingParams)
+	case rs.Default != nil:
+		return nil, errors.New("rulesets with a default result can't be tabulated")
+	case !isSupportedType(rs.Type):
+		return nil, fmt.Errorf("rulesets of type %s can't be tabulated", rs.Type)
+	}
+
+	var params []rule.Param
+	types := make(map[string]string)
+
+	var errs RuleErrors
+	conds := make([]map[string]*condition, len(rs.Rules))
+	results := make([]string, len(rs.Rules))
+	for i, rl := range rs.Rules {
+		c, err := tabulate(rl, types)
+		if err != nil {
+			errs = append(errs, RuleError{Rule: i, Reason: err.Error()})
+			continue
+		}
+
+		for _, prm := range rl.Params() {
+			if _, ok := types[prm.Name]; !ok {
+				types[prm.Name] = prm.Type
+				params = append(params, prm)
+			}
+		}
+
+		conds[i] = c
+		results[i] = formatValue(rl.Result.(*rule.Value))
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	header := make([]string, 0, len(params)+1)
+	for _, p := range params {
+		header = append(header, formatHeader(p.Name, p.Type))
+	}
+	header = append(header, formatHeader("result", rs.Type))
+
+	rows := [][]string{header}
+	for i := range rs.Rules {
+		row := make([]string, 0, len(header))
+		for _, p := range params {
+			row = append(row, conds[i][p.Name].format())
+		}
+		rows = append(rows, append(row, results[i]))
+	}
+
+	return rows, nil
+}
+
+func formatHeader(name, typ string) string {
+	if typ == "string" {
+		return name
+	}
+
+	return name + ":" + typ
+}
+
+// condition holds the constraints of a rule on a param, represented by a cell.
+type condition struct {
+	// values holds the values matched by the cell, if it is a value or a list.
+	values []*rule.Value
+	// lo and hi hold the bounds of the cell, if it is a range.
+	lo, hi *rule.Value
+}
+
+func (c *condition) format() string {
+	if c == nil {
+		return "*"
+	}
+
+	if len(c.values) == 0 {
+		var lo, hi string
+		if c.lo != nil {
+			lo = formatValue(c.lo)
+		}
+		if c.hi != nil {
+			hi = formatValue(c.hi)
+		}
+		return lo + ".." + hi
+	}
+
+	items := make([]string, len(c.values))
+	for i, v := range c.values {
+		items[i] = formatValue(v)
+	}
+
+	return strings.Join(items, ", ")
+}
+
+// formatValue returns the representation of the value in a cell, strings being quoted only if necessary.
+func formatValue(v *rule.Value) string {
+	if v.Type == "string" {
+		s := v.Data
+		if s == "" || s == "*" || s != strings.TrimSpace(s) || strings.ContainsAny(s, `,"`) {
+			return strconv.Quote(s)
+		}
+		return s
+	}
+
+	s, err := rule.Format(v)
+	if err != nil {
+		return v.Data
+	}
+
+	return s
+}
+
+// tabulate returns the conditions of the rule on each param, or the reason why it can't be represented
+// by a row. types holds the types of the params used by the previous rules.
+func tabulate(rl *rule.Rule, types map[string]string) (map[string]*condition, error) {
+	switch {
+	case rl.Enabled != nil && !*rl.Enabled:
+		return nil, errors.New("disabled rules can't be tabulated")
+	case rl.ValidFrom != nil || rl.ValidUntil != nil:
+		return nil, errors.New("rules with a validity window can't be tabulated")
+	}
+
+	if _, ok := rl.Result.(*rule.Value); !ok {
+		return nil, errors.New("the result must be a value")
+	}
+
+	conds := make(map[string]*condition)
+	for _, e := range conjuncts(rl.Expr) {
+		if v, ok := e.(*rule.Value); ok && v.Type == "bool" && v.Data == "true" {
+			continue
+		}
+
+		kind, ops := rule.Operator(e)
+		if len(ops) < 2 {
+			return nil, unsupportedCondition(e)
+		}
+
+		p, ok := ops[0].(*rule.Param)
+		if !ok {
+			return nil, unsupportedCondition(e)
+		}
+		values := make([]*rule.Value, len(ops)-1)
+		for i, op := range ops[1:] {
+			if values[i], ok = op.(*rule.Value); !ok {
+				return nil, unsupportedCondition(e)
+			}
+		}
+
+		switch {
+		case p.Optional:
+			return nil, fmt.Errorf("optional param %s can't be tabulated", p.Name)
+		case !isSupportedType(p.Type):
+			return nil, fmt.Errorf("params of type %s can't be tabulated", p.Type)
+		case types[p.Name] != "" && types[p.Name] != p.Type:
+			return nil, fmt.Errorf("param %s must be of type %s", p.Name, types[p.Name])
+		}
+
+		c := conds[p.Name]
+		if c == nil {
+			c = new(condition)
+			conds[p.Name] = c
+		}
+
+		switch {
+		case (kind == "eq" && len(values) == 1) || kind == "in":
+			if c.values != nil || c.lo != nil || c.hi != nil {
+				return nil, fmt.Errorf("param %s has too many conditions", p.Name)
+			}
+			c.values = values
+		case (kind == "gte" || kind == "lte") && len(values) == 1 && (p.Type == "int64" || p.Type == "float64"):
+			bound := &c.lo
+			if kind == "lte" {
+				bound = &c.hi
+			}
+			if c.values != nil || *bound != nil {
+				return nil, fmt.Errorf("param %s has too many conditions", p.Name)
+			}
+			*bound = values[0]
+		default:
+			return nil, unsupportedCondition(e)
+		}
+	}
+
+	return conds, nil
+}
+
+// conjuncts returns the operands of the and operators e is made of, or e itself.
+func conjuncts(e rule.Expr) []rule.Expr {
+	kind, ops := rule.Operator(e)
+	if kind != "and" {
+		return []rule.Expr{e}
+	}
+
+	var conds []rule.Expr
+	for _, op := range ops {
+		conds = append(conds, conjuncts(op)...)
+	}
+
+	return conds
+}
+
+func unsupportedCondition(e rule.Expr) error {
+	s, err := rule.Format(e)
+	if err != nil {
+		return errors.New("unsupported condition")
+	}
+
+	return fmt.Errorf("unsupported condition %s", s)
+}
@@ -0,0 +1,168 @@
+package table_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/heetch/regula"
+	"github.com/heetch/regula/rule"
+	"github.com/heetch/regula/table"
+	"github.com/stretchr/testify/require"
+)
+
+const csvTable = `city,age:int64,premium:bool,result:float64
+paris,18..65,*,3.0
+"lyon, ""nice""",..17,true,2.5
+"""a, b""",*,,2.0
+*,*,*,1.0
+`
+
+func TestToRuleset(t *testing.T) {
+	rs, err := table.ReadCSV(strings.NewReader(csvTable))
+	require.NoError(t, err)
+
+	expected, err := regula.NewFloat64Ruleset(
+		rule.New(
+			rule.And(
+				rule.Eq(rule.StringParam("city"), rule.StringValue("paris")),
+				rule.GTE(rule.Int64Param("age"), rule.Int64Value(18)),
+				rule.LTE(rule.Int64Param("age"), rule.Int64Value(65)),
+			),
+			rule.Float64Value(3),
+		),
+		rule.New(
+			rule.And(
+				rule.In(rule.StringParam("city"), rule.StringValue("lyon"), rule.StringValue("nice")),
+				rule.LTE(rule.Int64Param("age"), rule.Int64Value(17)),
+				rule.Eq(rule.BoolParam("premium"), rule.BoolValue(true)),
+			),
+			rule.Float64Value(2.5),
+		),
+		rule.New(
+			rule.Eq(rule.StringParam("city"), rule.StringValue("a, b")),
+			rule.Float64Value(2),
+		),
+		rule.New(rule.True(), rule.Float64Value(1)),
+	)
+	require.NoError(t, err)
+	require.Equal(t, expected, rs)
+
+	res, err := rs.Eval(regula.StringParams{"city": "nice", "age": "12", "premium": "true"})
+	require.NoError(t, err)
+	require.Equal(t, rule.Float64Value(2.5), res)
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name string
+			rows [][]string
+			err  error
+		}{
+			{"no header", nil, table.ErrMissingHeader},
+			{"empty header", [][]string{{}}, &table.CellError{Row: 1, Msg: "the table must have a result column"}},
+			{"bad type", [][]string{{"a:time", "result"}}, &table.CellError{Row: 1, Column: 1, Msg: "unsupported type time"}},
+			{"duplicate", [][]string{{"a", "a", "result"}}, &table.CellError{Row: 1, Column: 2, Msg: "duplicate param a"}},
+			{"missing cell", [][]string{{"a", "result"}, {"x"}}, &table.CellError{Row: 2, Msg: "expected 2 cells, got 1"}},
+			{"bad value", [][]string{{"a:int64", "result"}, {"x", "y"}}, &table.CellError{Row: 2, Column: 1, Msg: `invalid int64 value "x"`}},
+			{"empty range", [][]string{{"a:int64", "result"}, {"..", "y"}}, &table.CellError{Row: 2, Column: 1, Msg: "a range must have at least one bound"}},
+			{"empty item", [][]string{{"a", "result"}, {"x,,y", "y"}}, &table.CellError{Row: 2, Column: 1, Msg: "empty value in list x,,y"}},
+			{"bad result", [][]string{{"a", "result:bool"}, {"x", "y"}}, &table.CellError{Row: 2, Column: 2, Msg: `invalid bool value "y"`}},
+		}
+
+		for _, test := range tests {
+			t.Run(test.name, func(t *testing.T) {
+				_, err := table.ToRuleset(test.rows)
+				require.Equal(t, test.err, err)
+			})
+		}
+	})
+}
+
+func TestFromRuleset(t *testing.T) {
+	t.Run("CSV", func(t *testing.T) {
+		rs, err := table.ReadCSV(strings.NewReader(csvTable))
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = table.WriteCSV(&buf, rs)
+		require.NoError(t, err)
+		require.Equal(t, `city,age:int64,premium:bool,result:float64
+paris,18..65,*,3.0
+"lyon, nice",..17,true,2.5
+"""a, b""",*,*,2.0
+*,*,*,1.0
+`, buf.String())
+
+		rs2, err := table.ReadCSV(&buf)
+		require.NoError(t, err)
+		require.Equal(t, rs, rs2)
+	})
+
+	t.Run("Markdown", func(t *testing.T) {
+		rs, err := regula.ParseRuleset(`
+			type string
+
+			zone:int64 in (1, 2) and rate:float64 >= 1.5 -> "a|b"
+			rate:float64 <= 1.0 and zone:int64 == 3 -> "c"
+			true -> "*"
+		`)
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		err = table.WriteMarkdown(&buf, rs)
+		require.NoError(t, err)
+		require.Equal(t, `| zone:int64 | rate:float64 | result |
+| ---------- | ------------ | ------ |
+| 1, 2       | 1.5..        | a\|b   |
+| 3          | ..1.0        | c      |
+| *          | *            | "*"    |
+`, buf.String())
+
+		rs2, err := table.ReadMarkdown(&buf)
+		require.NoError(t, err)
+		for i := range rs.Rules {
+			require.Equal(t, rs.Rules[i].Result, rs2.Rules[i].Result)
+			require.True(t, rule.Implies(rs.Rules[i].Expr, rs2.Rules[i].Expr))
+			require.True(t, rule.Implies(rs2.Rules[i].Expr, rs.Rules[i].Expr))
+		}
+	})
+
+	t.Run("Untabulated rules", func(t *testing.T) {
+		rs, err := regula.ParseRuleset(`
+			type int64
+
+			city == "paris" or city == "lyon" -> 1
+			city != "paris" -> 2
+			age:int64 > 18 -> 3
+			city == "paris" and city == "lyon" -> 4
+			promo:string? == "a" -> 5
+			true -> 6
+		`)
+		require.NoError(t, err)
+
+		_, err = table.FromRuleset(rs)
+		require.Equal(t, table.RuleErrors{
+			{Rule: 0, Reason: `unsupported condition city == "paris" or city == "lyon"`},
+			{Rule: 1, Reason: `unsupported condition city != "paris"`},
+			{Rule: 2, Reason: `unsupported condition age:int64 > 18`},
+			{Rule: 3, Reason: "param city has too many conditions"},
+			{Rule: 4, Reason: "optional param promo can't be tabulated"},
+		}, err)
+		require.EqualError(t, err, `rules can't be tabulated: /rules/0: unsupported condition city == "paris" or city == "lyon"; `+
+			`/rules/1: unsupported condition city != "paris"; /rules/2: unsupported condition age:int64 > 18; `+
+			`/rules/3: param city has too many conditions; /rules/4: optional param promo can't be tabulated`)
+	})
+
+	t.Run("Unsupported ruleset", func(t *testing.T) {
+		rs, err := regula.ParseRuleset(`
+			type int64
+			strategy sum
+
+			true -> 1
+		`)
+		require.NoError(t, err)
+
+		_, err = table.FromRuleset(rs)
+		require.EqualError(t, err, "the sum strategy can't be tabulated")
+	})
+}
@@ -0,0 +1,225 @@
+package regula
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/heetch/regula/rule"
+)
+
+// A TestCase is an example of evaluation of a ruleset, stored along with its rules.
+// It documents an expected behaviour and ensures new versions of the ruleset preserve it, see RunTests.
+type TestCase struct {
+	Name string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Params holds the params passed to the ruleset, encoded as strings, see StringParams.
+	Params map[string]string `json:"params,omitempty" yaml:"params,omitempty"`
+	// Expected is the result expected from the evaluation, nil if the ruleset is expected not to match.
+	Expected *rule.Value `json:"expected,omitempty" yaml:"expected,omitempty"`
+}
+
+// label returns the name of the test case, or its index if it has none.
+func (tc *TestCase) label(i int) string {
+	if tc.Name != "" {
+		return strconv.Quote(tc.Name)
+	}
+
+	return "#" + strconv.Itoa(i)
+}
+
+// A TestFailure describes a test case which evaluation didn't return the expected result.
+type TestFailure struct {
+	// Case is the index of the test case.
+	Case    int    `json:"case"`
+	Name    string `json:"name,omitempty"`
+	Message string `json:"message"`
+}
+
+func (f TestFailure) String() string {
+	tc := TestCase{Name: f.Name}
+	return fmt.Sprintf("test %s: %s", tc.label(f.Case), f.Message)
+}
+
+// RunTests evaluates the test cases of the ruleset and returns those which didn't return the expected result.
+func (r *Ruleset) RunTests() []TestFailure {
+	var failures []TestFailure
+
+	for i := range r.Tests {
+		tc := &r.Tests[i]
+
+		res, err := r.Eval(StringParams(tc.Params))
+		msg := checkTestResult(tc.Expected, res, err)
+		if msg != "" {
+			failures = append(failures, TestFailure{Case: i, Name: tc.Name, Message: msg})
+		}
+	}
+
+	return failures
+}
+
+// checkTestResult compares the result of an evaluation with the expected one, nil meaning no match,
+// and returns the reason of the failure if they differ.
+func checkTestResult(expected, res *rule.Value, err error) string {
+	if err != nil && err != rule.ErrNoMatch {
+		return "evaluation failed: " + err.Error()
+	}
+
+	switch {
+	case expected == nil && err == rule.ErrNoMatch:
+		return ""
+	case expected == nil:
+		return fmt.Sprintf("expected no match, got %s", formatTestResult(res))
+	case err == rule.ErrNoMatch:
+		return fmt.Sprintf("expected %s, got no match", formatTestResult(expected))
+	case expected.Type != res.Type || !expected.Equal(res):
+		return fmt.Sprintf("expected %s, got %s", formatTestResult(expected), formatTestResult(res))
+	}
+
+	return ""
+}
+
+func formatTestResult(v *rule.Value) string {
+	s, err := rule.Format(v)
+	if err != nil {
+		return v.Data
+	}
+
+	return s
+}
+
+// validateTests ensures the expected results of the test cases are values of the result type of the ruleset.
+func (r *Ruleset) validateTests() error {
+	for i, tc := range r.Tests {
+		if tc.Expected == nil {
+			continue
+		}
+
+		path := fmt.Sprintf("/tests/%d/expected", i)
+		typ, err := rule.TypeCheck(tc.Expected)
+		if err != nil {
+			if te, ok := err.(*rule.TypeError); ok {
+				return &rule.TypeError{Path: path + te.Path, Msg: te.Msg}
+			}
+			return err
+		}
+
+		if typ != r.ResultType() {
+			return &rule.TypeError{Path: path, Msg: fmt.Sprintf("the expected result must be of type %s", r.ResultType())}
+		}
+
+		if r.Type == "object" {
+			if err := r.checkObject(tc.Expected, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatTestCase returns the test case in the format of the test header, the params being sorted by name, e.g.
+//
+//	test "paris riders" age="30" city="paris" -> 3.0
+func formatTestCase(tc *TestCase) (string, error) {
+	var b strings.Builder
+
+	b.WriteString("test")
+	if tc.Name != "" || len(tc.Params) == 0 {
+		b.WriteString(" " + strconv.Quote(tc.Name))
+	}
+
+	names := make([]string, 0, len(tc.Params))
+	for name := range tc.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		fmt.Fprintf(&b, " %s=%s", name, strconv.Quote(tc.Params[name]))
+	}
+
+	if tc.Expected == nil {
+		b.WriteString(" -> no-match")
+		return b.String(), nil
+	}
+
+	s, err := rule.Format(tc.Expected)
+	if err != nil {
+		return "", err
+	}
+	b.WriteString(" -> " + s)
+
+	return b.String(), nil
+}
+
+// parseTestCase parses the fields of the test header, following the test keyword, of the form
+// ["name"] param=value... -> result, the result being a value or no-match.
+// It returns ok false if the fields are not of that form, in which case the line is not a header,
+// for instance if it is a rule using a param named test.
+func parseTestCase(line string) (tc *TestCase, ok bool, err error) {
+	tokens, err := splitHeader(line)
+	if err != nil {
+		return nil, false, nil
+	}
+
+	arrow := -1
+	for i, t := range tokens {
+		if !t.quoted && t.text == "->" {
+			arrow = i
+			break
+		}
+	}
+	if arrow <= 0 {
+		return nil, false, nil
+	}
+
+	tc = new(TestCase)
+	args := tokens[:arrow]
+	if args[0].quoted {
+		tc.Name = args[0].text
+		args = args[1:]
+	}
+
+	for _, a := range args {
+		idx := strings.IndexByte(a.raw, '=')
+		if idx <= 0 {
+			return nil, false, nil
+		}
+
+		name, v := a.raw[:idx], a.raw[idx+1:]
+		if strings.HasPrefix(v, `"`) {
+			v, err = strconv.Unquote(v)
+			if err != nil {
+				return nil, true, fmt.Errorf("invalid value of param %s", name)
+			}
+		}
+
+		if tc.Params == nil {
+			tc.Params = make(map[string]string)
+		}
+		tc.Params[name] = v
+	}
+
+	result := make([]string, 0, len(tokens)-arrow-1)
+	for _, t := range tokens[arrow+1:] {
+		result = append(result, t.raw)
+	}
+
+	if len(result) == 1 && result[0] == "no-match" {
+		return tc, true, nil
+	}
+
+	e, err := rule.Parse(strings.Join(result, " "))
+	if err != nil {
+		return nil, true, fmt.Errorf("invalid expected result: %v", err)
+	}
+
+	v, isValue := e.(*rule.Value)
+	if !isValue {
+		return nil, true, fmt.Errorf("the expected result must be a value")
+	}
+	tc.Expected = v
+
+	return tc, true, nil
+}
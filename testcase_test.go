@@ -0,0 +1,102 @@
+package regula
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/heetch/regula/rule"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunTests(t *testing.T) {
+	rs, err := ParseRuleset(`
+		type float64
+
+		city == "paris" and age:int64 >= 18 -> 3.0
+		city == "lyon" -> 2.0
+	`)
+	require.NoError(t, err)
+
+	rs.Tests = []TestCase{
+		{Name: "paris riders", Params: map[string]string{"city": "paris", "age": "30"}, Expected: rule.Float64Value(3)},
+		{Params: map[string]string{"city": "nice", "age": "30"}},
+		{Name: "wrong result", Params: map[string]string{"city": "lyon", "age": "30"}, Expected: rule.Float64Value(3)},
+		{Name: "unexpected match", Params: map[string]string{"city": "lyon", "age": "30"}},
+		{Name: "unexpected no match", Params: map[string]string{"city": "paris", "age": "10"}, Expected: rule.Float64Value(3)},
+		{Name: "missing param", Params: map[string]string{"city": "paris"}, Expected: rule.Float64Value(3)},
+	}
+	require.NoError(t, rs.validate())
+
+	failures := rs.RunTests()
+	require.Equal(t, []TestFailure{
+		{Case: 2, Name: "wrong result", Message: "expected 3.0, got 2.0"},
+		{Case: 3, Name: "unexpected match", Message: "expected no match, got 2.0"},
+		{Case: 4, Name: "unexpected no match", Message: "expected 3.0, got no match"},
+		{Case: 5, Name: "missing param", Message: "evaluation failed: " + rule.ErrParamNotFound.Error()},
+	}, failures)
+	require.Equal(t, `test "wrong result": expected 3.0, got 2.0`, failures[0].String())
+	require.Equal(t, `test #1: expected no match, got 2.0`, TestFailure{Case: 1, Message: "expected no match, got 2.0"}.String())
+
+	t.Run("Computed", func(t *testing.T) {
+		rs, err := ParseRuleset(`
+			type float64
+
+			true -> x:float64
+		`)
+		require.NoError(t, err)
+
+		var tc TestCase
+		err = json.Unmarshal([]byte(`{"params": {"x": "3.5"}, "expected": {"type": "float64", "data": "3.5"}}`), &tc)
+		require.NoError(t, err)
+		rs.Tests = []TestCase{tc}
+		require.Empty(t, rs.RunTests())
+	})
+
+	t.Run("Validation", func(t *testing.T) {
+		rs.Tests = []TestCase{{Expected: rule.StringValue("a")}}
+		require.EqualError(t, rs.validate(), "/tests/0/expected: the expected result must be of type float64")
+	})
+
+	t.Run("EncDec", func(t *testing.T) {
+		rs.Tests = []TestCase{{Name: "a", Params: map[string]string{"city": "lyon"}, Expected: rule.Float64Value(2)}}
+
+		raw, err := json.Marshal(rs)
+		require.NoError(t, err)
+
+		var rs2 Ruleset
+		err = json.Unmarshal(raw, &rs2)
+		require.NoError(t, err)
+		require.Equal(t, rs.Tests, rs2.Tests)
+	})
+}
+
+func TestParseRulesetTests(t *testing.T) {
+	src := `type string
+test "paris riders" age="30" city="paris" -> "a"
+test city="lyon #2" -> no-match
+test "" -> "b"
+
+city == "paris" -> "a"
+test == "x" -> "c"
+true -> "b"
+`
+	rs, err := ParseRuleset(src)
+	require.NoError(t, err)
+	require.Equal(t, []TestCase{
+		{Name: "paris riders", Params: map[string]string{"age": "30", "city": "paris"}, Expected: rule.StringValue("a")},
+		{Params: map[string]string{"city": "lyon #2"}},
+		{Expected: rule.StringValue("b")},
+	}, rs.Tests)
+	require.Len(t, rs.Rules, 3)
+
+	s, err := FormatRuleset(rs)
+	require.NoError(t, err)
+	require.Equal(t, src, s)
+
+	rs, err = ParseRuleset("type int64\ntest age=30 -> 3 # adults\n")
+	require.NoError(t, err)
+	require.Equal(t, []TestCase{{Params: map[string]string{"age": "30"}, Expected: rule.Int64Value(3)}}, rs.Tests)
+
+	_, err = ParseRuleset("type int64\ntest age=30 -> age:int64\n")
+	require.Equal(t, &rule.ParseError{Line: 2, Column: 6, Msg: "invalid test case: the expected result must be a value"}, err)
+}